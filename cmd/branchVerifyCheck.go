@@ -2,8 +2,11 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"strings"
 
+	"github.com/jedib0t/go-pretty/table"
 	"github.com/rancher/ob-charts-tool/internal/cmd/branchverifycheck"
 	"github.com/spf13/cobra"
 
@@ -15,6 +18,32 @@ import (
 var branchVerifyCheck = &cobra.Command{
 	Use:   "branchVerifyCheck",
 	Short: "Verify branch state for chart consistency and sequential versioning",
+	Long: `Verify branch state for chart consistency and sequential versioning.
+
+Use --list-checks to see the available checks. --only runs just the given checks
+(plus the checks they depend on) and --skip excludes checks along with everything
+that depends on them, e.g. --only sequential-version skips the slow build check.
+
+The build and image checks run in throwaway git worktrees of HEAD, one per package and
+in parallel, so uncommitted changes in the checkout are neither verified nor touched.
+
+The branch is compared with the main branch of --upstream-url (rancher/ob-team-charts by
+default), fetched into a temporary remote. Use --no-fetch to use an existing remote's last
+fetched state instead, or --base to compare with any local ref, e.g. --base origin/release-v2.12
+for release branches; neither needs network access.
+
+In CI, --per-commit verifies each commit of the branch on its own so a broken intermediate
+commit is caught, and --range A..B does the same for any range without checking it out.
+Only the modified packages and sequential version checks run for each commit.
+
+Use --format sarif to annotate pull requests with image policy violations and subchart
+tag mismatches at their values.yaml lines, or --format junit for one testcase per check.
+With --output-file the report is written to a file and the human-readable results are
+still printed, e.g. --format sarif --output-file results.sarif.
+
+Check details in the JSON output are wrapped as {"kind": ..., "data": ...}; --json-schema
+prints the schema of the JSON output and --from-result re-renders a saved JSON result in
+any format, e.g. --from-result results.json --format junit.`,
 	Args: func(_ *cobra.Command, args []string) error {
 		// Check that there is either one or zero args
 		if len(args) == 1 || len(args) == 0 {
@@ -33,13 +62,39 @@ var branchVerifyCheck = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(branchVerifyCheck)
-	branchVerifyCheck.Flags().Bool("json", false, "Output results in JSON format")
+	branchVerifyCheck.Flags().Bool("json", false, "Output results in JSON format (same as --format json)")
+	branchVerifyCheck.Flags().String("format", string(branchverifycheck.OutputFormatHuman), "Output format: human, json, sarif or junit")
+	branchVerifyCheck.Flags().String("output-file", "", "Write the --format output to this file and print human-readable results to stdout")
+	branchVerifyCheck.Flags().String("image-policy", "", "Path to an image policy YAML file (allowed registries, repository prefixes, forbidden tags, digest pinning and exemptions); defaults to registry=\"\" and rancher/ repositories")
+	branchVerifyCheck.Flags().StringSlice("only", nil, "Run only these check IDs (and the checks they depend on)")
+	branchVerifyCheck.Flags().StringSlice("skip", nil, "Skip these check IDs (and the checks that depend on them)")
+	branchVerifyCheck.Flags().Bool("list-checks", false, "List the available checks and exit")
+	branchVerifyCheck.Flags().Int("concurrency", 2, "Number of packages to build and check in parallel worktrees")
+	branchVerifyCheck.Flags().String("base", "", "Verify against this local ref or revision (e.g. origin/release-v2.12) instead of upstream main, without fetching")
+	branchVerifyCheck.Flags().String("upstream-url", branchverifycheck.CanonicalUpstreamURL, "URL of the canonical upstream repository")
+	branchVerifyCheck.Flags().Bool("no-fetch", false, "Use upstream main as last fetched by an existing remote pointing to --upstream-url instead of fetching it")
+	branchVerifyCheck.Flags().String("range", "", "Verify each commit of a range such as A..B (without checking it out) instead of the branch")
+	branchVerifyCheck.Flags().Bool("per-commit", false, "Verify each commit of the branch since its merge base instead of only HEAD")
+	branchVerifyCheck.Flags().String("from-result", "", "Re-render a result saved with --format json instead of verifying a branch")
+	branchVerifyCheck.Flags().Bool("json-schema", false, "Print the JSON Schema of the --format json output and exit")
+	branchVerifyCheck.Flags().Bool("in-place", false, "Run the build and image checks in the checkout instead of throwaway worktrees of HEAD (requires a clean checkout)")
 }
 
 func branchVerifyCheckHandler(cmd *cobra.Command, args []string) {
 	var repoPath string
 	var err error
 
+	registry := branchverifycheck.DefaultRegistry()
+	if listChecks, _ := cmd.Flags().GetBool("list-checks"); listChecks {
+		printCheckList(registry)
+		return
+	}
+
+	if printSchema, _ := cmd.Flags().GetBool("json-schema"); printSchema {
+		fmt.Print(string(branchverifycheck.JSONSchema()))
+		return
+	}
+
 	if len(args) > 0 {
 		repoPath = args[0]
 	} else {
@@ -49,8 +104,41 @@ func branchVerifyCheckHandler(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	jsonOutput, _ := cmd.Flags().GetBool("json")
-	result, err := branchverifycheck.VerifyBranch(repoPath, jsonOutput)
+	opts := branchverifycheck.Options{Registry: registry}
+	formatFlag, _ := cmd.Flags().GetString("format")
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+		formatFlag = string(branchverifycheck.OutputFormatJSON)
+	}
+	opts.Format, err = branchverifycheck.ParseOutputFormat(formatFlag)
+	if err != nil {
+		log.Log.Fatal(err)
+	}
+	opts.OutputFile, _ = cmd.Flags().GetString("output-file")
+	if resultPath, _ := cmd.Flags().GetString("from-result"); resultPath != "" {
+		renderSavedResult(resultPath, opts)
+		return
+	}
+	opts.Only, _ = cmd.Flags().GetStringSlice("only")
+	opts.Skip, _ = cmd.Flags().GetStringSlice("skip")
+	opts.Concurrency, _ = cmd.Flags().GetInt("concurrency")
+	opts.InPlace, _ = cmd.Flags().GetBool("in-place")
+	opts.BaseRef, _ = cmd.Flags().GetString("base")
+	opts.UpstreamURL, _ = cmd.Flags().GetString("upstream-url")
+	opts.NoFetch, _ = cmd.Flags().GetBool("no-fetch")
+	opts.CommitRange, _ = cmd.Flags().GetString("range")
+	opts.PerCommit, _ = cmd.Flags().GetBool("per-commit")
+	if policyPath, _ := cmd.Flags().GetString("image-policy"); policyPath != "" {
+		opts.ImagePolicy, err = branchverifycheck.LoadImagePolicy(policyPath)
+		if err != nil {
+			log.Log.Fatal(err)
+		}
+	}
+
+	verify := branchverifycheck.VerifyBranch
+	if opts.CommitRange != "" || opts.PerCommit {
+		verify = branchverifycheck.VerifyCommits
+	}
+	result, err := verify(repoPath, opts)
 	if err != nil {
 		log.Log.Fatal(err)
 	}
@@ -59,3 +147,41 @@ func branchVerifyCheckHandler(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 }
+
+// renderSavedResult re-renders a saved JSON result with the output options of opts,
+// exiting non-zero when the saved verification failed.
+func renderSavedResult(resultPath string, opts branchverifycheck.Options) {
+	f, err := os.Open(resultPath)
+	if err != nil {
+		log.Log.Fatal(err)
+	}
+	defer f.Close()
+
+	result, err := branchverifycheck.ReadResult(f)
+	if err != nil {
+		log.Log.Fatal(err)
+	}
+	if err := branchverifycheck.OutputResults(result, opts); err != nil {
+		log.Log.Fatal(err)
+	}
+	if !result.Success {
+		os.Exit(1)
+	}
+}
+
+// printCheckList prints the registered checks in run order.
+func printCheckList(registry *branchverifycheck.Registry) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"ID", "Scope", "Severity", "Depends On", "Description"})
+	for _, check := range registry.Checks() {
+		t.AppendRow(table.Row{
+			check.ID(),
+			check.Scope(),
+			check.Severity(),
+			strings.Join(check.Dependencies(), ", "),
+			check.Description(),
+		})
+	}
+	t.Render()
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/rancher/ob-charts-tool/helmtools/chart"
+	"github.com/rancher/ob-charts-tool/internal/cmd/chartimages"
+	"github.com/rancher/ob-charts-tool/internal/cmd/imagelist"
+	"github.com/rancher/ob-charts-tool/internal/logging"
+
+	"github.com/jedib0t/go-pretty/text"
+	"github.com/spf13/cobra"
+)
+
+// exportImagesCmd represents the exportImages command
+var exportImagesCmd = &cobra.Command{
+	Use:   "exportImages <version>",
+	Short: "Export the images a built chart version pulls for air-gapped installs",
+	Long: `Collects the images from every values.yaml of a built chart, including its subcharts under charts/ and the
+companion -crd chart, and writes a deduplicated, sorted images.txt (like rancher-images.txt) plus a sources.yaml
+mapping each image to the charts that reference it.
+
+The argument may be a version under --charts-dir/--chart or a chart directory. Use --os to export only the
+linux or windows images.`,
+	Args: cobra.ExactArgs(1),
+	Run:  exportImagesHandler,
+}
+
+func init() {
+	rootCmd.AddCommand(exportImagesCmd)
+	exportImagesCmd.Flags().String("chart", "rancher-monitoring", "Name of the chart under --charts-dir to export")
+	exportImagesCmd.Flags().String("charts-dir", "charts", "Directory containing built charts as <chart>/<version>")
+	exportImagesCmd.Flags().String("os", "", "Only export images for this OS: linux or windows (default all)")
+	exportImagesCmd.Flags().Bool("include-crd", true, "Also export images of the companion <chart>-crd chart when it exists")
+	exportImagesCmd.Flags().String("output-dir", ".", "Directory to write images.txt and sources.yaml to")
+}
+
+func exportImagesHandler(cmd *cobra.Command, args []string) {
+	chartName, _ := cmd.Flags().GetString("chart")
+	chartsDir, _ := cmd.Flags().GetString("charts-dir")
+	osName, _ := cmd.Flags().GetString("os")
+	includeCRD, _ := cmd.Flags().GetBool("include-crd")
+	outputDir, _ := cmd.Flags().GetString("output-dir")
+
+	chartDir, err := chartimages.ResolveChartDir(chartsDir, chartName, args[0])
+	if err != nil {
+		logging.Log.Fatal(err)
+	}
+	chartDirs := []string{chartDir}
+	if crdDir, ok := chart.CompanionCRDChartDir(chartDir); ok && includeCRD {
+		chartDirs = append(chartDirs, crdDir)
+	}
+	for _, dir := range chartDirs {
+		fmt.Println(text.Color.Sprintf(text.FgBlue, "Collecting images from `%s`...", dir))
+	}
+
+	refs, err := imagelist.Collect(chartDirs...)
+	if err != nil {
+		logging.Log.Fatal(err)
+	}
+	refs, err = imagelist.Filter(refs, osName)
+	if err != nil {
+		logging.Log.Fatal(err)
+	}
+
+	imagesPath, sourcesPath, err := imagelist.Write(outputDir, refs)
+	if err != nil {
+		logging.Log.Fatal(err)
+	}
+	fmt.Printf("Exported %d images to %s (sources in %s)\n", len(refs), imagesPath, sourcesPath)
+}
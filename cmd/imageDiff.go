@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rancher/ob-charts-tool/helmtools/image"
+	"github.com/rancher/ob-charts-tool/helmtools/version"
+	"github.com/rancher/ob-charts-tool/internal/cmd/chartimages"
+	"github.com/rancher/ob-charts-tool/internal/cmd/imagediff"
+	"github.com/rancher/ob-charts-tool/internal/logging"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/jedib0t/go-pretty/text"
+	"github.com/spf13/cobra"
+)
+
+// imageDiffCmd represents the imageDiff command
+var imageDiffCmd = &cobra.Command{
+	Use:   "imageDiff <old> <new>",
+	Short: "Compare the images of two chart versions",
+	Long: `Reports the images added, removed and re-tagged between two chart versions, with the charts they come from,
+their OS and the kind of version bump (major, minor, patch, prerelease, downgrade or unknown for non-semver tags).
+
+Each argument may be a version under --charts-dir/--chart, a chart directory, or a rebase.yaml file from
+monitoring:getRebaseInfo. Images are read from the values.yaml of the chart and all of its subcharts.
+
+Use --output json, yaml or markdown for machine-readable or PR-friendly results.`,
+	Args: cobra.ExactArgs(2),
+	Run:  imageDiffHandler,
+}
+
+func init() {
+	rootCmd.AddCommand(imageDiffCmd)
+	imageDiffCmd.Flags().String("chart", "rancher-monitoring", "Name of the chart under --charts-dir to compare")
+	imageDiffCmd.Flags().String("charts-dir", "charts", "Directory containing built charts as <chart>/<version>")
+	imageDiffCmd.Flags().StringP("output", "o", string(chartimages.OutputTable), "Output format: table, json, yaml or markdown")
+}
+
+func imageDiffHandler(cmd *cobra.Command, args []string) {
+	chartName, _ := cmd.Flags().GetString("chart")
+	chartsDir, _ := cmd.Flags().GetString("charts-dir")
+	outputFlag, _ := cmd.Flags().GetString("output")
+	outputFormat, err := chartimages.ParseOutputFormat(outputFlag)
+	if err != nil {
+		logging.Log.Fatal(err)
+	}
+
+	oldImages, err := imagediff.LoadImages(chartsDir, chartName, args[0])
+	if err != nil {
+		logging.Log.Fatal(err)
+	}
+	newImages, err := imagediff.LoadImages(chartsDir, chartName, args[1])
+	if err != nil {
+		logging.Log.Fatal(err)
+	}
+	diff := imagediff.NewDiff(args[0], args[1], oldImages, newImages)
+
+	if outputFormat.IsMachineReadable() {
+		if err := imagediff.WriteDiff(os.Stdout, diff, outputFormat); err != nil {
+			logging.Log.Fatal(err)
+		}
+		return
+	}
+
+	fmt.Println(text.Color.Sprintf(text.FgBlue, "Image changes between `%s` and `%s`:", args[0], args[1]))
+	if len(diff.Changes) == 0 {
+		fmt.Println(text.Color.Sprint(text.FgGreen, "No image changes."))
+		return
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"#", "Change", "Image", "Old Tag", "New Tag", "Bump", "OS", "Sources"})
+	for idx, change := range diff.Changes {
+		t.AppendRow(table.Row{
+			idx + 1,
+			changeKindLabel(change.Kind),
+			change.Image,
+			change.OldTag,
+			change.NewTag,
+			bumpLabel(version.Bump(change.Bump)),
+			change.OS,
+			strings.Join(change.Sources, "\n"),
+		})
+	}
+	t.Render()
+
+	counts := diff.Counts()
+	fmt.Printf("%d added, %d removed, %d re-tagged\n", counts[image.ChangeAdded], counts[image.ChangeRemoved], counts[image.ChangeRetagged])
+}
+
+// changeKindLabel renders an image change kind for the results table.
+func changeKindLabel(kind image.ChangeKind) string {
+	switch kind {
+	case image.ChangeAdded:
+		return "➕ added"
+	case image.ChangeRemoved:
+		return "➖ removed"
+	default:
+		return "🔁 retagged"
+	}
+}
+
+// bumpLabel highlights the version bumps that deserve a closer look.
+func bumpLabel(bump version.Bump) string {
+	switch bump {
+	case version.BumpMajor:
+		return text.Color.Sprint(text.FgYellow, bump)
+	case version.BumpDowngrade:
+		return text.Color.Sprint(text.FgRed, bump)
+	default:
+		return string(bump)
+	}
+}
@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/jedib0t/go-pretty/text"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/rancher/ob-charts-tool/cmd/groups"
+	"github.com/rancher/ob-charts-tool/internal/cmd/rebaseinfo"
+	"github.com/rancher/ob-charts-tool/internal/upstream"
+)
+
+// getRebaseInfoCmd represents the getRebaseInfo command
+var getRebaseInfoCmd = &cobra.Command{
+	Use:     "getRebaseInfo",
+	GroupID: groups.LoggingGroup.ID,
+	Short:   "Collect the basic information about a potential logging-operator rebase target version",
+	Args: func(_ *cobra.Command, args []string) error {
+		// Check if there's one argument provided
+		if len(args) == 1 {
+			return nil
+		}
+
+		return errors.New("you must provide the target upstream chart version")
+	},
+	Run: getRebaseInfoHandler,
+}
+
+func init() {
+	getRebaseInfoCmd.Flags().String("charts-repo", "", "Path to a charts repository checkout to plan the rebase of its current package against")
+	getRebaseInfoCmd.Flags().String("package", "rancher-logging", "Package in --charts-repo that is rebased")
+	getRebaseInfoCmd.Flags().Bool("include-prereleases", false, "Allow chart dependencies to resolve to pre-release versions")
+}
+
+func getRebaseInfoHandler(cmd *cobra.Command, args []string) {
+	chartsRepo, _ := cmd.Flags().GetString("charts-repo")
+	packageName, _ := cmd.Flags().GetString("package")
+	includePrereleases, _ := cmd.Flags().GetBool("include-prereleases")
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println("This command will do a series of web requests to identify information about the chart rebase.")
+	targetChartVersion := args[0]
+	fmt.Println(
+		text.AlignCenter.Apply(
+			text.Color.Sprintf(text.FgBlue, "Looking for upstream logging-operator chart with version `%s`...", targetChartVersion),
+			75,
+		),
+	)
+
+	// VerifyTagExists will either exit or return the tag reference and hash for a given chart version.
+	tagRef, hash := rebaseinfo.VerifyTagExists(upstream.LoggingOperator, targetChartVersion)
+	rebaseInfoState := rebaseinfo.CollectInfo(upstream.LoggingOperator, targetChartVersion, tagRef, hash, includePrereleases)
+
+	log.Debug(rebaseInfoState)
+	fmt.Println("Rebase information has been collected and will be saved to `rebase.yaml` file.")
+
+	if chartsRepo != "" {
+		plan, err := rebaseinfo.PlanRebase(chartsRepo, packageName, upstream.LoggingOperator, rebaseInfoState)
+		if err != nil {
+			log.Fatal(err)
+		}
+		rebaseInfoState.Plan = plan
+		rebaseinfo.PrintPlan(plan)
+	}
+
+	savedRebaseInfoFilePath := rebaseInfoState.SaveStateToRebaseYaml(cwd)
+	fmt.Println("The rebase information is saved at: " + savedRebaseInfoFilePath)
+}
@@ -0,0 +1,87 @@
+package monitoring
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/jedib0t/go-pretty/text"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/rancher/ob-charts-tool/cmd/groups"
+	"github.com/rancher/ob-charts-tool/internal/cmd/applyrebase"
+	"github.com/rancher/ob-charts-tool/internal/rebase"
+)
+
+// applyRebaseCmd represents the applyRebase command
+var applyRebaseCmd = &cobra.Command{
+	Use:     "applyRebase [rebase.yaml]",
+	GroupID: groups.MonitoringGroup.ID,
+	Short:   "Apply a rebase.yaml to the rancher-monitoring package of a charts repository",
+	Long: `Rebases the current rancher-monitoring package onto the upstream commit of a rebase.yaml (from
+monitoring:getRebaseInfo, the default).
+
+When the upstream major.minor version changes, the current packages/rancher-monitoring/<version> directory is
+copied to a new one as a starting point. The package.yaml commit and version are then updated, following the
+-rancher.N convention, and the subchart_tag_expectations are applied to the values files of the package.
+
+Nothing is committed, so the edits can be reviewed with git diff. Values patches that change an expected tag
+cannot be edited in place: they are listed and the command fails until they are regenerated with the chart
+build scripts.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  applyRebaseHandler,
+}
+
+func init() {
+	applyRebaseCmd.Flags().String("charts-repo", ".", "Path to the charts repository checkout to edit")
+	applyRebaseCmd.Flags().String("package", "rancher-monitoring", "Package in --charts-repo that is rebased")
+}
+
+func applyRebaseHandler(cmd *cobra.Command, args []string) {
+	chartsRepo, _ := cmd.Flags().GetString("charts-repo")
+	packageName, _ := cmd.Flags().GetString("package")
+
+	rebaseFile := "rebase.yaml"
+	if len(args) == 1 {
+		rebaseFile = args[0]
+	}
+	rebaseInfo, err := rebase.LoadRebaseYaml(rebaseFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	result, err := applyrebase.Apply(chartsRepo, packageName, rebaseInfo)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if result.CopiedFrom != "" {
+		fmt.Printf("Copied %s to %s\n", result.CopiedFrom, result.VersionDir)
+	}
+	fmt.Printf("Updated %s: version %s → %s, commit %s\n", result.PackageYAML, result.OldVersion, result.NewVersion, rebaseInfo.FoundChart.CommitHash)
+
+	if len(result.TagUpdates) > 0 || len(result.MissingTags) > 0 {
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"File", "Key", "Old", "New"})
+		for _, update := range result.TagUpdates {
+			t.AppendRow(table.Row{update.File, update.Key, update.OldValue, update.NewValue})
+		}
+		for _, missing := range result.MissingTags {
+			t.AppendRow(table.Row{missing.File, missing.Key, text.Color.Sprint(text.FgYellow, "not found"), missing.NewValue})
+		}
+		t.Render()
+	}
+
+	if len(result.StalePatches) > 0 {
+		fmt.Println("")
+		fmt.Println(text.Color.Sprint(text.FgRed, "These values patches change subchart tags and are now stale:"))
+		for _, patch := range result.StalePatches {
+			fmt.Println("  " + patch)
+		}
+		printSubchartChecklist(rebaseInfo)
+		log.Fatalf("%d values patches were left stale; regenerate them with the expected subchart tags, then review the changes with `git diff`", len(result.StalePatches))
+	}
+	fmt.Println("Review the changes with `git diff`.")
+}
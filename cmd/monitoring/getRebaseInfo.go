@@ -12,6 +12,7 @@ import (
 	"github.com/rancher/ob-charts-tool/cmd/groups"
 	"github.com/rancher/ob-charts-tool/internal/cmd/rebaseinfo"
 	"github.com/rancher/ob-charts-tool/internal/rebase"
+	"github.com/rancher/ob-charts-tool/internal/upstream"
 )
 
 // getRebaseInfoCmd represents the getRebaseInfo command
@@ -30,7 +31,17 @@ var getRebaseInfoCmd = &cobra.Command{
 	Run: getRebaseInfoHandler,
 }
 
-func getRebaseInfoHandler(_ *cobra.Command, args []string) {
+func init() {
+	getRebaseInfoCmd.Flags().String("charts-repo", "", "Path to a charts repository checkout to plan the rebase of its current package against")
+	getRebaseInfoCmd.Flags().String("package", "rancher-monitoring", "Package in --charts-repo that is rebased")
+	getRebaseInfoCmd.Flags().Bool("include-prereleases", false, "Allow chart dependencies to resolve to pre-release versions")
+}
+
+func getRebaseInfoHandler(cmd *cobra.Command, args []string) {
+	chartsRepo, _ := cmd.Flags().GetString("charts-repo")
+	packageName, _ := cmd.Flags().GetString("package")
+	includePrereleases, _ := cmd.Flags().GetBool("include-prereleases")
+
 	cwd, err := os.Getwd()
 	if err != nil {
 		panic(err)
@@ -46,22 +57,32 @@ func getRebaseInfoHandler(_ *cobra.Command, args []string) {
 	)
 
 	// VerifyTagExists will either exit or return the tag reference and hash for a given chart version.
-	tagRef, hash := rebaseinfo.VerifyTagExists(targetChartVersion)
-	rebaseInfoState := rebaseinfo.CollectInfo(targetChartVersion, tagRef, hash)
+	tagRef, hash := rebaseinfo.VerifyTagExists(upstream.KubePrometheusStack, targetChartVersion)
+	rebaseInfoState := rebaseinfo.CollectInfo(upstream.KubePrometheusStack, targetChartVersion, tagRef, hash, includePrereleases)
 
 	/// Some of these TODOs might be better as new commands, some may live here
 	// TODO: Compare the found images for updated patch releases
 	// TODO: Compare the found images to those used in existing Rancher chart somehow
-	// TODO: Consider adding checks against "rancher/image-mirror" repo?
 
 	log.Debug(rebaseInfoState)
 	fmt.Println("Rebase information has been collected and will be saved to `rebase.yaml` file.")
 
 	rebaseInfoState.PopulateSubchartTagExpectations()
+	if chartsRepo != "" {
+		plan, err := rebaseinfo.PlanRebase(chartsRepo, packageName, upstream.KubePrometheusStack, rebaseInfoState)
+		if err != nil {
+			log.Fatal(err)
+		}
+		rebaseInfoState.Plan = plan
+		rebaseinfo.PrintPlan(plan)
+	}
+
 	savedRebaseInfoFilePath := rebaseInfoState.SaveStateToRebaseYaml(cwd)
 	fmt.Println("The rebase information is saved at: " + savedRebaseInfoFilePath)
 
 	printSubchartChecklist(rebaseInfoState)
+	fmt.Println("Run `monitoring:imageMirrorEntries` to find the rancher/image-mirror entries these images need.")
+	fmt.Println("Run `monitoring:applyRebase` to apply the rebase to a charts repository.")
 }
 
 // printSubchartChecklist prints the pre-computed subchart tag expectations to the console
@@ -0,0 +1,125 @@
+package monitoring
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/jedib0t/go-pretty/text"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/rancher/ob-charts-tool/cmd/groups"
+	"github.com/rancher/ob-charts-tool/helmtools/registry"
+	"github.com/rancher/ob-charts-tool/internal/cmd/chartimages"
+	"github.com/rancher/ob-charts-tool/internal/cmd/imagemirror"
+	"github.com/rancher/ob-charts-tool/internal/rebase"
+)
+
+// imageMirrorEntriesCmd represents the imageMirrorEntries command
+var imageMirrorEntriesCmd = &cobra.Command{
+	Use:     "imageMirrorEntries [rebase.yaml]",
+	GroupID: groups.MonitoringGroup.ID,
+	Short:   "Print the rancher/image-mirror entries needed for upstream chart images",
+	Long: `Maps each upstream image to its Rancher mirrored name (e.g. quay.io/prometheus/node-exporter →
+rancher/mirrored-prometheus-node-exporter), checks which mirrored images are missing, and prints the
+"SOURCE DESTINATION TAG" lines to add to the rancher/image-mirror images-list file.
+
+Images are read from a rebase.yaml (from monitoring:getRebaseInfo, the default) or from upstream values.yaml files
+given with --values. The naming table defaults to the built-in rules; use --rules to load a YAML file with a
+"prefix" and "overrides" (source, destination, reason) that are merged over them.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  imageMirrorEntriesHandler,
+}
+
+func init() {
+	imageMirrorEntriesCmd.Flags().StringArrayP("values", "f", nil, "Upstream values.yaml files to read images from instead of rebase.yaml (can be repeated)")
+	imageMirrorEntriesCmd.Flags().String("app-version", "", "Tag used for images without a tag in --values files (usually the chart appVersion)")
+	imageMirrorEntriesCmd.Flags().String("rules", "", "YAML file with upstream to Rancher naming rules merged over the built-in table")
+	imageMirrorEntriesCmd.Flags().Bool("all", false, "Print entries for every image, not only the missing ones")
+	imageMirrorEntriesCmd.Flags().String("docker-config", registry.DefaultDockerConfigPath(), "Path to a docker config.json with registry credentials")
+	imageMirrorEntriesCmd.Flags().Int("concurrency", registry.DefaultConcurrency, "Number of images to check in parallel")
+}
+
+func imageMirrorEntriesHandler(cmd *cobra.Command, args []string) {
+	valueFiles, _ := cmd.Flags().GetStringArray("values")
+	appVersion, _ := cmd.Flags().GetString("app-version")
+	rulesPath, _ := cmd.Flags().GetString("rules")
+	printAll, _ := cmd.Flags().GetBool("all")
+	dockerConfigPath, _ := cmd.Flags().GetString("docker-config")
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+
+	rules := imagemirror.DefaultRules()
+	if rulesPath != "" {
+		var err error
+		if rules, err = imagemirror.LoadRules(rulesPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var images []string
+	if len(valueFiles) > 0 {
+		for _, valuesFile := range valueFiles {
+			data, err := os.ReadFile(valuesFile)
+			if err != nil {
+				log.Fatal(err)
+			}
+			found, err := imagemirror.ValuesImages(data, appVersion)
+			if err != nil {
+				log.Fatalf("Failed to read images from %s: %v", valuesFile, err)
+			}
+			images = append(images, found...)
+		}
+	} else {
+		rebaseFile := "rebase.yaml"
+		if len(args) == 1 {
+			rebaseFile = args[0]
+		}
+		rebaseInfo, err := rebase.LoadRebaseYaml(rebaseFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		var skipped []string
+		images, skipped = rebaseInfo.ImageNames()
+		for _, img := range skipped {
+			log.Warnf("Skipping image that cannot be parsed: %s", img)
+		}
+	}
+
+	entries, skipped := imagemirror.BuildEntries(images, rules)
+	for _, img := range skipped {
+		log.Infof("No mirror entry for %s: %s", img.Image, img.Reason)
+	}
+
+	registryClient, err := chartimages.NewRegistryClient(dockerConfigPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Checking %d mirrored images...\n", len(entries))
+	checks := imagemirror.CheckEntries(registryClient, entries, concurrency)
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"#", "Source", "Mirrored Image", "Status"})
+	var toAdd []imagemirror.Entry
+	for idx, check := range checks {
+		t.AppendRow(table.Row{idx + 1, check.Source + ":" + check.Tag, check.MirroredImage(), chartimages.StatusIcon(check.Result.Status)})
+		if printAll || check.Missing() {
+			toAdd = append(toAdd, check.Entry)
+		}
+		if !check.Missing() && !check.Result.Exists() {
+			log.Warnf("Could not check %s: %v", check.MirroredImage(), check.Result.Err)
+		}
+	}
+	t.Render()
+
+	if len(toAdd) == 0 {
+		fmt.Println(text.Color.Sprint(text.FgGreen, "All images are already mirrored."))
+		return
+	}
+	fmt.Println("")
+	fmt.Println(text.Color.Sprint(text.FgYellow, "Add these lines to the rancher/image-mirror images-list:"))
+	for _, entry := range toAdd {
+		fmt.Println(entry.String())
+	}
+}
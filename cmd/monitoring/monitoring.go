@@ -9,7 +9,10 @@ import (
 
 func subCommandList() []*cobra.Command {
 	return []*cobra.Command{
+		applyRebaseCmd,
 		getRebaseInfoCmd,
+		imageMirrorEntriesCmd,
+		pinImageDigestsCmd,
 		testNewVersionCmd,
 	}
 }
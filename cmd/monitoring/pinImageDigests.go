@@ -0,0 +1,150 @@
+package monitoring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/jedib0t/go-pretty/text"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/rancher/ob-charts-tool/cmd/groups"
+	"github.com/rancher/ob-charts-tool/helmtools/registry"
+	"github.com/rancher/ob-charts-tool/helmtools/render"
+	"github.com/rancher/ob-charts-tool/internal/cmd/chartimages"
+	"github.com/rancher/ob-charts-tool/internal/cmd/pindigests"
+	"github.com/rancher/ob-charts-tool/internal/rebase"
+)
+
+// pinImageDigestsCmd represents the pinImageDigests command
+var pinImageDigestsCmd = &cobra.Command{
+	Use:     "pinImageDigests [rebase.yaml]",
+	GroupID: groups.MonitoringGroup.ID,
+	Short:   "Resolve chart image tags to manifest digests and record them for audits",
+	Long: `Resolves every image tag to the manifest digest it currently points to.
+
+By default the images come from the charts_images_lists of a rebase.yaml (from monitoring:getRebaseInfo), and the
+digests are written back to it under image_digests. With --chart-dir the given built chart is rendered instead and
+the digests are only printed.
+
+Use --values-overlay to also write a values file that pins each image's sha/digest field, so drift between QA and
+release can be detected by rendering with -f <overlay>.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  pinImageDigestsHandler,
+}
+
+func init() {
+	pinImageDigestsCmd.Flags().String("chart-dir", "", "Render this chart directory instead of reading images from rebase.yaml")
+	pinImageDigestsCmd.Flags().StringArrayP("values", "f", nil, "Values files to render --chart-dir with (can be repeated)")
+	pinImageDigestsCmd.Flags().String("values-overlay", "", "Write a values file pinning the resolved digests to this path")
+	pinImageDigestsCmd.Flags().String("docker-config", registry.DefaultDockerConfigPath(), "Path to a docker config.json with registry credentials")
+	pinImageDigestsCmd.Flags().Int("concurrency", registry.DefaultConcurrency, "Number of images to resolve in parallel")
+}
+
+func pinImageDigestsHandler(cmd *cobra.Command, args []string) {
+	chartDir, _ := cmd.Flags().GetString("chart-dir")
+	overlayPath, _ := cmd.Flags().GetString("values-overlay")
+	dockerConfigPath, _ := cmd.Flags().GetString("docker-config")
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+
+	rebaseFile := "rebase.yaml"
+	if len(args) == 1 {
+		rebaseFile = args[0]
+	}
+	if chartDir != "" && len(args) == 1 {
+		log.Fatal(errors.New("provide either a rebase.yaml file or --chart-dir, not both"))
+	}
+
+	var (
+		images     []string
+		skipped    []string
+		rebaseInfo rebase.ChartRebaseInfo
+		err        error
+	)
+	if chartDir != "" {
+		fmt.Println(text.Color.Sprintf(text.FgBlue, "Rendering chart at `%s`...", chartDir))
+		valueFiles, _ := cmd.Flags().GetStringArray("values")
+		chartName := filepath.Base(filepath.Dir(filepath.Clean(chartDir)))
+		var rendered string
+		rendered, err = chartimages.RenderChart(chartDir, render.Options{
+			ReleaseName: chartName,
+			Values:      render.ValuesOptions{ValueFiles: valueFiles},
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		images, skipped, err = pindigests.RenderedImages(rendered)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		rebaseInfo, err = rebase.LoadRebaseYaml(rebaseFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		images, skipped = rebaseInfo.ImageNames()
+	}
+	for _, img := range skipped {
+		log.Warnf("Skipping image without a resolvable tag: %s", img)
+	}
+
+	registryClient, err := chartimages.NewRegistryClient(dockerConfigPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Resolving digests for %d images...\n", len(images))
+	results := registryClient.ResolveDigests(context.Background(), images, concurrency)
+	resolvedAt := time.Now()
+	printDigestResults(results)
+
+	digests := pindigests.DigestMap(results)
+	if chartDir == "" {
+		rebaseInfo.RecordImageDigests(results, resolvedAt)
+		savedRebaseInfoFilePath := rebaseInfo.SaveStateToRebaseYaml(filepath.Dir(rebaseFile))
+		fmt.Println("The image digests are saved at: " + savedRebaseInfoFilePath)
+	}
+
+	if overlayPath != "" {
+		var (
+			overlay map[string]interface{}
+			pinned  int
+		)
+		if chartDir != "" {
+			overlay, pinned, err = pindigests.ChartValuesOverlay(chartDir, digests)
+		} else {
+			overlay, pinned, err = rebaseInfo.DigestValuesOverlay()
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := pindigests.WriteValuesOverlay(overlayPath, overlay, resolvedAt); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Pinned %d image values in: %s\n", pinned, overlayPath)
+	}
+
+	if failed := pindigests.FailedResults(results); len(failed) > 0 {
+		log.Errorf("Failed to resolve %d of %d image digests", len(failed), len(results))
+		os.Exit(1)
+	}
+}
+
+// printDigestResults prints the resolved digest, or the lookup error, for each image.
+func printDigestResults(results []registry.DigestResult) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"#", "Image", "Digest"})
+	for idx, result := range results {
+		digest := result.Digest
+		if result.Err != nil {
+			digest = text.Color.Sprintf(text.FgRed, "❌ %v", result.Err)
+		}
+		t.AppendRow(table.Row{idx + 1, result.Image, digest})
+	}
+	t.Render()
+}
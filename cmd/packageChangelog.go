@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/rancher/ob-charts-tool/internal/cmd/changelog"
+	"github.com/spf13/cobra"
+
+	log "github.com/rancher/ob-charts-tool/internal/logging"
+)
+
+// packageChangelogCmd represents the packageChangelog command
+var packageChangelogCmd = &cobra.Command{
+	Use:   "packageChangelog <package> [repo path]",
+	Short: "Generate Markdown release notes for a package from git history",
+	Long: `Generate Markdown release notes for a package between two built chart versions.
+
+The notes list the subjects of the commits touching packages/<package>/ since the commit
+that added the --from chart to charts/<package>/, up to the commit that added the --to chart
+(or HEAD when it is not built yet). --to defaults to the version in the package.yaml and
+--from to the newest built version older than it.
+
+With --rebase-yaml (from monitoring:getRebaseInfo) the notes also include the upstream chart
+version and the subchart version and appVersion changes compared to the --from chart.`,
+	Args: func(_ *cobra.Command, args []string) error {
+		if len(args) == 1 || len(args) == 2 {
+			return nil
+		}
+		return errors.New("you must provide the package name and optionally the repo path")
+	},
+	Run: packageChangelogHandler,
+}
+
+func init() {
+	rootCmd.AddCommand(packageChangelogCmd)
+	packageChangelogCmd.Flags().String("from", "", "Previous built chart version (defaults to the newest built version older than --to)")
+	packageChangelogCmd.Flags().String("to", "", "New chart version (defaults to the version in the package.yaml)")
+	packageChangelogCmd.Flags().String("rebase-yaml", "", "rebase.yaml describing the upstream chart and subcharts of the new version")
+	packageChangelogCmd.Flags().String("output-file", "", "Write the Markdown to this file instead of stdout")
+}
+
+func packageChangelogHandler(cmd *cobra.Command, args []string) {
+	opts := changelog.Options{Package: args[0]}
+	opts.FromVersion, _ = cmd.Flags().GetString("from")
+	opts.ToVersion, _ = cmd.Flags().GetString("to")
+	opts.RebaseYAML, _ = cmd.Flags().GetString("rebase-yaml")
+	outputFile, _ := cmd.Flags().GetString("output-file")
+
+	repoPath := ""
+	if len(args) == 2 {
+		repoPath = args[1]
+	} else {
+		var err error
+		if repoPath, err = os.Getwd(); err != nil {
+			log.Log.Fatal(err)
+		}
+	}
+
+	notes, err := changelog.Generate(repoPath, opts)
+	if err != nil {
+		log.Log.Fatal(err)
+	}
+
+	if outputFile == "" {
+		fmt.Print(notes.Markdown())
+		return
+	}
+	if err := os.WriteFile(outputFile, []byte(notes.Markdown()), 0644); err != nil {
+		log.Log.Fatal(err)
+	}
+	fmt.Println("The release notes are saved at: " + outputFile)
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/rancher/ob-charts-tool/cmd/groups"
+	loggingcmd "github.com/rancher/ob-charts-tool/cmd/logging"
 	"github.com/rancher/ob-charts-tool/cmd/monitoring"
 	"github.com/rancher/ob-charts-tool/internal/logging"
 
@@ -80,6 +81,8 @@ func init() {
 	// Init groups then load commands that depend on groups
 	rootCmd.AddGroup(&groups.MonitoringGroup)
 	monitoring.RegisterMonitoringSubcommands(rootCmd)
+	rootCmd.AddGroup(&groups.LoggingGroup)
+	loggingcmd.RegisterLoggingSubcommands(rootCmd)
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -1,14 +1,15 @@
 package cmd
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"strings"
 
+	"github.com/rancher/ob-charts-tool/helmtools/image"
+	"github.com/rancher/ob-charts-tool/helmtools/registry"
+	"github.com/rancher/ob-charts-tool/helmtools/render"
 	helpers "github.com/rancher/ob-charts-tool/internal/cmd"
 	"github.com/rancher/ob-charts-tool/internal/cmd/chartimages"
 	"github.com/rancher/ob-charts-tool/internal/logging"
@@ -22,8 +23,14 @@ import (
 var verifyChartImagesCmd = &cobra.Command{
 	Use:   "verifyChartImages",
 	Short: "Verify that the rancher mirrored images for a target monitoring chart exist",
-	Long: `Using either a version as first arg, or helm chart debug output from STDIN, this command will output a list
-of the necessary images used in the chart. And then verify those are mirrored by the Rancher Image mirror.`,
+	Long: `Using either a version (or chart directory) as first arg, or helm chart debug output from STDIN, this command will
+output a list of the necessary images used in the chart. And then verify those are mirrored by the Rancher Image mirror.
+
+Charts are rendered in-process (no helm binary required). Use --chart to pick any chart under --charts-dir and
+-f/--values, --set and --set-string to customise the render, e.g. ` + "`-f debug.yaml`" + `.
+
+Use --output json, yaml, junit or markdown for CI-friendly results. The command exits non-zero when any
+Rancher image is missing (or lacks a required platform) or any image uses the wrong source registry.`,
 	Args: func(_ *cobra.Command, args []string) error {
 		// Check if there's one argument provided
 		if len(args) == 1 {
@@ -42,51 +49,69 @@ of the necessary images used in the chart. And then verify those are mirrored by
 
 func init() {
 	rootCmd.AddCommand(verifyChartImagesCmd)
+	verifyChartImagesCmd.Flags().String("docker-config", registry.DefaultDockerConfigPath(), "Path to a docker config.json with registry credentials")
+	verifyChartImagesCmd.Flags().Int("concurrency", registry.DefaultConcurrency, "Number of images to check in parallel")
+	verifyChartImagesCmd.Flags().String("chart", "rancher-monitoring", "Name of the chart under --charts-dir to render")
+	verifyChartImagesCmd.Flags().String("charts-dir", "charts", "Directory containing built charts as <chart>/<version>")
+	verifyChartImagesCmd.Flags().StringArrayP("values", "f", nil, "Values files to render the chart with (can be repeated)")
+	verifyChartImagesCmd.Flags().StringArray("set", nil, "Set values on the command line (can be repeated or comma separated: key1=val1,key2=val2)")
+	verifyChartImagesCmd.Flags().StringArray("set-string", nil, "Set STRING values on the command line (can be repeated)")
+	verifyChartImagesCmd.Flags().StringP("namespace", "n", "cattle-monitoring-system", "Namespace to render the chart into")
+	verifyChartImagesCmd.Flags().String("release-name", "", "Release name used when rendering (defaults to the chart name)")
+	verifyChartImagesCmd.Flags().String("kube-version", "", "Kubernetes version used for .Capabilities when rendering")
+	verifyChartImagesCmd.Flags().StringP("output", "o", string(chartimages.OutputTable), "Output format: table, json, yaml, junit or markdown")
+	verifyChartImagesCmd.Flags().Bool("check-platforms", true, "Check that images provide linux/arm64 and, for windows images, a windows manifest")
 }
 
-func verifyChartImagesHandler(_ *cobra.Command, args []string) {
+func verifyChartImagesHandler(cmd *cobra.Command, args []string) {
+	outputFlag, _ := cmd.Flags().GetString("output")
+	outputFormat, err := chartimages.ParseOutputFormat(outputFlag)
+	if err != nil {
+		logging.Log.Fatal(err)
+	}
+	// Machine-readable formats keep stdout free of progress messages.
+	progress := func(a ...any) {
+		if !outputFormat.IsMachineReadable() {
+			fmt.Println(a...)
+		}
+	}
+
 	var data []byte
-	var err error
+	var references map[string]*image.ImageReference
 	if len(args) == 1 {
-		targetVersion := args[0]
-		fmt.Println(
+		target := args[0]
+		chartName, _ := cmd.Flags().GetString("chart")
+		chartsDir, _ := cmd.Flags().GetString("charts-dir")
+		progress(
 			text.AlignCenter.Apply(
-				text.Color.Sprintf(text.FgBlue, "Looking for `rancher-monitoring` chart with version `%s`...", targetVersion),
+				text.Color.Sprintf(text.FgBlue, "Looking for `%s` chart with version `%s`...", chartName, target),
 				125,
 			),
 		)
-		cwd, err := os.Getwd()
-		if err != nil {
-			panic(err)
-		}
 
-		chartTargetRoot := fmt.Sprintf("%s/charts/rancher-monitoring/%s", cwd, targetVersion)
-		if _, err := os.Stat(chartTargetRoot); os.IsNotExist(err) {
-			panic(fmt.Sprintf("Cannot find a monitoring chart with the provided version (%s)", targetVersion))
+		chartTargetRoot, err := chartimages.ResolveChartDir(chartsDir, chartName, target)
+		if err != nil {
+			logging.Log.Fatal(err)
 		}
 
-		fmt.Println(
-			text.Color.Sprintf(text.FgBlue, "The %s chart was found - next this tool will run `helm debug` to get the rendered chart.", targetVersion),
+		progress(
+			text.Color.Sprintf(text.FgBlue, "The %s chart was found - next this tool will render the chart.", target),
 		)
 
-		var helmArgs string
-		if _, err := os.Stat(cwd + "/debug.yaml"); !os.IsNotExist(err) {
-			helmArgs = fmt.Sprintf("template --debug rancher-monitoring %s -f %s/debug.yaml -n cattle-monitoring-system", chartTargetRoot, cwd)
-		} else {
-			helmArgs = fmt.Sprintf("template --debug rancher-monitoring %s -n cattle-monitoring-system", chartTargetRoot)
-		}
-		logging.Log.Debug(helmArgs)
-		cmd := exec.Command("helm", strings.Split(helmArgs, " ")...)
-		var stdout, stderr bytes.Buffer
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		err = cmd.Run()
+		renderOpts := renderOptionsFromFlags(cmd, chartName)
+		logging.Log.Debugf("Rendering %s with %+v", chartTargetRoot, renderOpts)
+		rendered, err := chartimages.RenderChart(chartTargetRoot, renderOpts)
 		if err != nil {
-			logging.Log.Error(stderr.String())
-			panic(err)
+			logging.Log.Fatal(err)
 		}
 
-		data = stdout.Bytes()
+		data = []byte(rendered)
+
+		// The values.yaml OS lists tell which platforms each image must provide
+		references, err = chartimages.ChartDirImages(chartTargetRoot)
+		if err != nil {
+			logging.Log.Warnf("Unable to read the chart images from values.yaml, guessing their OS from the image names: %v", err)
+		}
 
 	} else if helpers.IsDataFromStdin() {
 		// Read stdin data
@@ -95,35 +120,108 @@ func verifyChartImagesHandler(_ *cobra.Command, args []string) {
 			fmt.Println("Error reading stdin:", err)
 			return
 		}
-		fmt.Println(text.AlignCenter.Apply(text.Color.Sprint(text.FgBlue, "Starting to process from stdin..."), 75))
+		progress(text.AlignCenter.Apply(text.Color.Sprint(text.FgBlue, "Starting to process from stdin..."), 75))
 	}
 	if len(data) > 0 {
-		processHelmChartImages(string(data))
+		dockerConfigPath, _ := cmd.Flags().GetString("docker-config")
+		registryClient, err := chartimages.NewRegistryClient(dockerConfigPath)
+		if err != nil {
+			logging.Log.Fatal(err)
+		}
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		checkPlatforms, _ := cmd.Flags().GetBool("check-platforms")
+		report := processHelmChartImages(registryClient, references, concurrency, checkPlatforms, outputFormat, string(data))
+		if !report.Success {
+			os.Exit(1)
+		}
+	}
+}
+
+// renderOptionsFromFlags collects the `helm template` style flags into render options.
+func renderOptionsFromFlags(cmd *cobra.Command, chartName string) render.Options {
+	valueFiles, _ := cmd.Flags().GetStringArray("values")
+	setValues, _ := cmd.Flags().GetStringArray("set")
+	setStringValues, _ := cmd.Flags().GetStringArray("set-string")
+	namespace, _ := cmd.Flags().GetString("namespace")
+	releaseName, _ := cmd.Flags().GetString("release-name")
+	kubeVersion, _ := cmd.Flags().GetString("kube-version")
+	if releaseName == "" {
+		releaseName = chartName
+	}
+
+	return render.Options{
+		ReleaseName: releaseName,
+		Namespace:   namespace,
+		KubeVersion: kubeVersion,
+		Values: render.ValuesOptions{
+			ValueFiles:   valueFiles,
+			Values:       setValues,
+			StringValues: setStringValues,
+		},
 	}
 }
 
-func processHelmChartImages(helmChart string) {
+func processHelmChartImages(registryClient *registry.Client, references map[string]*image.ImageReference, concurrency int, checkPlatforms bool, outputFormat chartimages.OutputFormat, helmChart string) *chartimages.Report {
 	imagesLists := chartimages.PrepareChartImagesList(helmChart)
-	err := chartimages.ProcessRenderedChartImages(&imagesLists)
-	if err != nil {
-		return
+	if !outputFormat.IsMachineReadable() {
+		if err := chartimages.ProcessRenderedChartImages(&imagesLists); err != nil {
+			logging.Log.Fatal(err)
+		}
 	}
-	checkedImages := chartimages.CheckRancherImages(imagesLists.RancherImages)
+	checkedImages := chartimages.CheckRancherImages(registryClient, imagesLists.RancherImages, references, concurrency, checkPlatforms)
+	report := chartimages.NewReport(imagesLists, checkedImages)
+
+	if outputFormat.IsMachineReadable() {
+		if err := chartimages.WriteReport(os.Stdout, report, outputFormat); err != nil {
+			logging.Log.Fatal(err)
+		}
+		return report
+	}
+
 	t := table.NewWriter()
 	t.SetOutputMirror(os.Stdout)
-	t.AppendHeader(table.Row{"#", "Image", "Status"})
-	idx := 0
-	for image, status := range checkedImages {
-		idx++
-		statusIcon := "✅"
-		if !status {
-			statusIcon = "❌"
+	if checkPlatforms {
+		t.AppendHeader(table.Row{"#", "Image", "Status", "Platforms"})
+	} else {
+		t.AppendHeader(table.Row{"#", "Image", "Status"})
+	}
+	for idx, check := range checkedImages {
+		row := table.Row{idx + 1, check.Image, imageCheckStatus(check)}
+		if checkPlatforms {
+			row = append(row, formatPlatforms(check.Coverage))
 		}
-		t.AppendRow(table.Row{
-			idx,
-			image,
-			statusIcon,
-		})
+		t.AppendRow(row)
 	}
 	t.Render()
+
+	return report
+}
+
+// imageCheckStatus renders an image check for the results table.
+func imageCheckStatus(check chartimages.ImageCheck) string {
+	if check.Exists() && check.Coverage != nil {
+		switch {
+		case check.Coverage.Err != nil:
+			return "⚠️ platforms unknown"
+		case len(check.Coverage.Missing) > 0:
+			missing := make([]string, len(check.Coverage.Missing))
+			for i, p := range check.Coverage.Missing {
+				missing[i] = p.String()
+			}
+			return "❌ missing " + strings.Join(missing, ", ")
+		}
+	}
+	return chartimages.StatusIcon(check.Status)
+}
+
+// formatPlatforms lists the platforms an image provides.
+func formatPlatforms(coverage *registry.PlatformCoverage) string {
+	if coverage == nil || coverage.Err != nil {
+		return "-"
+	}
+	platforms := make([]string, len(coverage.Platforms))
+	for i, p := range coverage.Platforms {
+		platforms[i] = p.String()
+	}
+	return strings.Join(platforms, ", ")
 }
@@ -0,0 +1,22 @@
+package chart
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// CRDChartSuffix names the companion chart that ships a chart's CRDs, e.g. "rancher-monitoring-crd".
+const CRDChartSuffix = "-crd"
+
+// CompanionCRDChartDir returns the directory of the CRD chart built alongside the
+// chart at chartDir (<chartsDir>/<chart>/<version> → <chartsDir>/<chart>-crd/<version>),
+// and whether it exists.
+func CompanionCRDChartDir(chartDir string) (string, bool) {
+	chartDir = filepath.Clean(chartDir)
+	chartName := filepath.Base(filepath.Dir(chartDir))
+	crdDir := filepath.Join(filepath.Dir(filepath.Dir(chartDir)), chartName+CRDChartSuffix, filepath.Base(chartDir))
+	if _, err := os.Stat(filepath.Join(crdDir, "Chart.yaml")); err != nil {
+		return "", false
+	}
+	return crdDir, true
+}
@@ -0,0 +1,30 @@
+package chart_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancher/ob-charts-tool/helmtools/chart"
+)
+
+func TestCompanionCRDChartDir(t *testing.T) {
+	chartsDir := t.TempDir()
+	for _, dir := range []string{"rancher-monitoring/108.0.0", "rancher-monitoring-crd/108.0.0", "rancher-logging/108.0.0"} {
+		if err := os.MkdirAll(filepath.Join(chartsDir, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(chartsDir, dir, "Chart.yaml"), []byte("name: x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	crdDir, ok := chart.CompanionCRDChartDir(filepath.Join(chartsDir, "rancher-monitoring", "108.0.0") + "/")
+	if want := filepath.Join(chartsDir, "rancher-monitoring-crd", "108.0.0"); !ok || crdDir != want {
+		t.Errorf("CompanionCRDChartDir() = %q, %v, want %q, true", crdDir, ok, want)
+	}
+
+	if crdDir, ok := chart.CompanionCRDChartDir(filepath.Join(chartsDir, "rancher-logging", "108.0.0")); ok {
+		t.Errorf("CompanionCRDChartDir() = %q, true, want no CRD chart", crdDir)
+	}
+}
@@ -25,4 +25,8 @@
 //	index, err := chart.ParseIndex(data)
 //	latest := index.GetLatestVersion("nginx")
 //	charts := index.ListCharts()
+//
+// Find the CRD chart built alongside a chart (charts/<chart>-crd/<version>):
+//
+//	crdDir, ok := chart.CompanionCRDChartDir("charts/rancher-monitoring/108.0.0")
 package chart
@@ -4,6 +4,8 @@
 //   - chart: Parse and fetch Helm Chart.yaml files
 //   - git: Query Git repositories for Helm chart tags and versions
 //   - image: Extract container images from Helm values.yaml files
+//   - render: Render Helm charts in-process with the Helm Go SDK
+//   - registry: Check image manifests in OCI Distribution v2 registries
 //   - values: Navigate and manipulate Helm values.yaml structure
 //   - version: Version comparison utilities
 //   - util: Shared utilities (HTTP, sets, slices)
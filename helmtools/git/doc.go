@@ -14,6 +14,11 @@
 //
 //	highestTag := git.FindHighestVersionTag(tags, "kube-prometheus-stack")
 //
+// Find the highest version tag satisfying a chart dependency's version constraint:
+//
+//	constraint, err := semver.NewConstraint("~9.2.0")
+//	tag := git.FindHighestVersionTagInRange(tags, "grafana", constraint, false)
+//
 // All Git operations use go-git for remote repository access and do not require
 // a local clone. Functions accept a context.Context for cancellation and timeout support.
 package git
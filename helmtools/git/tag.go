@@ -117,3 +117,38 @@ func FindHighestVersionTag(tags []Tag, componentPrefix string) *Tag {
 
 	return highestTag
 }
+
+// FindHighestVersionTagInRange selects the tag with the highest semantic version number
+// that satisfies constraint from the provided tags, filtering by the given prefix.
+// Pre-releases only satisfy the constraint when includePrerelease is set or when the
+// constraint itself names a pre-release, as with Helm.
+func FindHighestVersionTagInRange(tags []Tag, componentPrefix string, constraint *semver.Constraints, includePrerelease bool) *Tag {
+	if len(tags) == 0 || componentPrefix == "" || constraint == nil {
+		return nil
+	}
+	inRange := *constraint
+	inRange.IncludePrerelease = inRange.IncludePrerelease || includePrerelease
+
+	var highestTag *Tag
+	var highestVersion *semver.Version
+
+	prefix := componentPrefix + "-"
+	for i := range tags {
+		tag := &tags[i]
+		if !strings.HasPrefix(tag.Name, prefix) {
+			continue
+		}
+
+		version, err := semver.NewVersion(strings.TrimPrefix(tag.Name, prefix))
+		if err != nil || !inRange.Check(version) {
+			continue
+		}
+
+		if highestVersion == nil || version.GreaterThan(highestVersion) {
+			highestVersion = version
+			highestTag = tag
+		}
+	}
+
+	return highestTag
+}
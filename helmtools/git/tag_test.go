@@ -3,6 +3,7 @@ package git_test
 import (
 	"testing"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/rancher/ob-charts-tool/helmtools/git"
 )
 
@@ -154,3 +155,49 @@ func TestFindHighestVersionTag(t *testing.T) {
 		})
 	}
 }
+
+func TestFindHighestVersionTagInRange(t *testing.T) {
+	tags := []git.Tag{
+		{Name: "grafana-1.2.0", CommitHash: "hash1"},
+		{Name: "grafana-1.2.9", CommitHash: "hash2"},
+		{Name: "grafana-1.3.0", CommitHash: "hash3"},
+		{Name: "grafana-1.4.0-rc.1", CommitHash: "hash4"},
+		{Name: "grafana-5.4.1", CommitHash: "hash5"},
+		{Name: "grafana-6.0.0", CommitHash: "hash6"},
+		{Name: "grafana-agent-9.0.0", CommitHash: "hash7"},
+	}
+
+	tests := []struct {
+		constraint        string
+		includePrerelease bool
+		wantTag           string
+	}{
+		{constraint: "~1.2.0", wantTag: "grafana-1.2.9"},
+		{constraint: ">=5.0 <6", wantTag: "grafana-5.4.1"},
+		{constraint: "1.x", wantTag: "grafana-1.3.0"},
+		{constraint: "1.*", wantTag: "grafana-1.3.0"},
+		{constraint: "1.x", includePrerelease: true, wantTag: "grafana-1.4.0-rc.1"},
+		{constraint: ">=1.4.0-0 <2", wantTag: "grafana-1.4.0-rc.1"},
+		{constraint: "*", wantTag: "grafana-6.0.0"},
+		{constraint: "^2.0.0", wantTag: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			constraint, err := semver.NewConstraint(tt.constraint)
+			if err != nil {
+				t.Fatalf("NewConstraint(%q) error = %v", tt.constraint, err)
+			}
+			got := git.FindHighestVersionTagInRange(tags, "grafana", constraint, tt.includePrerelease)
+			if tt.wantTag == "" {
+				if got != nil {
+					t.Errorf("FindHighestVersionTagInRange() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil || got.Name != tt.wantTag {
+				t.Errorf("FindHighestVersionTagInRange() = %+v, want %s", got, tt.wantTag)
+			}
+		})
+	}
+}
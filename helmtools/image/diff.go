@@ -0,0 +1,139 @@
+package image
+
+import (
+	"sort"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/rancher/ob-charts-tool/helmtools/version"
+)
+
+// ChangeKind classifies how an image differs between two image sets.
+type ChangeKind string
+
+const (
+	// ChangeAdded is an image repository only present in the new set.
+	ChangeAdded ChangeKind = "added"
+	// ChangeRemoved is an image repository only present in the old set.
+	ChangeRemoved ChangeKind = "removed"
+	// ChangeRetagged is an image repository present in both sets with a different tag.
+	ChangeRetagged ChangeKind = "retagged"
+)
+
+// ImageChange is a single difference reported by DiffImages.
+type ImageChange struct {
+	Kind ChangeKind
+	// Name is the normalized repository name the images were matched on,
+	// e.g. "quay.io/prometheus/prometheus".
+	Name string
+	// Old is nil for added images; New is nil for removed images.
+	Old *ImageReference
+	New *ImageReference
+	// Bump classifies the tag change of retagged images.
+	Bump version.Bump
+}
+
+// DiffImages compares two image sets, as returned by ExtractImagesWithSources or
+// MergeImageSources, by repository name. Tags present in both sets are unchanged.
+// When a repository's remaining tags exist on both sides they are paired in
+// ascending version order and reported as retagged; any surplus is reported as
+// added or removed. Changes are sorted by name, then by tag.
+func DiffImages(oldImages, newImages map[string]*ImageReference) []ImageChange {
+	oldByName := groupByName(oldImages)
+	newByName := groupByName(newImages)
+
+	names := make([]string, 0, len(oldByName)+len(newByName))
+	for name := range oldByName {
+		names = append(names, name)
+	}
+	for name := range newByName {
+		if _, ok := oldByName[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var changes []ImageChange
+	for _, name := range names {
+		oldRefs, newRefs := withoutCommonTags(oldByName[name], newByName[name])
+		paired := min(len(oldRefs), len(newRefs))
+		for i := range paired {
+			changes = append(changes, ImageChange{
+				Kind: ChangeRetagged,
+				Name: name,
+				Old:  oldRefs[i],
+				New:  newRefs[i],
+				Bump: version.ClassifyBump(oldRefs[i].Image.Tag, newRefs[i].Image.Tag),
+			})
+		}
+		for _, ref := range oldRefs[paired:] {
+			changes = append(changes, ImageChange{Kind: ChangeRemoved, Name: name, Old: ref})
+		}
+		for _, ref := range newRefs[paired:] {
+			changes = append(changes, ImageChange{Kind: ChangeAdded, Name: name, New: ref})
+		}
+	}
+	return changes
+}
+
+// groupByName groups image references by normalized repository name, each
+// group sorted by tag version.
+func groupByName(images map[string]*ImageReference) map[string][]*ImageReference {
+	groups := make(map[string][]*ImageReference)
+	for key, ref := range images {
+		name := key
+		if parsed, err := ref.Image.Reference(); err == nil {
+			name = parsed.Name()
+		}
+		groups[name] = append(groups[name], ref)
+	}
+	for _, refs := range groups {
+		sort.Slice(refs, func(i, j int) bool {
+			return tagLess(refs[i].Image.Tag, refs[j].Image.Tag)
+		})
+	}
+	return groups
+}
+
+// withoutCommonTags drops the tags found on both sides.
+func withoutCommonTags(oldRefs, newRefs []*ImageReference) ([]*ImageReference, []*ImageReference) {
+	oldTags := make(map[string]bool, len(oldRefs))
+	for _, ref := range oldRefs {
+		oldTags[ref.Image.Tag] = true
+	}
+	newTags := make(map[string]bool, len(newRefs))
+	for _, ref := range newRefs {
+		newTags[ref.Image.Tag] = true
+	}
+
+	var keptOld, keptNew []*ImageReference
+	for _, ref := range oldRefs {
+		if !newTags[ref.Image.Tag] {
+			keptOld = append(keptOld, ref)
+		}
+	}
+	for _, ref := range newRefs {
+		if !oldTags[ref.Image.Tag] {
+			keptNew = append(keptNew, ref)
+		}
+	}
+	return keptOld, keptNew
+}
+
+// tagLess orders semver tags by version, before any non-semver tags, which sort lexically.
+func tagLess(a, b string) bool {
+	va, errA := semver.NewVersion(a)
+	vb, errB := semver.NewVersion(b)
+	switch {
+	case errA == nil && errB == nil:
+		if !va.Equal(vb) {
+			return va.LessThan(vb)
+		}
+		return a < b
+	case errA == nil:
+		return true
+	case errB == nil:
+		return false
+	default:
+		return a < b
+	}
+}
@@ -0,0 +1,96 @@
+package image_test
+
+import (
+	"testing"
+
+	"github.com/rancher/ob-charts-tool/helmtools/image"
+	"github.com/rancher/ob-charts-tool/helmtools/version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffImages(t *testing.T) {
+	oldValues := []byte(`
+prometheus:
+  image:
+    registry: quay.io
+    repository: prometheus/prometheus
+    tag: v3.1.0
+exporter:
+  image:
+    repository: quay.io/prometheus/node-exporter
+    tag: v1.8.2
+sidecar:
+  image:
+    repository: kiwigrid/k8s-sidecar
+    tag: 1.28.0
+removed:
+  image:
+    repository: bats/bats
+    tag: v1.4.1
+`)
+	newValues := []byte(`
+prometheus:
+  image:
+    registry: quay.io
+    repository: prometheus/prometheus
+    tag: v3.2.0
+exporter:
+  image:
+    repository: quay.io/prometheus/node-exporter
+    tag: v1.8.2
+sidecar:
+  image:
+    repository: docker.io/kiwigrid/k8s-sidecar
+    tag: 1.27.5
+windowsExporter:
+  image:
+    repository: ghcr.io/prometheus-community/windows-exporter
+    tag: 0.29.2
+`)
+	oldImages, err := image.ExtractImagesWithSources(oldValues, "rancher-monitoring:107.0.0", "")
+	require.NoError(t, err)
+	newImages, err := image.ExtractImagesWithSources(newValues, "rancher-monitoring:108.0.0", "")
+	require.NoError(t, err)
+
+	changes := image.DiffImages(oldImages, newImages)
+	require.Len(t, changes, 4)
+
+	assert.Equal(t, image.ChangeRemoved, changes[0].Kind)
+	assert.Equal(t, "docker.io/bats/bats", changes[0].Name)
+	assert.Nil(t, changes[0].New)
+
+	// Matched across "kiwigrid/k8s-sidecar" and "docker.io/kiwigrid/k8s-sidecar".
+	assert.Equal(t, image.ChangeRetagged, changes[1].Kind)
+	assert.Equal(t, "docker.io/kiwigrid/k8s-sidecar", changes[1].Name)
+	assert.Equal(t, version.BumpDowngrade, changes[1].Bump)
+
+	assert.Equal(t, image.ChangeAdded, changes[2].Kind)
+	assert.Equal(t, "ghcr.io/prometheus-community/windows-exporter", changes[2].Name)
+	assert.Equal(t, "windows", changes[2].New.OS)
+	assert.Equal(t, []string{"rancher-monitoring:108.0.0"}, changes[2].New.Sources)
+
+	assert.Equal(t, image.ChangeRetagged, changes[3].Kind)
+	assert.Equal(t, "quay.io/prometheus/prometheus", changes[3].Name)
+	assert.Equal(t, "v3.1.0", changes[3].Old.Image.Tag)
+	assert.Equal(t, "v3.2.0", changes[3].New.Image.Tag)
+	assert.Equal(t, version.BumpMinor, changes[3].Bump)
+}
+
+func TestDiffImages_MultipleTags(t *testing.T) {
+	ref := func(tag string) *image.ImageReference {
+		return &image.ImageReference{Image: image.Image{Repository: "rancher/shell", Tag: tag}}
+	}
+	oldImages := map[string]*image.ImageReference{"a": ref("v0.2.0"), "b": ref("v0.3.0")}
+	newImages := map[string]*image.ImageReference{"a": ref("v0.3.0"), "b": ref("v0.4.1"), "c": ref("v0.5.0")}
+
+	changes := image.DiffImages(oldImages, newImages)
+	require.Len(t, changes, 2)
+	assert.Equal(t, image.ChangeRetagged, changes[0].Kind)
+	assert.Equal(t, "v0.2.0", changes[0].Old.Image.Tag)
+	assert.Equal(t, "v0.4.1", changes[0].New.Image.Tag)
+	assert.Equal(t, image.ChangeAdded, changes[1].Kind)
+	assert.Equal(t, "v0.5.0", changes[1].New.Image.Tag)
+
+	assert.Empty(t, image.DiffImages(oldImages, oldImages))
+}
@@ -10,7 +10,21 @@
 //
 //	images, err := image.ExtractImages(valuesData, "v1.0.0")
 //
-// Extract images from rendered templates:
+// Extract images from rendered manifests (e.g. render.Result.String()), with
+// the Kubernetes resource that owns each one:
+//
+//	found, err := image.ExtractImagesFromManifests([]byte(renderedChart))
+//	for _, f := range found {
+//	    fmt.Printf("%s used by %s (%s)\n", f.Image, f.Resource, f.Field)
+//	}
+//
+// Workload PodSpecs (Deployment, DaemonSet, StatefulSet, Job, CronJob, ...),
+// prometheus-operator Prometheus/Alertmanager/ThanosRuler resources,
+// image-bearing container args listed in ImageArgFlags and container env
+// values holding a full image reference are inspected.
+//
+// Extract images from rendered templates by pattern matching, for input that
+// is not valid YAML:
 //
 //	images := image.ExtractImagesFromTemplates(renderedChart)
 //
@@ -41,6 +55,27 @@
 //	refs2, _ := image.ExtractImagesWithSources(data2, "chart-b:2.0.0", "")
 //	merged := image.MergeImageSources(refs1, refs2)
 //
+// Compare two image sets; re-tagged images carry a semver bump classification:
+//
+//	for _, change := range image.DiffImages(oldRefs, newRefs) {
+//		fmt.Println(change.Kind, change.Name, change.Bump) // e.g. "retagged quay.io/prometheus/prometheus minor"
+//	}
+//
+// Parse and normalize image references:
+//
+//	ref, err := image.ParseReference("localhost:5000/team/app:1.0@sha256:...")
+//	ref.Registry     // "localhost:5000"
+//	ref.Identifier() // digest when set, else tag, else "latest"
+//
+//	image.MustParseReference("nginx").String()                     // "docker.io/library/nginx"
+//	image.MustParseReference("docker.io/rancher/shell:v1").Familiar() // "rancher/shell:v1"
+//
+// Pin image digests with a values overlay (digests are keyed by DigestKey):
+//
+//	locations, _ := image.ExtractImageLocations(valuesData, appVersion)
+//	overlay := map[string]interface{}{}
+//	image.PinDigests(overlay, "", locations, digests) // sets e.g. prometheus.prometheusSpec.image.sha
+//
 // The extraction uses heuristic pattern matching to find keys ending in "image"
 // and decodes their structure (repository, tag, etc.).
 package image
@@ -0,0 +1,142 @@
+package image
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rancher/ob-charts-tool/helmtools/values"
+	"go.yaml.in/yaml/v3"
+)
+
+// Digest field names used by charts to pin an image.
+const (
+	// DigestFieldSHA holds the bare hex digest, as in kube-prometheus-stack's
+	// "{{ .repository }}:{{ .tag }}@sha256:{{ .sha }}" templates.
+	DigestFieldSHA = "sha"
+	// DigestFieldDigest holds the full "sha256:<hex>" digest.
+	DigestFieldDigest = "digest"
+)
+
+// ImageLocation is an image definition found at a dotted path in values.yaml.
+//
+//nolint:revive // ImageLocation is intentional - mirrors ImageReference naming
+type ImageLocation struct {
+	// Path is the dotted path of the image map, e.g. "prometheus.prometheusSpec.image".
+	Path  string
+	Image Image
+	// DigestField is the key that pins the image's digest: DigestFieldDigest when
+	// the image map already has a "digest" key, DigestFieldSHA otherwise.
+	DigestField string
+}
+
+// DigestValue formats digest ("sha256:<hex>") for the location's DigestField.
+func (l ImageLocation) DigestValue(digest string) string {
+	if l.DigestField == DigestFieldSHA {
+		_, hex, found := strings.Cut(digest, ":")
+		if found {
+			return hex
+		}
+	}
+	return digest
+}
+
+// ExtractImageLocations finds image maps in values.yaml like ExtractImages, but
+// returns each occurrence with its dotted path so values overlays can target it.
+// Image maps without a repository are skipped. If an image has an empty tag and
+// defaultTag is provided, it uses defaultTag.
+func ExtractImageLocations(valuesData []byte, defaultTag string) ([]ImageLocation, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(valuesData, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse values.yaml: %w", err)
+	}
+
+	var locations []ImageLocation
+	collectImageLocations(&root, "", defaultTag, &locations)
+	return locations, nil
+}
+
+func collectImageLocations(node *yaml.Node, path, defaultTag string, locations *[]ImageLocation) {
+	if node == nil {
+		return
+	}
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		collectImageLocations(node.Content[0], path, defaultTag, locations)
+		return
+	}
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+		valueNode := node.Content[i+1]
+		if keyNode.Kind != yaml.ScalarNode {
+			continue
+		}
+		keyPath := keyNode.Value
+		if path != "" {
+			keyPath = path + "." + keyNode.Value
+		}
+
+		if imageKeyPattern.MatchString(keyNode.Value) && valueNode.Kind == yaml.MappingNode {
+			var img Image
+			if err := valueNode.Decode(&img); err == nil && img.Repository != "" {
+				if img.Tag == "" && defaultTag != "" {
+					img.Tag = defaultTag
+				}
+				location := ImageLocation{Path: keyPath, Image: img, DigestField: DigestFieldSHA}
+				if hasKey(valueNode, DigestFieldDigest) {
+					location.DigestField = DigestFieldDigest
+				}
+				*locations = append(*locations, location)
+			}
+		}
+
+		collectImageLocations(valueNode, keyPath, defaultTag, locations)
+	}
+}
+
+func hasKey(node *yaml.Node, key string) bool {
+	for i := 0; i < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return true
+		}
+	}
+	return false
+}
+
+// DigestKey returns the key used to match an image to its resolved digest: the
+// fully qualified reference without any digest, e.g. "docker.io/library/nginx:1.27".
+func DigestKey(imageName string) (string, error) {
+	ref, err := ParseReference(imageName)
+	if err != nil {
+		return "", err
+	}
+	return ref.WithDigest("").String(), nil
+}
+
+// PinDigests writes the digest of every location whose image appears in digests
+// (keyed by DigestKey) into overlay, nesting each entry under prefix, a dotted
+// path such as a subchart name ("" for the chart itself). It returns the number
+// of locations pinned.
+func PinDigests(overlay map[string]interface{}, prefix string, locations []ImageLocation, digests map[string]string) int {
+	pinned := 0
+	for _, location := range locations {
+		key, err := DigestKey(location.Image.String())
+		if err != nil {
+			continue
+		}
+		digest, ok := digests[key]
+		if !ok || digest == "" {
+			continue
+		}
+		path := location.Path + "." + location.DigestField
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		if err := values.SetByPath(overlay, path, location.DigestValue(digest)); err == nil {
+			pinned++
+		}
+	}
+	return pinned
+}
@@ -0,0 +1,93 @@
+package image_test
+
+import (
+	"testing"
+
+	"github.com/rancher/ob-charts-tool/helmtools/image"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const locationsValues = `
+image:
+  repository: rancher/shell
+  tag: v0.3.0
+prometheus:
+  prometheusSpec:
+    image:
+      registry: quay.io
+      repository: prometheus/prometheus
+      tag: v3.1.0
+      sha: ""
+exporter:
+  image:
+    repository: prom/node-exporter
+    digest: ""
+  templated:
+    image:
+      repository: ""
+`
+
+func TestExtractImageLocations(t *testing.T) {
+	locations, err := image.ExtractImageLocations([]byte(locationsValues), "v1.9.0")
+	require.NoError(t, err)
+
+	assert.Equal(t, []image.ImageLocation{
+		{Path: "image", Image: image.Image{Repository: "rancher/shell", Tag: "v0.3.0"}, DigestField: image.DigestFieldSHA},
+		{
+			Path:        "prometheus.prometheusSpec.image",
+			Image:       image.Image{Registry: "quay.io", Repository: "prometheus/prometheus", Tag: "v3.1.0"},
+			DigestField: image.DigestFieldSHA,
+		},
+		{Path: "exporter.image", Image: image.Image{Repository: "prom/node-exporter", Tag: "v1.9.0"}, DigestField: image.DigestFieldDigest},
+	}, locations)
+
+	_, err = image.ExtractImageLocations([]byte("image: [unclosed"), "")
+	assert.Error(t, err)
+}
+
+func TestPinDigests(t *testing.T) {
+	locations, err := image.ExtractImageLocations([]byte(locationsValues), "v1.9.0")
+	require.NoError(t, err)
+
+	digests := map[string]string{
+		"docker.io/rancher/shell:v0.3.0":         "sha256:aaa",
+		"quay.io/prometheus/prometheus:v3.1.0":   "sha256:bbb",
+		"docker.io/prom/node-exporter:v1.9.0":    "sha256:ccc",
+		"docker.io/library/unrelated-image:v1.0": "sha256:ddd",
+	}
+
+	t.Run("chart root", func(t *testing.T) {
+		overlay := map[string]interface{}{}
+		assert.Equal(t, 3, image.PinDigests(overlay, "", locations, digests))
+		assert.Equal(t, map[string]interface{}{
+			"image": map[string]interface{}{"sha": "aaa"},
+			"prometheus": map[string]interface{}{
+				"prometheusSpec": map[string]interface{}{"image": map[string]interface{}{"sha": "bbb"}},
+			},
+			"exporter": map[string]interface{}{"image": map[string]interface{}{"digest": "sha256:ccc"}},
+		}, overlay)
+	})
+
+	t.Run("subchart prefix and unresolved images", func(t *testing.T) {
+		overlay := map[string]interface{}{}
+		pinned := image.PinDigests(overlay, "grafana", locations[:1], map[string]string{})
+		assert.Zero(t, pinned)
+		assert.Empty(t, overlay)
+
+		pinned = image.PinDigests(overlay, "grafana", locations[:1], digests)
+		assert.Equal(t, 1, pinned)
+		assert.Equal(t, map[string]interface{}{
+			"grafana": map[string]interface{}{"image": map[string]interface{}{"sha": "aaa"}},
+		}, overlay)
+	})
+}
+
+func TestDigestKey(t *testing.T) {
+	key, err := image.DigestKey("nginx:1.27@sha256:abc")
+	require.NoError(t, err)
+	assert.Equal(t, "docker.io/library/nginx:1.27", key)
+
+	_, err = image.DigestKey("")
+	assert.Error(t, err)
+}
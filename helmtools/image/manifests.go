@@ -0,0 +1,267 @@
+package image
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// ResourceRef identifies the Kubernetes object an image was found in.
+type ResourceRef struct {
+	APIVersion string `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string `json:"kind" yaml:"kind"`
+	Namespace  string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Name       string `json:"name" yaml:"name"`
+	// Source is the template path from the "# Source:" comment helm adds to rendered documents.
+	Source string `json:"source,omitempty" yaml:"source,omitempty"`
+}
+
+// String formats the resource as "Kind/name" or "Kind/namespace/name".
+func (r ResourceRef) String() string {
+	if r.Namespace != "" {
+		return r.Kind + "/" + r.Namespace + "/" + r.Name
+	}
+	return r.Kind + "/" + r.Name
+}
+
+// ManifestImage is an image reference found in a rendered Kubernetes manifest.
+type ManifestImage struct {
+	Image    string      `json:"image" yaml:"image"`
+	Resource ResourceRef `json:"resource" yaml:"resource"`
+	// Container is the name of the container the image belongs to, if any.
+	Container string `json:"container,omitempty" yaml:"container,omitempty"`
+	// Field is the path of the field holding the image, e.g. "spec.template.spec.containers[0].image".
+	Field string `json:"field" yaml:"field"`
+}
+
+// ImageArgFlags are container command-line flags whose value is a full image reference.
+// Both "--flag=image" and "--flag image" forms are recognised.
+var ImageArgFlags = []string{
+	"--prometheus-config-reloader",
+	"--config-reloader-image",
+}
+
+// podSpecPaths maps workload kinds to the location of their PodSpec.
+var podSpecPaths = map[string][]string{
+	"Pod":                   {"spec"},
+	"Deployment":            {"spec", "template", "spec"},
+	"ReplicaSet":            {"spec", "template", "spec"},
+	"ReplicationController": {"spec", "template", "spec"},
+	"DaemonSet":             {"spec", "template", "spec"},
+	"StatefulSet":           {"spec", "template", "spec"},
+	"Job":                   {"spec", "template", "spec"},
+	"CronJob":               {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// prometheusOperatorKinds are the monitoring.coreos.com resources whose spec embeds
+// an "image" field plus PodSpec-style containers and initContainers.
+var prometheusOperatorKinds = map[string]bool{
+	"Prometheus":      true,
+	"PrometheusAgent": true,
+	"Alertmanager":    true,
+	"ThanosRuler":     true,
+}
+
+var containerFields = []string{"initContainers", "containers", "ephemeralContainers"}
+
+// ExtractImagesFromManifests decodes a multi-document YAML stream of rendered
+// Kubernetes objects (such as `helm template` output) and returns every image
+// referenced by workload PodSpecs, prometheus-operator custom resources, known
+// image-bearing container args and container env values holding an image
+// reference, together with the resource that owns it.
+// Images are returned in document order; the same image may appear once per owner.
+func ExtractImagesFromManifests(rendered []byte) ([]ManifestImage, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(rendered))
+	var found []ManifestImage
+	for {
+		var doc yaml.Node
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode rendered manifests: %w", err)
+		}
+
+		var obj map[string]interface{}
+		if err := doc.Decode(&obj); err != nil || obj == nil {
+			// Not an object (e.g. an empty document or a bare scalar); nothing to extract.
+			continue
+		}
+		found = append(found, imagesFromObject(obj, sourceComment(&doc))...)
+	}
+	return found, nil
+}
+
+// OwnersByImage groups extracted images by image reference, listing each owning
+// resource once in the order it was first seen.
+func OwnersByImage(found []ManifestImage) map[string][]ResourceRef {
+	owners := make(map[string][]ResourceRef)
+	for _, img := range found {
+		if !slices.Contains(owners[img.Image], img.Resource) {
+			owners[img.Image] = append(owners[img.Image], img.Resource)
+		}
+	}
+	return owners
+}
+
+// sourceComment returns the template path from a "# Source: <path>" comment.
+func sourceComment(doc *yaml.Node) string {
+	comments := []string{doc.HeadComment}
+	if len(doc.Content) > 0 {
+		comments = append(comments, doc.Content[0].HeadComment)
+		if len(doc.Content[0].Content) > 0 {
+			comments = append(comments, doc.Content[0].Content[0].HeadComment)
+		}
+	}
+	for _, comment := range comments {
+		for line := range strings.SplitSeq(comment, "\n") {
+			if source, ok := strings.CutPrefix(strings.TrimSpace(line), "# Source:"); ok {
+				return strings.TrimSpace(source)
+			}
+		}
+	}
+	return ""
+}
+
+func imagesFromObject(obj map[string]interface{}, source string) []ManifestImage {
+	kind, _ := obj["kind"].(string)
+	if strings.HasSuffix(kind, "List") {
+		items, _ := obj["items"].([]interface{})
+		var found []ManifestImage
+		for _, item := range items {
+			if itemObj, ok := item.(map[string]interface{}); ok {
+				found = append(found, imagesFromObject(itemObj, source)...)
+			}
+		}
+		return found
+	}
+
+	ref := ResourceRef{Kind: kind, Source: source}
+	ref.APIVersion, _ = obj["apiVersion"].(string)
+	if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+		ref.Name, _ = metadata["name"].(string)
+		ref.Namespace, _ = metadata["namespace"].(string)
+	}
+
+	var found []ManifestImage
+	if path, ok := podSpecPaths[kind]; ok {
+		if podSpec, ok := lookupMap(obj, path...); ok {
+			found = append(found, imagesFromPodSpec(podSpec, ref, strings.Join(path, "."))...)
+		}
+	}
+	if prometheusOperatorKinds[kind] && strings.HasPrefix(ref.APIVersion, "monitoring.coreos.com/") {
+		if spec, ok := lookupMap(obj, "spec"); ok {
+			if img, ok := spec["image"].(string); ok && img != "" {
+				found = append(found, ManifestImage{Image: img, Resource: ref, Field: "spec.image"})
+			}
+			found = append(found, imagesFromPodSpec(spec, ref, "spec")...)
+		}
+	}
+	return found
+}
+
+// imagesFromPodSpec collects container images and image-bearing args from a PodSpec.
+func imagesFromPodSpec(podSpec map[string]interface{}, ref ResourceRef, prefix string) []ManifestImage {
+	var found []ManifestImage
+	for _, field := range containerFields {
+		containers, _ := podSpec[field].([]interface{})
+		for i, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := container["name"].(string)
+			containerPath := fmt.Sprintf("%s.%s[%d]", prefix, field, i)
+			if img, ok := container["image"].(string); ok && img != "" {
+				found = append(found, ManifestImage{Image: img, Resource: ref, Container: name, Field: containerPath + ".image"})
+			}
+			for _, argsField := range []string{"command", "args"} {
+				args, _ := container[argsField].([]interface{})
+				for j, img := range imagesFromArgs(args) {
+					if img == "" {
+						continue
+					}
+					found = append(found, ManifestImage{
+						Image:     img,
+						Resource:  ref,
+						Container: name,
+						Field:     containerPath + "." + argsField + "[" + strconv.Itoa(j) + "]",
+					})
+				}
+			}
+			env, _ := container["env"].([]interface{})
+			for j, e := range env {
+				envVar, _ := e.(map[string]interface{})
+				value, _ := envVar["value"].(string)
+				if !isImageReference(value) {
+					continue
+				}
+				found = append(found, ManifestImage{
+					Image:     value,
+					Resource:  ref,
+					Container: name,
+					Field:     containerPath + ".env[" + strconv.Itoa(j) + "].value",
+				})
+			}
+		}
+	}
+	return found
+}
+
+// isImageReference reports whether an env value is a full image reference: a
+// repository with a namespace or registry plus a tag or digest, such as
+// "quay.io/prometheus-operator/prometheus-config-reloader:v0.79.2". Host:port
+// pairs, URLs and plain values are rejected.
+func isImageReference(value string) bool {
+	if value == "" || strings.ContainsAny(value, " \t\n") || strings.Contains(value, "://") || strings.HasPrefix(value, "/") {
+		return false
+	}
+	name, _, _ := strings.Cut(value, "@")
+	if !strings.Contains(name, "/") {
+		return false
+	}
+	ref, err := ParseReference(value)
+	return err == nil && (ref.Tag != "" || ref.Digest != "")
+}
+
+// imagesFromArgs returns, for each argument index, the image named by a known
+// ImageArgFlags flag at that position (or "" when the argument holds no image).
+func imagesFromArgs(args []interface{}) []string {
+	images := make([]string, len(args))
+	for i, a := range args {
+		arg, ok := a.(string)
+		if !ok {
+			continue
+		}
+		for _, flag := range ImageArgFlags {
+			if value, ok := strings.CutPrefix(arg, flag+"="); ok {
+				images[i] = strings.Trim(value, `"'`)
+			} else if arg == flag && i+1 < len(args) {
+				if value, ok := args[i+1].(string); ok {
+					images[i+1] = value
+				}
+			}
+		}
+	}
+	return images
+}
+
+// lookupMap follows keys through nested maps.
+func lookupMap(obj map[string]interface{}, keys ...string) (map[string]interface{}, bool) {
+	current := obj
+	for _, key := range keys {
+		next, ok := current[key].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current = next
+	}
+	return current, true
+}
@@ -0,0 +1,216 @@
+package image_test
+
+import (
+	"testing"
+
+	"github.com/rancher/ob-charts-tool/helmtools/image"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const renderedMonitoring = `---
+# Source: rancher-monitoring/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: notes
+data:
+  # image: rancher/mirrored-commented:v1
+  readme: "image: rancher/mirrored-in-configmap:v1"
+---
+# Source: rancher-monitoring/templates/prometheus-operator/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: rancher-monitoring-operator
+  namespace: cattle-monitoring-system
+spec:
+  template:
+    spec:
+      containers:
+        - name: rancher-monitoring
+          image: "docker.io/rancher/mirrored-prometheus-operator-prometheus-operator:v0.79.2"
+          args:
+            - --kubelet-service=kube-system/rancher-monitoring-kubelet
+            - --prometheus-config-reloader=docker.io/rancher/mirrored-prometheus-operator-prometheus-config-reloader:v0.79.2
+            - --config-reloader-image
+            - rancher/mirrored-configmap-reload:v0.8.0
+---
+# Source: rancher-monitoring/charts/prometheus-node-exporter/templates/daemonset.yaml
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: node-exporter
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: init
+          image: rancher/mirrored-library-busybox:1.36
+      containers:
+        - name: node-exporter
+          image: rancher/mirrored-prometheus-node-exporter:v1.8.2
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: cleanup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: kubectl
+              image: rancher/kubectl:v1.31.1
+---
+apiVersion: monitoring.coreos.com/v1
+kind: Prometheus
+metadata:
+  name: rancher-monitoring-prometheus
+  namespace: cattle-monitoring-system
+spec:
+  image: docker.io/rancher/mirrored-prometheus-prometheus:v3.1.0
+  containers:
+    - name: prometheus-proxy
+      image: rancher/mirrored-library-nginx:1.27.2-alpine
+---
+apiVersion: monitoring.coreos.com/v1
+kind: Alertmanager
+metadata:
+  name: rancher-monitoring-alertmanager
+spec:
+  image: rancher/mirrored-prometheus-alertmanager:v0.28.0
+---
+apiVersion: example.com/v1
+kind: Prometheus
+metadata:
+  name: not-prometheus-operator
+spec:
+  image: should-be-ignored:v1
+---
+apiVersion: v1
+kind: List
+items:
+  - apiVersion: batch/v1
+    kind: Job
+    metadata:
+      name: hook
+    spec:
+      template:
+        spec:
+          containers:
+            - name: hook
+              image: rancher/shell:v0.3.0
+`
+
+func TestExtractImagesFromManifests(t *testing.T) {
+	found, err := image.ExtractImagesFromManifests([]byte(renderedMonitoring))
+	require.NoError(t, err)
+
+	var images []string
+	for _, f := range found {
+		images = append(images, f.Image)
+	}
+	assert.Equal(t, []string{
+		"docker.io/rancher/mirrored-prometheus-operator-prometheus-operator:v0.79.2",
+		"docker.io/rancher/mirrored-prometheus-operator-prometheus-config-reloader:v0.79.2",
+		"rancher/mirrored-configmap-reload:v0.8.0",
+		"rancher/mirrored-library-busybox:1.36",
+		"rancher/mirrored-prometheus-node-exporter:v1.8.2",
+		"rancher/kubectl:v1.31.1",
+		"docker.io/rancher/mirrored-prometheus-prometheus:v3.1.0",
+		"rancher/mirrored-library-nginx:1.27.2-alpine",
+		"rancher/mirrored-prometheus-alertmanager:v0.28.0",
+		"rancher/shell:v0.3.0",
+	}, images)
+
+	reloader := found[1]
+	assert.Equal(t, image.ResourceRef{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Namespace:  "cattle-monitoring-system",
+		Name:       "rancher-monitoring-operator",
+		Source:     "rancher-monitoring/templates/prometheus-operator/deployment.yaml",
+	}, reloader.Resource)
+	assert.Equal(t, "rancher-monitoring", reloader.Container)
+	assert.Equal(t, "spec.template.spec.containers[0].args[1]", reloader.Field)
+	assert.Equal(t, "spec.template.spec.containers[0].args[3]", found[2].Field)
+
+	assert.Equal(t, "spec.template.spec.initContainers[0].image", found[3].Field)
+	assert.Equal(t, "spec.jobTemplate.spec.template.spec.containers[0].image", found[5].Field)
+	assert.Equal(t, "spec.image", found[6].Field)
+	assert.Equal(t, "Prometheus/cattle-monitoring-system/rancher-monitoring-prometheus", found[6].Resource.String())
+	assert.Equal(t, "spec.containers[0].image", found[7].Field)
+	assert.Equal(t, "Job/hook", found[9].Resource.String())
+}
+
+func TestExtractImagesFromManifests_Env(t *testing.T) {
+	found, err := image.ExtractImagesFromManifests([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: operator
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: init
+          image: rancher/shell:v0.3.0
+          env:
+            - name: KUBECTL_IMAGE
+              value: rancher/kubectl:v1.31.1
+      containers:
+        - name: operator
+          image: rancher/operator:v1
+          env:
+            - name: RELOADER_IMAGE
+              value: quay.io/prometheus-operator/prometheus-config-reloader:v0.79.2
+            - name: PROMETHEUS_URL
+              value: http://prometheus.cattle-monitoring-system:9090/api
+            - name: LISTEN_ADDRESS
+              value: localhost:9090
+            - name: CONFIG
+              value: /etc/config:ro
+            - name: LOG_LEVEL
+              value: info
+            - name: UNTAGGED
+              value: rancher/operator
+            - name: POD_NAME
+              valueFrom:
+                fieldRef:
+                  fieldPath: metadata.name
+`))
+	require.NoError(t, err)
+	require.Len(t, found, 4)
+
+	assert.Equal(t, "rancher/kubectl:v1.31.1", found[1].Image)
+	assert.Equal(t, "init", found[1].Container)
+	assert.Equal(t, "spec.template.spec.initContainers[0].env[0].value", found[1].Field)
+	assert.Equal(t, "quay.io/prometheus-operator/prometheus-config-reloader:v0.79.2", found[3].Image)
+	assert.Equal(t, "operator", found[3].Container)
+	assert.Equal(t, "spec.template.spec.containers[0].env[0].value", found[3].Field)
+}
+
+func TestExtractImagesFromManifests_Invalid(t *testing.T) {
+	_, err := image.ExtractImagesFromManifests([]byte("kind: Pod\n  bad: [indent"))
+	assert.Error(t, err)
+
+	found, err := image.ExtractImagesFromManifests([]byte("---\n---\njust a string\n"))
+	require.NoError(t, err)
+	assert.Empty(t, found)
+}
+
+func TestOwnersByImage(t *testing.T) {
+	deploy := image.ResourceRef{Kind: "Deployment", Name: "a"}
+	ds := image.ResourceRef{Kind: "DaemonSet", Name: "b"}
+	owners := image.OwnersByImage([]image.ManifestImage{
+		{Image: "rancher/shell:v1", Resource: deploy},
+		{Image: "rancher/shell:v1", Resource: deploy},
+		{Image: "rancher/shell:v1", Resource: ds},
+		{Image: "rancher/kubectl:v1", Resource: ds},
+	})
+	assert.Equal(t, map[string][]image.ResourceRef{
+		"rancher/shell:v1":   {deploy, ds},
+		"rancher/kubectl:v1": {ds},
+	}, owners)
+}
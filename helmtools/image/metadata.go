@@ -21,11 +21,7 @@ type ImageReference struct {
 // FullImage returns the complete image reference as a string.
 // Examples: "registry/repository:tag" or "repository:tag"
 func (ref ImageReference) FullImage() string {
-	img := ref.Image
-	if img.Registry != "" {
-		return img.Registry + "/" + img.Repository + ":" + img.Tag
-	}
-	return img.Repository + ":" + img.Tag
+	return ref.Image.String()
 }
 
 // SupportsOS checks if the image supports the given operating system.
@@ -88,7 +84,7 @@ func extractImagesWithSource(node *yaml.Node, source string, defaultTag string,
 				osList := extractOSField(valueNode)
 
 				// Build full image string as key
-				fullImage := buildFullImage(img)
+				fullImage := img.String()
 
 				// Add or update ImageReference
 				if ref, exists := refs[fullImage]; exists {
@@ -131,14 +127,6 @@ func extractImagesWithSource(node *yaml.Node, source string, defaultTag string,
 	}
 }
 
-// buildFullImage constructs the full image reference string.
-func buildFullImage(img Image) string {
-	if img.Registry != "" {
-		return img.Registry + "/" + img.Repository + ":" + img.Tag
-	}
-	return img.Repository + ":" + img.Tag
-}
-
 // extractOSField extracts the OS field from an image YAML node.
 func extractOSField(node *yaml.Node) []string {
 	if node == nil || node.Kind != yaml.MappingNode {
@@ -173,7 +161,14 @@ func extractOSField(node *yaml.Node) []string {
 
 // detectOSFromName detects the operating system based on image name patterns.
 func detectOSFromName(img Image) string {
-	fullImage := strings.ToLower(buildFullImage(img))
+	return DetectOS(img.String())
+}
+
+// DetectOS guesses the operating system of an image from its name.
+// Images whose name mentions windows, nanoserver or windowsservercore are
+// "windows"; everything else is "linux".
+func DetectOS(imageName string) string {
+	fullImage := strings.ToLower(imageName)
 	if strings.Contains(fullImage, "windows") ||
 		strings.Contains(fullImage, "nanoserver") ||
 		strings.Contains(fullImage, "windowsservercore") {
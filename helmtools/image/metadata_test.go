@@ -194,6 +194,24 @@ func TestImageReference_FullImage(t *testing.T) {
 	}
 }
 
+func TestDetectOS(t *testing.T) {
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{image: "rancher/mirrored-prometheus-node-exporter:v1.8.2", want: "linux"},
+		{image: "rancher/windows_exporter-package:v0.0.4", want: "windows"},
+		{image: "mcr.microsoft.com/windows/nanoserver:1809", want: "windows"},
+		{image: "mcr.microsoft.com/windows/servercore:ltsc2022", want: "windows"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			assert.Equal(t, tt.want, image.DetectOS(tt.image))
+		})
+	}
+}
+
 func TestExtractImagesWithSources_ExplicitOSField(t *testing.T) {
 	tests := []struct {
 		name       string
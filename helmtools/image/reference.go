@@ -0,0 +1,210 @@
+package image
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// DefaultRegistry is the registry used for references without a registry host.
+	DefaultRegistry = "docker.io"
+	// DefaultTag is the tag implied by references without a tag or digest.
+	DefaultTag = "latest"
+
+	officialRepositoryNamespace = "library"
+)
+
+var (
+	// pathComponentPattern matches a single lowercase repository path component.
+	pathComponentPattern = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*$`)
+	tagPattern           = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	digestPattern        = regexp.MustCompile(`^[a-z0-9]+(?:[.+_-][a-z0-9]+)*:[a-zA-Z0-9=_-]+$`)
+
+	// ErrInvalidReference is returned when an image reference cannot be parsed.
+	ErrInvalidReference = errors.New("invalid image reference")
+)
+
+// Reference is a parsed and normalized container image reference.
+//
+// Registry and Repository are always set: references without a registry use
+// DefaultRegistry, and single-component Docker Hub repositories get the
+// implicit "library/" namespace. Tag and Digest are optional; when both are
+// set the digest identifies the content.
+type Reference struct {
+	Registry   string // e.g. "docker.io", "quay.io", "localhost:5000"
+	Repository string // e.g. "library/nginx", "rancher/mirrored-prometheus-prometheus"
+	Tag        string // e.g. "v3.1.0"
+	Digest     string // e.g. "sha256:..."
+}
+
+// ParseReference parses and normalizes an image reference such as "nginx",
+// "rancher/shell:v0.3.0", "localhost:5000/team/app:1.0" or
+// "quay.io/prometheus/prometheus:v3.1.0@sha256:...".
+func ParseReference(s string) (Reference, error) {
+	raw := s
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Reference{}, fmt.Errorf("%w: reference cannot be empty", ErrInvalidReference)
+	}
+
+	var ref Reference
+	if name, digest, found := strings.Cut(s, "@"); found {
+		if !digestPattern.MatchString(digest) {
+			return Reference{}, fmt.Errorf("%w %q: invalid digest %q", ErrInvalidReference, raw, digest)
+		}
+		ref.Digest = digest
+		s = name
+	}
+
+	if lastColon := strings.LastIndex(s, ":"); lastColon > strings.LastIndex(s, "/") {
+		tag := s[lastColon+1:]
+		if !tagPattern.MatchString(tag) {
+			return Reference{}, fmt.Errorf("%w %q: invalid tag %q", ErrInvalidReference, raw, tag)
+		}
+		ref.Tag = tag
+		s = s[:lastColon]
+	}
+
+	ref.Registry = DefaultRegistry
+	if first, rest, found := strings.Cut(s, "/"); found && isRegistryHost(first) {
+		ref.Registry = NormalizeRegistry(first)
+		s = rest
+	}
+	if ref.Registry == DefaultRegistry && !strings.Contains(s, "/") {
+		s = officialRepositoryNamespace + "/" + s
+	}
+
+	for component := range strings.SplitSeq(s, "/") {
+		if !pathComponentPattern.MatchString(component) {
+			return Reference{}, fmt.Errorf("%w %q: invalid repository %q", ErrInvalidReference, raw, s)
+		}
+	}
+	ref.Repository = s
+
+	return ref, nil
+}
+
+// MustParseReference is like ParseReference but panics on error.
+// It is intended for tests and package-level constants.
+func MustParseReference(s string) Reference {
+	ref, err := ParseReference(s)
+	if err != nil {
+		panic(err)
+	}
+	return ref
+}
+
+// Familiarize returns the familiar form of an image reference string (see
+// Reference.Familiar), or s unchanged when it cannot be parsed, e.g. because it
+// still contains template expressions.
+func Familiarize(s string) string {
+	ref, err := ParseReference(s)
+	if err != nil {
+		return s
+	}
+	return ref.Familiar()
+}
+
+// isRegistryHost reports whether the first path component of a reference names a registry.
+func isRegistryHost(component string) bool {
+	return strings.ContainsAny(component, ".:") || component == "localhost"
+}
+
+// NormalizeRegistry maps the Docker Hub host aliases to DefaultRegistry and
+// lowercases the host.
+func NormalizeRegistry(host string) string {
+	host = strings.ToLower(host)
+	switch host {
+	case "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return DefaultRegistry
+	}
+	return host
+}
+
+// Name returns the fully qualified repository name, e.g. "docker.io/library/nginx".
+func (r Reference) Name() string {
+	return r.Registry + "/" + r.Repository
+}
+
+// FamiliarName returns the repository name as users usually write it: Docker
+// Hub references drop the registry and the "library/" namespace.
+func (r Reference) FamiliarName() string {
+	if r.Registry != DefaultRegistry {
+		return r.Name()
+	}
+	if name, ok := strings.CutPrefix(r.Repository, officialRepositoryNamespace+"/"); ok && !strings.Contains(name, "/") {
+		return name
+	}
+	return r.Repository
+}
+
+// Identifier returns the manifest reference to request from a registry: the
+// digest when set, otherwise the tag, otherwise DefaultTag.
+func (r Reference) Identifier() string {
+	switch {
+	case r.Digest != "":
+		return r.Digest
+	case r.Tag != "":
+		return r.Tag
+	default:
+		return DefaultTag
+	}
+}
+
+// String returns the fully qualified reference, e.g. "docker.io/library/nginx:1.27@sha256:...".
+func (r Reference) String() string {
+	return r.Name() + r.suffix()
+}
+
+// Familiar returns the shortest equivalent reference, e.g. "nginx:1.27" or "rancher/shell:v0.3.0".
+func (r Reference) Familiar() string {
+	return r.FamiliarName() + r.suffix()
+}
+
+// WithTag returns a copy of the reference with the tag replaced and the digest cleared.
+func (r Reference) WithTag(tag string) Reference {
+	r.Tag = tag
+	r.Digest = ""
+	return r
+}
+
+// WithDigest returns a copy of the reference pinned to digest.
+func (r Reference) WithDigest(digest string) Reference {
+	r.Digest = digest
+	return r
+}
+
+func (r Reference) suffix() string {
+	var b strings.Builder
+	if r.Tag != "" {
+		b.WriteString(":" + r.Tag)
+	}
+	if r.Digest != "" {
+		b.WriteString("@" + r.Digest)
+	}
+	return b.String()
+}
+
+// Reference parses the image as it appears in values.yaml into a normalized
+// Reference. The repository may itself include a registry (as many charts
+// leave registry empty), and the tag may carry an "@sha256:" digest.
+func (img Image) Reference() (Reference, error) {
+	return ParseReference(img.String())
+}
+
+// String joins the image fields as written in values.yaml, e.g.
+// "quay.io/prometheus/prometheus:v3.1.0" or "nginx:1.27". Unlike
+// Reference.String it does not add an implicit registry or namespace.
+func (img Image) String() string {
+	var b strings.Builder
+	if img.Registry != "" {
+		b.WriteString(strings.TrimSuffix(img.Registry, "/") + "/")
+	}
+	b.WriteString(img.Repository)
+	if img.Tag != "" {
+		b.WriteString(":" + img.Tag)
+	}
+	return b.String()
+}
@@ -0,0 +1,192 @@
+package image_test
+
+import (
+	"testing"
+
+	"github.com/rancher/ob-charts-tool/helmtools/image"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		input        string
+		want         image.Reference
+		wantString   string
+		wantFamiliar string
+	}{
+		{
+			input:        "nginx",
+			want:         image.Reference{Registry: "docker.io", Repository: "library/nginx"},
+			wantString:   "docker.io/library/nginx",
+			wantFamiliar: "nginx",
+		},
+		{
+			input:        "nginx:1.27",
+			want:         image.Reference{Registry: "docker.io", Repository: "library/nginx", Tag: "1.27"},
+			wantString:   "docker.io/library/nginx:1.27",
+			wantFamiliar: "nginx:1.27",
+		},
+		{
+			input:        "docker.io/rancher/shell:v0.3.0",
+			want:         image.Reference{Registry: "docker.io", Repository: "rancher/shell", Tag: "v0.3.0"},
+			wantString:   "docker.io/rancher/shell:v0.3.0",
+			wantFamiliar: "rancher/shell:v0.3.0",
+		},
+		{
+			input:        "index.docker.io/library/busybox:1.36",
+			want:         image.Reference{Registry: "docker.io", Repository: "library/busybox", Tag: "1.36"},
+			wantString:   "docker.io/library/busybox:1.36",
+			wantFamiliar: "busybox:1.36",
+		},
+		{
+			input:        "localhost:5000/team/app:1.0",
+			want:         image.Reference{Registry: "localhost:5000", Repository: "team/app", Tag: "1.0"},
+			wantString:   "localhost:5000/team/app:1.0",
+			wantFamiliar: "localhost:5000/team/app:1.0",
+		},
+		{
+			input:        "localhost/app",
+			want:         image.Reference{Registry: "localhost", Repository: "app"},
+			wantString:   "localhost/app",
+			wantFamiliar: "localhost/app",
+		},
+		{
+			input:        "registry.example.com:8443/app",
+			want:         image.Reference{Registry: "registry.example.com:8443", Repository: "app"},
+			wantString:   "registry.example.com:8443/app",
+			wantFamiliar: "registry.example.com:8443/app",
+		},
+		{
+			input:        "quay.io/prometheus/prometheus@" + testDigest,
+			want:         image.Reference{Registry: "quay.io", Repository: "prometheus/prometheus", Digest: testDigest},
+			wantString:   "quay.io/prometheus/prometheus@" + testDigest,
+			wantFamiliar: "quay.io/prometheus/prometheus@" + testDigest,
+		},
+		{
+			input:        "rancher/mirrored-prometheus-prometheus:v3.1.0@" + testDigest,
+			want:         image.Reference{Registry: "docker.io", Repository: "rancher/mirrored-prometheus-prometheus", Tag: "v3.1.0", Digest: testDigest},
+			wantString:   "docker.io/rancher/mirrored-prometheus-prometheus:v3.1.0@" + testDigest,
+			wantFamiliar: "rancher/mirrored-prometheus-prometheus:v3.1.0@" + testDigest,
+		},
+		{
+			input:        "docker.io/library/team/app:v1",
+			want:         image.Reference{Registry: "docker.io", Repository: "library/team/app", Tag: "v1"},
+			wantString:   "docker.io/library/team/app:v1",
+			wantFamiliar: "library/team/app:v1",
+		},
+		{
+			input:        "  ghcr.io/org/sub_group/app__x:1.0-rc.1 ",
+			want:         image.Reference{Registry: "ghcr.io", Repository: "org/sub_group/app__x", Tag: "1.0-rc.1"},
+			wantString:   "ghcr.io/org/sub_group/app__x:1.0-rc.1",
+			wantFamiliar: "ghcr.io/org/sub_group/app__x:1.0-rc.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			ref, err := image.ParseReference(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ref)
+			assert.Equal(t, tt.wantString, ref.String())
+			assert.Equal(t, tt.wantFamiliar, ref.Familiar())
+
+			// Both forms parse back to the same reference.
+			assert.Equal(t, ref, image.MustParseReference(ref.String()))
+			assert.Equal(t, ref, image.MustParseReference(ref.Familiar()))
+		})
+	}
+}
+
+func TestParseReference_Invalid(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"   ",
+		"Rancher/Shell:v1",
+		"rancher/shell:",
+		"rancher/shell:bad/tag",
+		"rancher/shell@sha256",
+		"rancher/shell@:abc",
+		"rancher//shell",
+		"{{ .Values.image }}",
+	} {
+		t.Run(input, func(t *testing.T) {
+			_, err := image.ParseReference(input)
+			assert.ErrorIs(t, err, image.ErrInvalidReference)
+		})
+	}
+
+	assert.Panics(t, func() { image.MustParseReference("") })
+}
+
+func TestReference_Identifier(t *testing.T) {
+	assert.Equal(t, "latest", image.MustParseReference("nginx").Identifier())
+	assert.Equal(t, "1.27", image.MustParseReference("nginx:1.27").Identifier())
+	assert.Equal(t, testDigest, image.MustParseReference("nginx:1.27@"+testDigest).Identifier())
+}
+
+func TestReference_WithTagAndDigest(t *testing.T) {
+	ref := image.MustParseReference("rancher/shell:v0.3.0@" + testDigest)
+	assert.Equal(t, "rancher/shell:v0.4.0", ref.WithTag("v0.4.0").Familiar())
+	assert.Equal(t, "rancher/shell:v0.3.0@sha256:ffff", ref.WithDigest("sha256:ffff").Familiar())
+	assert.Equal(t, "docker.io/rancher/shell", ref.Name())
+	assert.Equal(t, "rancher/shell", ref.FamiliarName())
+}
+
+func TestFamiliarize(t *testing.T) {
+	assert.Equal(t, "rancher/shell:v0.3.0", image.Familiarize("docker.io/rancher/shell:v0.3.0"))
+	assert.Equal(t, "nginx:1.27", image.Familiarize("docker.io/library/nginx:1.27"))
+	assert.Equal(t, "quay.io/prometheus/prometheus:v3.1.0", image.Familiarize("quay.io/prometheus/prometheus:v3.1.0"))
+	assert.Equal(t, "{{ .Values.image }}", image.Familiarize("{{ .Values.image }}"))
+}
+
+func TestImage_Reference(t *testing.T) {
+	tests := []struct {
+		name       string
+		img        image.Image
+		wantString string
+		want       string
+	}{
+		{
+			name:       "registry field",
+			img:        image.Image{Registry: "quay.io", Repository: "prometheus/prometheus", Tag: "v3.1.0"},
+			wantString: "quay.io/prometheus/prometheus:v3.1.0",
+			want:       "quay.io/prometheus/prometheus:v3.1.0",
+		},
+		{
+			name:       "registry with trailing slash",
+			img:        image.Image{Registry: "registry.k8s.io/", Repository: "kube-state-metrics/kube-state-metrics", Tag: "v2.14.0"},
+			wantString: "registry.k8s.io/kube-state-metrics/kube-state-metrics:v2.14.0",
+			want:       "registry.k8s.io/kube-state-metrics/kube-state-metrics:v2.14.0",
+		},
+		{
+			name:       "registry in repository",
+			img:        image.Image{Repository: "localhost:5000/app", Tag: "1.0"},
+			wantString: "localhost:5000/app:1.0",
+			want:       "localhost:5000/app:1.0",
+		},
+		{
+			name:       "digest in tag",
+			img:        image.Image{Repository: "rancher/shell", Tag: "v0.3.0@" + testDigest},
+			wantString: "rancher/shell:v0.3.0@" + testDigest,
+			want:       "docker.io/rancher/shell:v0.3.0@" + testDigest,
+		},
+		{
+			name:       "no tag",
+			img:        image.Image{Repository: "busybox"},
+			wantString: "busybox",
+			want:       "docker.io/library/busybox",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantString, tt.img.String())
+			ref, err := tt.img.Reference()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ref.String())
+		})
+	}
+}
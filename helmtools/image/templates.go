@@ -44,12 +44,8 @@ func ExtractImagesFromTemplates(renderedChart string) util.Set[string] {
 		}
 		return s
 	}).Map(func(s string) string {
-		// Remove quotes and docker.io/ prefix
-		s = strings.ReplaceAll(s, "\"", "")
-		if strings.Index(s, "docker.io/") == 0 {
-			s = strings.ReplaceAll(s, "docker.io/", "")
-		}
-		return s
+		// Remove quotes and the implicit docker.io/ registry
+		return Familiarize(strings.ReplaceAll(s, "\"", ""))
 	})
 
 	return imagesSet
@@ -0,0 +1,190 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Challenge is a parsed WWW-Authenticate challenge returned by a registry.
+type Challenge struct {
+	Scheme string            // Lower-cased auth scheme (e.g. "bearer" or "basic")
+	Params map[string]string // Challenge parameters (e.g. realm, service, scope)
+}
+
+// ParseChallenge parses a WWW-Authenticate header value such as:
+//
+//	Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/nginx:pull"
+//
+// Quoted parameter values may contain commas and escaped quotes.
+func ParseChallenge(header string) (Challenge, error) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return Challenge{}, errors.New("challenge header cannot be empty")
+	}
+
+	scheme, rest, _ := strings.Cut(header, " ")
+	challenge := Challenge{
+		Scheme: strings.ToLower(scheme),
+		Params: make(map[string]string),
+	}
+
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+		key, after, found := strings.Cut(rest, "=")
+		if !found {
+			return Challenge{}, fmt.Errorf("malformed challenge parameter %q", rest)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		var value string
+		if strings.HasPrefix(after, `"`) {
+			var err error
+			value, rest, err = readQuoted(after[1:])
+			if err != nil {
+				return Challenge{}, err
+			}
+		} else {
+			value, rest, _ = strings.Cut(after, ",")
+			value = strings.TrimSpace(value)
+		}
+		challenge.Params[key] = value
+
+		rest = strings.TrimPrefix(strings.TrimSpace(rest), ",")
+	}
+
+	return challenge, nil
+}
+
+// readQuoted reads a quoted-string body (after the opening quote) and returns the
+// unescaped value and the remaining input after the closing quote.
+func readQuoted(s string) (string, string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				sb.WriteByte(s[i])
+			}
+		case '"':
+			return sb.String(), s[i+1:], nil
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return "", "", errors.New("unterminated quoted string in challenge")
+}
+
+// tokenResponse is the body returned by a bearer token service.
+// Registries use either "token" or "access_token" for the same value.
+type tokenResponse struct {
+	Token       string `json:"token"`
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// fetchToken requests a bearer token from the realm advertised in the challenge
+// and returns it along with its lifetime (zero if the service did not say).
+// The provided scope takes precedence over a scope carried by the challenge, as
+// challenges are cached per host and answer requests for other repositories.
+func (c *Client) fetchToken(ctx context.Context, challenge Challenge, scope string, cred Credential) (string, time.Duration, error) {
+	realm := challenge.Params["realm"]
+	if realm == "" {
+		return "", 0, errors.New("bearer challenge has no realm")
+	}
+
+	realmURL, err := url.Parse(realm)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid token realm %q: %w", realm, err)
+	}
+
+	query := realmURL.Query()
+	if service := challenge.Params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	if scope == "" {
+		scope = challenge.Params["scope"]
+	}
+	if scope != "" {
+		query.Set("scope", scope)
+	}
+	realmURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realmURL.String(), nil)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create token request: %w", err)
+	}
+	if !cred.IsEmpty() {
+		req.SetBasicAuth(cred.Username, cred.Password)
+	}
+
+	resp, err := c.sendWithRetry(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to fetch token from %s: %w", realmURL.Host, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return "", 0, fmt.Errorf("%w: token service returned HTTP %d", ErrUnauthorized, resp.StatusCode)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", 0, fmt.Errorf("HTTP %d fetching token from %s", resp.StatusCode, realmURL.Host)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	var tr tokenResponse
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return "", 0, fmt.Errorf("failed to parse token response: %w", err)
+	}
+
+	token := tr.Token
+	if token == "" {
+		token = tr.AccessToken
+	}
+	if token == "" {
+		return "", 0, errors.New("token response did not contain a token")
+	}
+	return token, time.Duration(tr.ExpiresIn) * time.Second, nil
+}
+
+// applyChallenge authorizes req according to a registry challenge, reusing a
+// cached bearer token for the scope when one is still valid.
+func (c *Client) applyChallenge(ctx context.Context, req *http.Request, host, scope string, challenge Challenge, cred Credential) error {
+	switch challenge.Scheme {
+	case "bearer":
+		token, ok := c.tokens.token(host, scope, time.Now())
+		if !ok {
+			var lifetime time.Duration
+			var err error
+			token, lifetime, err = c.fetchToken(ctx, challenge, scope, cred)
+			if err != nil {
+				return err
+			}
+			c.tokens.setToken(host, scope, token, lifetime, time.Now())
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	case "basic":
+		if cred.IsEmpty() {
+			return fmt.Errorf("%w: registry %s requires credentials", ErrUnauthorized, host)
+		}
+		req.SetBasicAuth(cred.Username, cred.Password)
+	default:
+		return fmt.Errorf("unsupported auth scheme %q from registry %s", challenge.Scheme, host)
+	}
+	return nil
+}
+
+// pullScope returns the token scope needed to pull from a repository.
+func pullScope(repository string) string {
+	return "repository:" + repository + ":pull"
+}
@@ -0,0 +1,90 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChallenge(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		wantScheme string
+		wantParams map[string]string
+		wantErr    bool
+	}{
+		{
+			name:       "docker hub bearer challenge",
+			header:     `Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/nginx:pull"`,
+			wantScheme: "bearer",
+			wantParams: map[string]string{
+				"realm":   "https://auth.docker.io/token",
+				"service": "registry.docker.io",
+				"scope":   "repository:library/nginx:pull",
+			},
+		},
+		{
+			name:       "scope containing commas",
+			header:     `Bearer realm="https://ghcr.io/token",scope="repository:org/app:pull,push"`,
+			wantScheme: "bearer",
+			wantParams: map[string]string{
+				"realm": "https://ghcr.io/token",
+				"scope": "repository:org/app:pull,push",
+			},
+		},
+		{
+			name:       "basic challenge",
+			header:     `Basic realm="Registry Realm"`,
+			wantScheme: "basic",
+			wantParams: map[string]string{"realm": "Registry Realm"},
+		},
+		{
+			name:       "unquoted values and whitespace",
+			header:     `Bearer realm=https://quay.io/v2/auth , service=quay.io`,
+			wantScheme: "bearer",
+			wantParams: map[string]string{
+				"realm":   "https://quay.io/v2/auth",
+				"service": "quay.io",
+			},
+		},
+		{
+			name:       "escaped quote in value",
+			header:     `Bearer realm="https://example.com/token",error="bad \"scope\""`,
+			wantScheme: "bearer",
+			wantParams: map[string]string{
+				"realm": "https://example.com/token",
+				"error": `bad "scope"`,
+			},
+		},
+		{
+			name:    "empty header",
+			header:  "",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated quote",
+			header:  `Bearer realm="https://example.com`,
+			wantErr: true,
+		},
+		{
+			name:    "parameter without value",
+			header:  `Bearer realm`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseChallenge(tt.header)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantScheme, got.Scheme)
+			assert.Equal(t, tt.wantParams, got.Params)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ImageStatus classifies the outcome of checking an image in its registry.
+type ImageStatus string
+
+const (
+	// StatusFound means the image manifest exists.
+	StatusFound ImageStatus = "found"
+	// StatusMissing means the registry answered but has no such manifest.
+	StatusMissing ImageStatus = "missing"
+	// StatusUnauthorized means the registry rejected the (possibly anonymous) credentials.
+	StatusUnauthorized ImageStatus = "unauthorized"
+	// StatusRateLimited means the registry kept answering HTTP 429 after all retries.
+	StatusRateLimited ImageStatus = "rate_limited"
+	// StatusNetworkError covers transport failures and unexpected registry responses.
+	StatusNetworkError ImageStatus = "network_error"
+)
+
+// DefaultConcurrency is the number of parallel lookups used by CheckImages when
+// a non-positive concurrency is requested.
+const DefaultConcurrency = 8
+
+// CheckResult is the outcome of checking a single image.
+type CheckResult struct {
+	Image  string      `json:"image" yaml:"image"`
+	Status ImageStatus `json:"status" yaml:"status"`
+	Digest string      `json:"digest,omitempty" yaml:"digest,omitempty"`
+	Err    error       `json:"-" yaml:"-"`
+}
+
+// Exists reports whether the image was found.
+func (r CheckResult) Exists() bool {
+	return r.Status == StatusFound
+}
+
+// CheckImage looks up a single image and classifies the outcome.
+func (c *Client) CheckImage(ctx context.Context, imageName string) CheckResult {
+	desc, err := c.HeadManifest(ctx, imageName)
+	if err == nil {
+		return CheckResult{Image: imageName, Status: StatusFound, Digest: desc.Digest}
+	}
+	return CheckResult{Image: imageName, Status: classifyError(err), Err: err}
+}
+
+// CheckImages looks up images using at most concurrency parallel requests.
+// Results are returned in the same order as images. Cancelling ctx stops
+// outstanding lookups; their results report the context error.
+func (c *Client) CheckImages(ctx context.Context, images []string, concurrency int) []CheckResult {
+	results := make([]CheckResult, len(images))
+	runConcurrently(len(images), concurrency, func(i int) {
+		results[i] = c.CheckImage(ctx, images[i])
+	})
+	return results
+}
+
+// runConcurrently calls fn for every index in [0, n) using at most concurrency
+// goroutines, falling back to DefaultConcurrency for non-positive values.
+func runConcurrently(n, concurrency int, fn func(i int)) {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	if concurrency > n {
+		concurrency = n
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Go(func() {
+			for i := range indexes {
+				fn(i)
+			}
+		})
+	}
+	for i := range n {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// classifyError maps an error from HeadManifest to an ImageStatus.
+func classifyError(err error) ImageStatus {
+	switch {
+	case errors.Is(err, errManifestNotFound):
+		return StatusMissing
+	case errors.Is(err, ErrUnauthorized):
+		return StatusUnauthorized
+	case errors.Is(err, ErrRateLimited):
+		return StatusRateLimited
+	default:
+		return StatusNetworkError
+	}
+}
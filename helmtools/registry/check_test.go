@@ -0,0 +1,87 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_CheckImages(t *testing.T) {
+	fr := newFakeRegistry(t, "bearer")
+	fr.addManifest("rancher/a", "v1", fakeManifest{digest: "sha256:a"})
+	fr.addManifest("rancher/b", "v1", fakeManifest{digest: "sha256:b"})
+	c := fr.client(t, nil)
+
+	images := []string{
+		fr.host() + "/rancher/a:v1",
+		fr.host() + "/rancher/missing:v1",
+		fr.host() + "/rancher/b:v1",
+	}
+	results := c.CheckImages(context.Background(), images, 2)
+	require.Len(t, results, 3)
+
+	assert.Equal(t, images[0], results[0].Image, "results keep input order")
+	assert.Equal(t, StatusFound, results[0].Status)
+	assert.Equal(t, "sha256:a", results[0].Digest)
+	assert.True(t, results[0].Exists())
+
+	assert.Equal(t, StatusMissing, results[1].Status)
+	assert.False(t, results[1].Exists())
+
+	assert.Equal(t, StatusFound, results[2].Status)
+}
+
+func TestClient_CheckImages_Empty(t *testing.T) {
+	c, err := NewClient(&http.Client{}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, c.CheckImages(context.Background(), nil, 4))
+}
+
+func TestClient_CheckImages_CachesTokensPerScope(t *testing.T) {
+	fr := newFakeRegistry(t, "bearer")
+	var images []string
+	for i := range 20 {
+		tag := fmt.Sprintf("v%d", i)
+		fr.addManifest("rancher/app", tag, fakeManifest{digest: "sha256:" + tag})
+		images = append(images, fr.host()+"/rancher/app:"+tag)
+	}
+	fr.addManifest("rancher/other", "v1", fakeManifest{digest: "sha256:other"})
+	c := fr.client(t, nil)
+
+	// Prime the challenge so the parallel batch can authorize up front.
+	require.Equal(t, StatusFound, c.CheckImage(context.Background(), images[0]).Status)
+	require.Equal(t, 1, fr.tokenRequests)
+
+	for _, r := range c.CheckImages(context.Background(), images, 1) {
+		assert.Equal(t, StatusFound, r.Status, r.Image)
+	}
+	assert.Equal(t, 1, fr.tokenRequests, "same repository scope reuses the cached token")
+
+	require.Equal(t, StatusFound, c.CheckImage(context.Background(), fr.host()+"/rancher/other:v1").Status)
+	assert.Equal(t, 2, fr.tokenRequests, "a new repository scope needs a new token")
+}
+
+func TestClient_CheckImage_Statuses(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("unauthorized", func(t *testing.T) {
+		fr := newFakeRegistry(t, "basic")
+		fr.username, fr.password = "user", "secret"
+		result := fr.client(t, nil).CheckImage(ctx, fr.host()+"/private/app:v1")
+		assert.Equal(t, StatusUnauthorized, result.Status)
+	})
+
+	t.Run("network error", func(t *testing.T) {
+		fr := newFakeRegistry(t, "")
+		c := fr.client(t, nil)
+		host := fr.host()
+		fr.server.Close()
+		result := c.CheckImage(ctx, host+"/rancher/app:v1")
+		assert.Equal(t, StatusNetworkError, result.Status)
+		assert.Error(t, result.Err)
+	})
+}
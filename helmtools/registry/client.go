@@ -0,0 +1,205 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rancher/ob-charts-tool/helmtools/image"
+)
+
+const (
+	dockerHubHost    = image.DefaultRegistry
+	dockerHubAPIHost = "registry-1.docker.io"
+)
+
+// Manifest media types accepted when looking up a manifest.
+const (
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+)
+
+// acceptedManifestTypes is sent as the Accept header so registries return
+// multi-arch indexes rather than falling back to schema1 manifests.
+var acceptedManifestTypes = []string{
+	MediaTypeOCIIndex,
+	MediaTypeDockerManifestList,
+	MediaTypeOCIManifest,
+	MediaTypeDockerManifest,
+}
+
+var (
+	// ErrUnauthorized is returned when the registry rejects the request credentials.
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+// Descriptor describes a manifest as reported by the registry.
+type Descriptor struct {
+	MediaType string
+	Digest    string
+	Size      int64
+}
+
+// Client is an OCI Distribution v2 registry client.
+// Client is safe for concurrent use by multiple goroutines.
+type Client struct {
+	httpClient  *http.Client
+	credentials *DockerConfig
+	retry       RetryPolicy
+	tokens      *tokenCache
+}
+
+// NewClient creates a new registry Client with the given HTTP client.
+// The httpClient parameter must not be nil. credentials may be nil for anonymous access.
+// The client uses DefaultRetryPolicy and caches bearer tokens per repository scope.
+func NewClient(httpClient *http.Client, credentials *DockerConfig) (*Client, error) {
+	if httpClient == nil {
+		return nil, errors.New("httpClient cannot be nil")
+	}
+	return &Client{
+		httpClient:  httpClient,
+		credentials: credentials,
+		retry:       DefaultRetryPolicy,
+		tokens:      newTokenCache(),
+	}, nil
+}
+
+// SetRetryPolicy replaces the client's retry policy.
+// It must be called before the client is used concurrently.
+func (c *Client) SetRetryPolicy(policy RetryPolicy) {
+	c.retry = policy
+}
+
+// ManifestExists reports whether the image's manifest exists in its registry.
+// A missing manifest returns (false, nil); authentication and transport failures return an error.
+func (c *Client) ManifestExists(ctx context.Context, imageName string) (bool, error) {
+	_, err := c.HeadManifest(ctx, imageName)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, errManifestNotFound) {
+		return false, nil
+	}
+	return false, err
+}
+
+var errManifestNotFound = errors.New("manifest not found")
+
+// HeadManifest fetches the descriptor of an image's manifest without downloading it.
+func (c *Client) HeadManifest(ctx context.Context, imageName string) (*Descriptor, error) {
+	host, repository, reference, err := parseImageName(imageName)
+	if err != nil {
+		return nil, err
+	}
+
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", apiHost(host), repository, reference)
+	resp, err := c.do(ctx, http.MethodHead, manifestURL, host, repository)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", errManifestNotFound, imageName)
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, fmt.Errorf("%w: HTTP %d for %s", ErrUnauthorized, resp.StatusCode, imageName)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return nil, fmt.Errorf("HTTP %d fetching manifest for %s", resp.StatusCode, imageName)
+	}
+
+	size, _ := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	return &Descriptor{
+		MediaType: resp.Header.Get("Content-Type"),
+		Digest:    resp.Header.Get("Docker-Content-Digest"),
+		Size:      size,
+	}, nil
+}
+
+// do sends a registry request. Requests to a host whose challenge is already
+// known are authorized up front; otherwise a single WWW-Authenticate challenge
+// is answered when the registry responds with 401. The caller must close the
+// response body.
+func (c *Client) do(ctx context.Context, method, rawURL, host, repository string) (*http.Response, error) {
+	scope := pullScope(repository)
+	cred, _ := c.credentials.CredentialFor(host)
+
+	req, err := c.newRequest(ctx, method, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if challenge, ok := c.tokens.challenge(host); ok {
+		if err := c.applyChallenge(ctx, req, host, scope, challenge, cred); err != nil && !errors.Is(err, ErrUnauthorized) {
+			return nil, err
+		}
+	}
+
+	resp, err := c.sendWithRetry(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach registry %s: %w", host, err)
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+
+	header := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+	if header == "" {
+		return nil, fmt.Errorf("%w: registry %s returned 401 without a challenge", ErrUnauthorized, host)
+	}
+
+	challenge, err := ParseChallenge(header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse challenge from %s: %w", host, err)
+	}
+	c.tokens.setChallenge(host, challenge)
+	c.tokens.invalidate(host, scope)
+
+	req, err = c.newRequest(ctx, method, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.applyChallenge(ctx, req, host, scope, challenge, cred); err != nil {
+		return nil, err
+	}
+
+	resp, err = c.sendWithRetry(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach registry %s: %w", host, err)
+	}
+	return resp, nil
+}
+
+func (c *Client) newRequest(ctx context.Context, method, rawURL string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for %s: %w", rawURL, err)
+	}
+	req.Header.Set("Accept", strings.Join(acceptedManifestTypes, ", "))
+	return req, nil
+}
+
+// apiHost maps a registry name to the host serving its v2 API.
+func apiHost(host string) string {
+	if host == dockerHubHost {
+		return dockerHubAPIHost
+	}
+	return host
+}
+
+// parseImageName splits an image name into registry host, repository and
+// reference (tag or digest) using image.ParseReference. Images without a
+// registry resolve to Docker Hub, single-segment Docker Hub names get the
+// implicit "library/" namespace, and images without a tag or digest use "latest".
+func parseImageName(imageName string) (host, repository, reference string, err error) {
+	ref, err := image.ParseReference(imageName)
+	if err != nil {
+		return "", "", "", err
+	}
+	return ref.Registry, ref.Repository, ref.Identifier(), nil
+}
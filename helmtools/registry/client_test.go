@@ -0,0 +1,321 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeManifest is a manifest served by fakeRegistry.
+type fakeManifest struct {
+	mediaType string
+	digest    string
+	body      string
+}
+
+// fakeRegistry is an in-process stand-in for an OCI registry. It serves
+// manifests under /v2/<repo>/manifests/<ref>, blobs under
+// /v2/<repo>/blobs/<digest> and, in bearer mode, issues tokens from /token.
+type fakeRegistry struct {
+	server    *httptest.Server
+	authMode  string // "", "bearer" or "basic"
+	username  string
+	password  string
+	manifests map[string]fakeManifest // "<repo>:<ref>" → manifest
+	blobs     map[string]string       // "<repo>@<digest>" → blob content
+
+	mu                 sync.Mutex
+	tokenRequests      int
+	manifestRequests   int
+	scopes             []string
+	challenges         int
+	scopedTokens       bool   // bearer challenges carry the repository scope and tokens are per scope
+	rateLimitRemaining int    // number of manifest requests to answer with 429
+	retryAfter         string // Retry-After header sent with 429 responses
+}
+
+func newFakeRegistry(t *testing.T, authMode string) *fakeRegistry {
+	t.Helper()
+	fr := &fakeRegistry{
+		authMode:  authMode,
+		manifests: make(map[string]fakeManifest),
+		blobs:     make(map[string]string),
+	}
+	fr.server = httptest.NewTLSServer(http.HandlerFunc(fr.serveHTTP))
+	t.Cleanup(fr.server.Close)
+	return fr
+}
+
+// host returns the registry host:port used in image names.
+func (fr *fakeRegistry) host() string {
+	return strings.TrimPrefix(fr.server.URL, "https://")
+}
+
+func (fr *fakeRegistry) addManifest(repo, ref string, m fakeManifest) {
+	if m.mediaType == "" {
+		m.mediaType = MediaTypeDockerManifest
+	}
+	fr.manifests[repo+":"+ref] = m
+}
+
+func (fr *fakeRegistry) addBlob(repo, digest, content string) {
+	fr.blobs[repo+"@"+digest] = content
+}
+
+func (fr *fakeRegistry) client(t *testing.T, cfg *DockerConfig) *Client {
+	t.Helper()
+	c, err := NewClient(fr.server.Client(), cfg)
+	require.NoError(t, err)
+	c.SetRetryPolicy(RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond})
+	return c
+}
+
+func (fr *fakeRegistry) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/token" {
+		fr.mu.Lock()
+		fr.tokenRequests++
+		fr.scopes = append(fr.scopes, r.URL.Query().Get("scope"))
+		fr.mu.Unlock()
+		if fr.username != "" {
+			user, pass, ok := r.BasicAuth()
+			if !ok || user != fr.username || pass != fr.password {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+		token := "test-token"
+		if fr.scopedTokens {
+			token = "token-" + r.URL.Query().Get("scope")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"token":"` + token + `"}`))
+		return
+	}
+
+	switch fr.authMode {
+	case "bearer":
+		token, challenge := "test-token", `Bearer realm="`+fr.server.URL+`/token",service="fake-registry"`
+		if fr.scopedTokens {
+			scope := "repository:" + fakeRepository(r.URL.Path) + ":pull"
+			token, challenge = "token-"+scope, challenge+`,scope="`+scope+`"`
+		}
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			fr.mu.Lock()
+			fr.challenges++
+			fr.mu.Unlock()
+			w.Header().Set("WWW-Authenticate", challenge)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	case "basic":
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != fr.username || pass != fr.password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="fake"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+
+	fr.mu.Lock()
+	fr.manifestRequests++
+	limited := fr.rateLimitRemaining > 0
+	if limited {
+		fr.rateLimitRemaining--
+	}
+	fr.mu.Unlock()
+	if limited {
+		if fr.retryAfter != "" {
+			w.Header().Set("Retry-After", fr.retryAfter)
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/v2/")
+	if repo, digest, found := strings.Cut(path, "/blobs/"); found {
+		blob, ok := fr.blobs[repo+"@"+digest]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write([]byte(blob))
+		return
+	}
+	repo, ref, found := strings.Cut(path, "/manifests/")
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	m, ok := fr.manifests[repo+":"+ref]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", m.mediaType)
+	w.Header().Set("Docker-Content-Digest", m.digest)
+	if r.Method == http.MethodHead {
+		w.Header().Set("Content-Length", "0")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	_, _ = w.Write([]byte(m.body))
+}
+
+// fakeRepository returns the repository of a /v2/<repo>/manifests or blobs path.
+func fakeRepository(path string) string {
+	path = strings.TrimPrefix(path, "/v2/")
+	if repo, _, found := strings.Cut(path, "/manifests/"); found {
+		return repo
+	}
+	repo, _, _ := strings.Cut(path, "/blobs/")
+	return repo
+}
+
+func TestNewClient(t *testing.T) {
+	_, err := NewClient(nil, nil)
+	assert.Error(t, err)
+
+	c, err := NewClient(&http.Client{}, nil)
+	require.NoError(t, err)
+	assert.NotNil(t, c)
+}
+
+func TestParseImageName(t *testing.T) {
+	tests := []struct {
+		image                      string
+		wantHost, wantRepo, wantRf string
+		wantErr                    bool
+	}{
+		{image: "nginx", wantHost: "docker.io", wantRepo: "library/nginx", wantRf: "latest"},
+		{image: "rancher/shell:v0.2.1", wantHost: "docker.io", wantRepo: "rancher/shell", wantRf: "v0.2.1"},
+		{image: "docker.io/rancher/shell:v0.2.1", wantHost: "docker.io", wantRepo: "rancher/shell", wantRf: "v0.2.1"},
+		{image: "quay.io/prometheus/node-exporter:v1.8.2", wantHost: "quay.io", wantRepo: "prometheus/node-exporter", wantRf: "v1.8.2"},
+		{image: "localhost:5000/team/app:1.0", wantHost: "localhost:5000", wantRepo: "team/app", wantRf: "1.0"},
+		{image: "localhost/app", wantHost: "localhost", wantRepo: "app", wantRf: "latest"},
+		{image: "ghcr.io/org/app@sha256:abc", wantHost: "ghcr.io", wantRepo: "org/app", wantRf: "sha256:abc"},
+		{image: "ghcr.io/org/app:v1@sha256:abc", wantHost: "ghcr.io", wantRepo: "org/app", wantRf: "sha256:abc"},
+		{image: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			host, repo, ref, err := parseImageName(tt.image)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantHost, host)
+			assert.Equal(t, tt.wantRepo, repo)
+			assert.Equal(t, tt.wantRf, ref)
+		})
+	}
+}
+
+func TestClient_ManifestExists(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("anonymous registry", func(t *testing.T) {
+		fr := newFakeRegistry(t, "")
+		fr.addManifest("rancher/shell", "v1", fakeManifest{digest: "sha256:aaa"})
+		c := fr.client(t, nil)
+
+		exists, err := c.ManifestExists(ctx, fr.host()+"/rancher/shell:v1")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		exists, err = c.ManifestExists(ctx, fr.host()+"/rancher/shell:v2")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("bearer token challenge", func(t *testing.T) {
+		fr := newFakeRegistry(t, "bearer")
+		fr.addManifest("rancher/shell", "v1", fakeManifest{digest: "sha256:aaa"})
+		c := fr.client(t, nil)
+
+		exists, err := c.ManifestExists(ctx, fr.host()+"/rancher/shell:v1")
+		require.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, []string{"repository:rancher/shell:pull"}, fr.scopes)
+	})
+
+	t.Run("bearer challenge with a repository scope", func(t *testing.T) {
+		fr := newFakeRegistry(t, "bearer")
+		fr.scopedTokens = true
+		fr.addManifest("rancher/shell", "v1", fakeManifest{digest: "sha256:aaa"})
+		fr.addManifest("rancher/kubectl", "v1", fakeManifest{digest: "sha256:bbb"})
+		c := fr.client(t, nil)
+
+		for _, name := range []string{"rancher/shell:v1", "rancher/kubectl:v1", "rancher/shell:v1"} {
+			exists, err := c.ManifestExists(ctx, fr.host()+"/"+name)
+			require.NoError(t, err)
+			assert.True(t, exists, name)
+		}
+		assert.Equal(t, []string{"repository:rancher/shell:pull", "repository:rancher/kubectl:pull"}, fr.scopes,
+			"the cached challenge is answered with the scope of each repository")
+		assert.Equal(t, 1, fr.challenges, "only the first request is challenged")
+	})
+
+	t.Run("bearer token with docker config credentials", func(t *testing.T) {
+		fr := newFakeRegistry(t, "bearer")
+		fr.username, fr.password = "user", "secret"
+		fr.addManifest("private/app", "v1", fakeManifest{digest: "sha256:bbb"})
+
+		cfg := &DockerConfig{Auths: map[string]dockerAuthEntry{
+			fr.host(): {Username: "user", Password: "secret"},
+		}}
+		exists, err := fr.client(t, cfg).ManifestExists(ctx, fr.host()+"/private/app:v1")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		_, err = fr.client(t, nil).ManifestExists(ctx, fr.host()+"/private/app:v1")
+		assert.ErrorIs(t, err, ErrUnauthorized)
+	})
+
+	t.Run("basic auth challenge", func(t *testing.T) {
+		fr := newFakeRegistry(t, "basic")
+		fr.username, fr.password = "user", "secret"
+		fr.addManifest("mirror/app", "v1", fakeManifest{digest: "sha256:ccc"})
+
+		cfg := &DockerConfig{Auths: map[string]dockerAuthEntry{
+			"https://" + fr.host(): {Username: "user", Password: "secret"},
+		}}
+		exists, err := fr.client(t, cfg).ManifestExists(ctx, fr.host()+"/mirror/app:v1")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		_, err = fr.client(t, nil).ManifestExists(ctx, fr.host()+"/mirror/app:v1")
+		assert.ErrorIs(t, err, ErrUnauthorized)
+	})
+
+	t.Run("invalid image", func(t *testing.T) {
+		fr := newFakeRegistry(t, "")
+		_, err := fr.client(t, nil).ManifestExists(ctx, "")
+		assert.Error(t, err)
+	})
+}
+
+func TestClient_HeadManifest(t *testing.T) {
+	fr := newFakeRegistry(t, "bearer")
+	fr.addManifest("rancher/app", "v1", fakeManifest{mediaType: MediaTypeOCIIndex, digest: "sha256:index"})
+	fr.addManifest("rancher/app", "sha256:index", fakeManifest{mediaType: MediaTypeOCIIndex, digest: "sha256:index"})
+	c := fr.client(t, nil)
+
+	desc, err := c.HeadManifest(context.Background(), fr.host()+"/rancher/app:v1")
+	require.NoError(t, err)
+	assert.Equal(t, MediaTypeOCIIndex, desc.MediaType)
+	assert.Equal(t, "sha256:index", desc.Digest)
+
+	desc, err = c.HeadManifest(context.Background(), fr.host()+"/rancher/app@sha256:index")
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:index", desc.Digest)
+}
@@ -0,0 +1,117 @@
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rancher/ob-charts-tool/helmtools/image"
+)
+
+// Credential holds a username/password pair for a registry.
+type Credential struct {
+	Username string
+	Password string
+}
+
+// IsEmpty reports whether the credential has no username and password.
+func (c Credential) IsEmpty() bool {
+	return c.Username == "" && c.Password == ""
+}
+
+// dockerAuthEntry is a single entry of the "auths" map in a docker config.json.
+type dockerAuthEntry struct {
+	Auth     string `json:"auth"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// DockerConfig holds per-registry credentials read from a docker config.json.
+// Credential helpers (credsStore/credHelpers) are not supported; only inline
+// "auths" entries are used.
+type DockerConfig struct {
+	Auths map[string]dockerAuthEntry `json:"auths"`
+}
+
+// DefaultDockerConfigPath returns the docker config.json location, honouring
+// the DOCKER_CONFIG environment variable and falling back to ~/.docker/config.json.
+// Returns an empty string if the home directory cannot be determined.
+func DefaultDockerConfigPath() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return filepath.Join(dir, "config.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".docker", "config.json")
+}
+
+// LoadDockerConfig reads and parses a docker config.json file.
+func LoadDockerConfig(path string) (*DockerConfig, error) {
+	if path == "" {
+		return nil, errors.New("docker config path cannot be empty")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read docker config: %w", err)
+	}
+	return ParseDockerConfig(data)
+}
+
+// ParseDockerConfig parses docker config.json bytes.
+func ParseDockerConfig(data []byte) (*DockerConfig, error) {
+	if len(data) == 0 {
+		return nil, errors.New("docker config data cannot be empty")
+	}
+	var cfg DockerConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse docker config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// CredentialFor returns the credential configured for a registry host.
+// Keys in the config may be bare hosts ("ghcr.io") or URLs
+// ("https://index.docker.io/v1/"); Docker Hub aliases are treated as one host.
+func (c *DockerConfig) CredentialFor(host string) (Credential, bool) {
+	if c == nil {
+		return Credential{}, false
+	}
+	want := normalizeRegistryHost(host)
+	for key, entry := range c.Auths {
+		if normalizeRegistryHost(key) != want {
+			continue
+		}
+		if entry.Username != "" || entry.Password != "" {
+			return Credential{Username: entry.Username, Password: entry.Password}, true
+		}
+		if entry.Auth == "" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			continue
+		}
+		user, pass, ok := strings.Cut(string(decoded), ":")
+		if !ok {
+			continue
+		}
+		return Credential{Username: user, Password: pass}, true
+	}
+	return Credential{}, false
+}
+
+// normalizeRegistryHost strips scheme and path from a registry key and folds
+// the Docker Hub aliases into "docker.io".
+func normalizeRegistryHost(key string) string {
+	key = strings.ToLower(strings.TrimSpace(key))
+	key = strings.TrimPrefix(key, "https://")
+	key = strings.TrimPrefix(key, "http://")
+	key, _, _ = strings.Cut(key, "/")
+	return image.NormalizeRegistry(key)
+}
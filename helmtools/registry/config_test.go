@@ -0,0 +1,85 @@
+package registry
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockerConfig_CredentialFor(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte("hubuser:hubpass"))
+	cfg, err := ParseDockerConfig([]byte(`{
+  "auths": {
+    "https://index.docker.io/v1/": {"auth": "` + encoded + `"},
+    "ghcr.io": {"username": "ghuser", "password": "ghtoken"},
+    "https://registry.suse.com": {"auth": "not-base64!"}
+  }
+}`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		host     string
+		wantCred Credential
+		wantOK   bool
+	}{
+		{name: "docker hub via docker.io", host: "docker.io", wantCred: Credential{"hubuser", "hubpass"}, wantOK: true},
+		{name: "docker hub via api host", host: "registry-1.docker.io", wantCred: Credential{"hubuser", "hubpass"}, wantOK: true},
+		{name: "explicit username and password", host: "ghcr.io", wantCred: Credential{"ghuser", "ghtoken"}, wantOK: true},
+		{name: "host is case-insensitive", host: "GHCR.io", wantCred: Credential{"ghuser", "ghtoken"}, wantOK: true},
+		{name: "undecodable auth is skipped", host: "registry.suse.com", wantOK: false},
+		{name: "unknown host", host: "quay.io", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cred, ok := cfg.CredentialFor(tt.host)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantCred, cred)
+		})
+	}
+}
+
+func TestDockerConfig_CredentialFor_Nil(t *testing.T) {
+	var cfg *DockerConfig
+	cred, ok := cfg.CredentialFor("docker.io")
+	assert.False(t, ok)
+	assert.True(t, cred.IsEmpty())
+}
+
+func TestLoadDockerConfig(t *testing.T) {
+	t.Run("reads file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"auths":{"quay.io":{"username":"u","password":"p"}}}`), 0o600))
+
+		cfg, err := LoadDockerConfig(path)
+		require.NoError(t, err)
+		cred, ok := cfg.CredentialFor("quay.io")
+		assert.True(t, ok)
+		assert.Equal(t, "u", cred.Username)
+	})
+
+	t.Run("empty path", func(t *testing.T) {
+		_, err := LoadDockerConfig("")
+		assert.Error(t, err)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := LoadDockerConfig(filepath.Join(t.TempDir(), "missing.json"))
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		_, err := ParseDockerConfig([]byte("{"))
+		assert.Error(t, err)
+	})
+}
+
+func TestDefaultDockerConfigPath(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", "/custom/docker")
+	assert.Equal(t, filepath.Join("/custom/docker", "config.json"), DefaultDockerConfigPath())
+}
@@ -0,0 +1,50 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// DigestResult is the outcome of resolving a single image tag to a digest.
+type DigestResult struct {
+	Image  string `json:"image" yaml:"image"`
+	Digest string `json:"digest,omitempty" yaml:"digest,omitempty"`
+	Err    error  `json:"-" yaml:"-"`
+}
+
+// ResolveDigest returns the manifest digest ("sha256:<hex>") an image tag
+// currently points to. For multi-arch images this is the digest of the index,
+// which is what pinning a chart value to the tag's content requires. When the
+// registry does not report Docker-Content-Digest on HEAD, the manifest is
+// downloaded and hashed instead.
+func (c *Client) ResolveDigest(ctx context.Context, imageName string) (string, error) {
+	desc, err := c.HeadManifest(ctx, imageName)
+	if err != nil {
+		return "", err
+	}
+	if desc.Digest != "" {
+		return desc.Digest, nil
+	}
+
+	manifest, err := c.GetManifest(ctx, imageName)
+	if err != nil {
+		return "", err
+	}
+	if manifest.Digest != "" {
+		return manifest.Digest, nil
+	}
+	sum := sha256.Sum256(manifest.Body)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// ResolveDigests resolves images using at most concurrency parallel requests.
+// Results are returned in the same order as images.
+func (c *Client) ResolveDigests(ctx context.Context, images []string, concurrency int) []DigestResult {
+	results := make([]DigestResult, len(images))
+	runConcurrently(len(images), concurrency, func(i int) {
+		digest, err := c.ResolveDigest(ctx, images[i])
+		results[i] = DigestResult{Image: images[i], Digest: digest, Err: err}
+	})
+	return results
+}
@@ -0,0 +1,53 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ResolveDigest(t *testing.T) {
+	fr := newPlatformRegistry(t)
+	fr.addManifest("rancher/nodigest", "v1", fakeManifest{body: singleManifest})
+	c := fr.client(t, nil)
+	ctx := context.Background()
+
+	t.Run("digest header", func(t *testing.T) {
+		digest, err := c.ResolveDigest(ctx, fr.host()+"/rancher/multi:v1")
+		require.NoError(t, err)
+		assert.Equal(t, "sha256:multi", digest)
+	})
+
+	t.Run("hashes manifest without digest header", func(t *testing.T) {
+		sum := sha256.Sum256([]byte(singleManifest))
+		digest, err := c.ResolveDigest(ctx, fr.host()+"/rancher/nodigest:v1")
+		require.NoError(t, err)
+		assert.Equal(t, "sha256:"+hex.EncodeToString(sum[:]), digest)
+	})
+
+	t.Run("missing image", func(t *testing.T) {
+		_, err := c.ResolveDigest(ctx, fr.host()+"/rancher/multi:v9")
+		assert.Error(t, err)
+	})
+}
+
+func TestClient_ResolveDigests(t *testing.T) {
+	fr := newPlatformRegistry(t)
+	c := fr.client(t, nil)
+
+	images := []string{
+		fr.host() + "/rancher/multi:v1",
+		fr.host() + "/rancher/missing:v1",
+		fr.host() + "/rancher/windows-exporter:v1",
+	}
+	results := c.ResolveDigests(context.Background(), images, 2)
+	require.Len(t, results, 3)
+	assert.Equal(t, DigestResult{Image: images[0], Digest: "sha256:multi"}, results[0])
+	assert.Error(t, results[1].Err)
+	assert.Empty(t, results[1].Digest)
+	assert.Equal(t, "sha256:win", results[2].Digest)
+}
@@ -0,0 +1,55 @@
+// Package registry provides a minimal OCI Distribution v2 client for checking
+// whether container images exist in a registry and which platforms they provide.
+//
+// # Basic Usage
+//
+// Check if an image tag exists (anonymous pulls):
+//
+//	client, err := registry.NewClient(&http.Client{Timeout: 30 * time.Second}, nil)
+//	if err != nil {
+//		return err
+//	}
+//	exists, err := client.ManifestExists(ctx, "rancher/mirrored-prometheus-node-exporter:v1.8.2")
+//
+// Use credentials from a docker config.json:
+//
+//	cfg, err := registry.LoadDockerConfig(registry.DefaultDockerConfigPath())
+//	client, err := registry.NewClient(httpClient, cfg)
+//
+// Check many images in parallel with typed results:
+//
+//	results := client.CheckImages(ctx, images, registry.DefaultConcurrency)
+//	for _, r := range results {
+//		fmt.Println(r.Image, r.Status) // found, missing, unauthorized, rate_limited, network_error
+//	}
+//
+// Check which platforms an image provides, cross-referenced with the OS list
+// extracted from values.yaml:
+//
+//	coverage := client.CheckReferenceCoverage(ctx, ref) // ref is an image.ImageReference
+//	if !coverage.Complete() {
+//		fmt.Println(coverage.Image, "is missing", coverage.Missing)
+//	}
+//
+//	coverages := client.CheckReferenceCoverages(ctx, refs, registry.DefaultConcurrency)
+//
+// Linux images must provide linux/amd64 and linux/arm64; windows images must
+// provide windows/amd64 (see RequiredPlatforms).
+//
+// Resolve tags to the manifest digests they currently point to, e.g. to pin
+// chart images for audits:
+//
+//	results := client.ResolveDigests(ctx, images, registry.DefaultConcurrency)
+//	for _, r := range results {
+//		fmt.Println(r.Image, r.Digest) // sha256:...
+//	}
+//
+// Bearer tokens are cached per registry and repository scope, and HTTP 429 or
+// transient 5xx responses are retried honouring Retry-After with exponential
+// backoff (see RetryPolicy).
+//
+// Authentication follows the registry's WWW-Authenticate challenge, so both
+// bearer token services (Docker Hub, ghcr.io, quay.io) and basic auth
+// registries are supported. Manifest lookups accept Docker v2 manifests,
+// Docker manifest lists, OCI manifests and OCI image indexes.
+package registry
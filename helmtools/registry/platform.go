@@ -0,0 +1,234 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/rancher/ob-charts-tool/helmtools/image"
+)
+
+// Platform identifies an OS/architecture combination an image is built for.
+type Platform struct {
+	OS           string `json:"os" yaml:"os"`
+	Architecture string `json:"architecture" yaml:"architecture"`
+	Variant      string `json:"variant,omitempty" yaml:"variant,omitempty"`
+}
+
+// String formats the platform as "os/arch" or "os/arch/variant".
+func (p Platform) String() string {
+	if p.Variant != "" {
+		return p.OS + "/" + p.Architecture + "/" + p.Variant
+	}
+	return p.OS + "/" + p.Architecture
+}
+
+// satisfies reports whether p provides the required platform. A requirement
+// without a variant is satisfied by any variant of the same OS and architecture.
+func (p Platform) satisfies(required Platform) bool {
+	if !strings.EqualFold(p.OS, required.OS) || !strings.EqualFold(p.Architecture, required.Architecture) {
+		return false
+	}
+	return required.Variant == "" || strings.EqualFold(p.Variant, required.Variant)
+}
+
+// Manifest is a raw manifest document fetched from a registry.
+type Manifest struct {
+	MediaType string
+	Digest    string
+	Body      []byte
+}
+
+// IsIndex reports whether the manifest is a multi-platform manifest list or OCI index.
+func (m *Manifest) IsIndex() bool {
+	return m.MediaType == MediaTypeOCIIndex || m.MediaType == MediaTypeDockerManifestList
+}
+
+type manifestDocument struct {
+	MediaType string `json:"mediaType"`
+	Config    struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+	Manifests []struct {
+		Digest   string    `json:"digest"`
+		Platform *Platform `json:"platform"`
+	} `json:"manifests"`
+}
+
+// GetManifest downloads an image's manifest.
+func (c *Client) GetManifest(ctx context.Context, imageName string) (*Manifest, error) {
+	host, repository, reference, err := parseImageName(imageName)
+	if err != nil {
+		return nil, err
+	}
+
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", apiHost(host), repository, reference)
+	body, header, err := c.get(ctx, manifestURL, host, repository, imageName)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{
+		MediaType: header.Get("Content-Type"),
+		Digest:    header.Get("Docker-Content-Digest"),
+		Body:      body,
+	}
+	if !manifest.IsIndex() && manifest.MediaType != MediaTypeOCIManifest && manifest.MediaType != MediaTypeDockerManifest {
+		// Some registries answer with a generic content type; trust the document instead.
+		var doc manifestDocument
+		if err := json.Unmarshal(body, &doc); err == nil && doc.MediaType != "" {
+			manifest.MediaType = doc.MediaType
+		} else if err == nil && len(doc.Manifests) > 0 {
+			manifest.MediaType = MediaTypeOCIIndex
+		}
+	}
+	return manifest, nil
+}
+
+// Platforms returns the platforms an image provides. For manifest lists and
+// OCI indexes this is every listed platform except attestation entries; for
+// single-platform images the platform is read from the image config blob.
+func (c *Client) Platforms(ctx context.Context, imageName string) ([]Platform, error) {
+	manifest, err := c.GetManifest(ctx, imageName)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc manifestDocument
+	if err := json.Unmarshal(manifest.Body, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest for %s: %w", imageName, err)
+	}
+
+	if manifest.IsIndex() {
+		var platforms []Platform
+		for _, m := range doc.Manifests {
+			if m.Platform == nil || m.Platform.OS == "unknown" {
+				continue
+			}
+			if !slices.Contains(platforms, *m.Platform) {
+				platforms = append(platforms, *m.Platform)
+			}
+		}
+		return platforms, nil
+	}
+
+	if doc.Config.Digest == "" {
+		return nil, fmt.Errorf("manifest for %s has no config descriptor", imageName)
+	}
+	host, repository, _, _ := parseImageName(imageName)
+	blobURL := fmt.Sprintf("https://%s/v2/%s/blobs/%s", apiHost(host), repository, doc.Config.Digest)
+	body, _, err := c.get(ctx, blobURL, host, repository, imageName)
+	if err != nil {
+		return nil, err
+	}
+
+	var platform Platform
+	if err := json.Unmarshal(body, &platform); err != nil {
+		return nil, fmt.Errorf("failed to parse image config for %s: %w", imageName, err)
+	}
+	return []Platform{platform}, nil
+}
+
+// get performs an authenticated GET and returns the response body and headers.
+func (c *Client) get(ctx context.Context, rawURL, host, repository, imageName string) ([]byte, http.Header, error) {
+	resp, err := c.do(ctx, http.MethodGet, rawURL, host, repository)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, nil, fmt.Errorf("%w: %s", errManifestNotFound, imageName)
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, nil, fmt.Errorf("%w: HTTP %d for %s", ErrUnauthorized, resp.StatusCode, imageName)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return nil, nil, fmt.Errorf("HTTP %d fetching %s", resp.StatusCode, rawURL)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response from %s: %w", rawURL, err)
+	}
+	return body, resp.Header, nil
+}
+
+// RequiredPlatforms returns the platforms an image must provide for the given
+// OS list: linux images need amd64 and arm64, windows images need amd64.
+// An empty list is treated as linux.
+func RequiredPlatforms(osList []string) []Platform {
+	if len(osList) == 0 {
+		osList = []string{"linux"}
+	}
+	var required []Platform
+	for _, os := range osList {
+		switch strings.ToLower(os) {
+		case "linux":
+			required = append(required,
+				Platform{OS: "linux", Architecture: "amd64"},
+				Platform{OS: "linux", Architecture: "arm64"},
+			)
+		case "windows":
+			required = append(required, Platform{OS: "windows", Architecture: "amd64"})
+		}
+	}
+	return required
+}
+
+// MissingPlatforms returns the required platforms not satisfied by any available platform.
+func MissingPlatforms(available, required []Platform) []Platform {
+	var missing []Platform
+	for _, req := range required {
+		if !slices.ContainsFunc(available, func(p Platform) bool { return p.satisfies(req) }) {
+			missing = append(missing, req)
+		}
+	}
+	return missing
+}
+
+// PlatformCoverage reports which platforms an image provides and which required ones it lacks.
+type PlatformCoverage struct {
+	Image     string     `json:"image" yaml:"image"`
+	Platforms []Platform `json:"platforms" yaml:"platforms"`
+	Missing   []Platform `json:"missing,omitempty" yaml:"missing,omitempty"`
+	Err       error      `json:"-" yaml:"-"`
+}
+
+// Complete reports whether the image was inspected and provides every required platform.
+func (pc PlatformCoverage) Complete() bool {
+	return pc.Err == nil && len(pc.Missing) == 0
+}
+
+// CheckPlatformCoverage fetches an image's platforms and compares them to the
+// platforms required for osList (see RequiredPlatforms).
+func (c *Client) CheckPlatformCoverage(ctx context.Context, imageName string, osList []string) PlatformCoverage {
+	coverage := PlatformCoverage{Image: imageName}
+	platforms, err := c.Platforms(ctx, imageName)
+	if err != nil {
+		coverage.Err = err
+		return coverage
+	}
+	coverage.Platforms = platforms
+	coverage.Missing = MissingPlatforms(platforms, RequiredPlatforms(osList))
+	return coverage
+}
+
+// CheckReferenceCoverage checks platform coverage for an image extracted from
+// values.yaml, using the reference's OSList as the required operating systems.
+func (c *Client) CheckReferenceCoverage(ctx context.Context, ref image.ImageReference) PlatformCoverage {
+	return c.CheckPlatformCoverage(ctx, ref.FullImage(), ref.OSList)
+}
+
+// CheckReferenceCoverages checks platform coverage for many image references using
+// at most concurrency parallel lookups. Results are in the same order as refs.
+func (c *Client) CheckReferenceCoverages(ctx context.Context, refs []image.ImageReference, concurrency int) []PlatformCoverage {
+	results := make([]PlatformCoverage, len(refs))
+	runConcurrently(len(refs), concurrency, func(i int) {
+		results[i] = c.CheckReferenceCoverage(ctx, refs[i])
+	})
+	return results
+}
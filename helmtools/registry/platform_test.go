@@ -0,0 +1,156 @@
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rancher/ob-charts-tool/helmtools/image"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	multiArchIndex = `{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.oci.image.index.v1+json",
+  "manifests": [
+    {"digest": "sha256:amd64", "platform": {"os": "linux", "architecture": "amd64"}},
+    {"digest": "sha256:arm64", "platform": {"os": "linux", "architecture": "arm64", "variant": "v8"}},
+    {"digest": "sha256:att", "platform": {"os": "unknown", "architecture": "unknown"}},
+    {"digest": "sha256:amd64-dup", "platform": {"os": "linux", "architecture": "amd64"}}
+  ]
+}`
+	windowsIndex = `{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.docker.distribution.manifest.list.v2+json",
+  "manifests": [
+    {"digest": "sha256:win", "platform": {"os": "windows", "architecture": "amd64", "os.version": "10.0.17763.1"}}
+  ]
+}`
+	singleManifest = `{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.docker.distribution.manifest.v2+json",
+  "config": {"digest": "sha256:config"}
+}`
+)
+
+// newPlatformRegistry serves a multi-arch linux image, an amd64-only linux
+// image, a windows image and a linux-only image mislabelled as windows.
+func newPlatformRegistry(t *testing.T) *fakeRegistry {
+	t.Helper()
+	fr := newFakeRegistry(t, "bearer")
+	fr.addManifest("rancher/multi", "v1", fakeManifest{mediaType: MediaTypeOCIIndex, digest: "sha256:multi", body: multiArchIndex})
+	fr.addManifest("rancher/single", "v1", fakeManifest{digest: "sha256:single", body: singleManifest})
+	fr.addBlob("rancher/single", "sha256:config", `{"os":"linux","architecture":"amd64","rootfs":{}}`)
+	fr.addManifest("rancher/windows-exporter", "v1", fakeManifest{mediaType: MediaTypeDockerManifestList, digest: "sha256:win", body: windowsIndex})
+	fr.addManifest("rancher/windows-exporter", "v2", fakeManifest{mediaType: MediaTypeOCIIndex, digest: "sha256:multi", body: multiArchIndex})
+	return fr
+}
+
+func TestClient_Platforms(t *testing.T) {
+	fr := newPlatformRegistry(t)
+	c := fr.client(t, nil)
+	ctx := context.Background()
+
+	t.Run("index skips attestations and duplicates", func(t *testing.T) {
+		platforms, err := c.Platforms(ctx, fr.host()+"/rancher/multi:v1")
+		require.NoError(t, err)
+		assert.Equal(t, []Platform{
+			{OS: "linux", Architecture: "amd64"},
+			{OS: "linux", Architecture: "arm64", Variant: "v8"},
+		}, platforms)
+	})
+
+	t.Run("single manifest reads config blob", func(t *testing.T) {
+		platforms, err := c.Platforms(ctx, fr.host()+"/rancher/single:v1")
+		require.NoError(t, err)
+		assert.Equal(t, []Platform{{OS: "linux", Architecture: "amd64"}}, platforms)
+	})
+
+	t.Run("missing image", func(t *testing.T) {
+		_, err := c.Platforms(ctx, fr.host()+"/rancher/multi:v9")
+		assert.ErrorIs(t, err, errManifestNotFound)
+	})
+}
+
+func TestMissingPlatforms(t *testing.T) {
+	tests := []struct {
+		name      string
+		available []Platform
+		osList    []string
+		want      []Platform
+	}{
+		{
+			name:      "linux multi-arch",
+			available: []Platform{{OS: "linux", Architecture: "amd64"}, {OS: "linux", Architecture: "arm64", Variant: "v8"}},
+			osList:    []string{"linux"},
+		},
+		{
+			name:      "empty os list defaults to linux",
+			available: []Platform{{OS: "linux", Architecture: "amd64"}},
+			want:      []Platform{{OS: "linux", Architecture: "arm64"}},
+		},
+		{
+			name:      "windows needs windows manifest",
+			available: []Platform{{OS: "linux", Architecture: "amd64"}, {OS: "linux", Architecture: "arm64"}},
+			osList:    []string{"windows"},
+			want:      []Platform{{OS: "windows", Architecture: "amd64"}},
+		},
+		{
+			name:      "linux and windows",
+			available: []Platform{{OS: "windows", Architecture: "amd64"}, {OS: "linux", Architecture: "amd64"}},
+			osList:    []string{"linux", "windows"},
+			want:      []Platform{{OS: "linux", Architecture: "arm64"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, MissingPlatforms(tt.available, RequiredPlatforms(tt.osList)))
+		})
+	}
+}
+
+func TestMissingPlatforms_Variant(t *testing.T) {
+	required := []Platform{{OS: "linux", Architecture: "arm", Variant: "v7"}}
+	assert.Empty(t, MissingPlatforms([]Platform{{OS: "linux", Architecture: "arm", Variant: "v7"}}, required))
+	assert.Equal(t, required, MissingPlatforms([]Platform{{OS: "linux", Architecture: "arm", Variant: "v6"}}, required))
+}
+
+func TestClient_CheckReferenceCoverage(t *testing.T) {
+	fr := newPlatformRegistry(t)
+	c := fr.client(t, nil)
+
+	ref := image.ImageReference{
+		Image:  image.Image{Registry: fr.host(), Repository: "rancher/windows-exporter", Tag: "v2"},
+		OSList: []string{"windows"},
+	}
+	coverage := c.CheckReferenceCoverage(context.Background(), ref)
+	require.NoError(t, coverage.Err)
+	assert.Equal(t, fr.host()+"/rancher/windows-exporter:v2", coverage.Image)
+	assert.Equal(t, []Platform{{OS: "windows", Architecture: "amd64"}}, coverage.Missing)
+}
+
+func TestPlatform_String(t *testing.T) {
+	assert.Equal(t, "linux/amd64", Platform{OS: "linux", Architecture: "amd64"}.String())
+	assert.Equal(t, "linux/arm64/v8", Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}.String())
+}
+
+func TestClient_CheckReferenceCoverages(t *testing.T) {
+	fr := newPlatformRegistry(t)
+	c := fr.client(t, nil)
+
+	results := c.CheckReferenceCoverages(context.Background(), []image.ImageReference{
+		{Image: image.Image{Registry: fr.host(), Repository: "rancher/single", Tag: "v1"}, OSList: []string{"linux"}},
+		// windows-exporter v1 only provides windows/amd64, so it lacks the linux platforms
+		{Image: image.Image{Registry: fr.host(), Repository: "rancher/windows-exporter", Tag: "v1"}, OSList: []string{"windows", "linux"}},
+		{Image: image.Image{Registry: fr.host(), Repository: "rancher/multi", Tag: "v1"}, OSList: []string{"linux"}},
+		{Image: image.Image{Registry: fr.host(), Repository: "rancher/missing", Tag: "v1"}, OSList: []string{"linux"}},
+	}, 2)
+	require.Len(t, results, 4)
+	assert.Equal(t, []Platform{{OS: "linux", Architecture: "arm64"}}, results[0].Missing)
+	assert.Equal(t, []Platform{{OS: "linux", Architecture: "amd64"}, {OS: "linux", Architecture: "arm64"}}, results[1].Missing)
+	assert.True(t, results[2].Complete())
+	assert.False(t, results[3].Complete())
+	assert.ErrorIs(t, results[3].Err, errManifestNotFound)
+}
@@ -0,0 +1,122 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrRateLimited is returned when the registry keeps answering HTTP 429 after all retries.
+var ErrRateLimited = errors.New("rate limited")
+
+// RetryPolicy controls how the client retries rate-limited and transient failures.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt. Zero disables retries.
+	MaxRetries int
+	// BaseDelay is the first backoff delay; it doubles on each subsequent retry.
+	BaseDelay time.Duration
+	// MaxDelay caps both the exponential backoff and any Retry-After value.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is used by clients created with NewClient.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 4,
+	BaseDelay:  500 * time.Millisecond,
+	MaxDelay:   30 * time.Second,
+}
+
+// delay returns how long to wait before the given retry attempt (starting at 0).
+// A Retry-After value from the registry takes precedence over exponential backoff.
+func (p RetryPolicy) delay(attempt int, retryAfter time.Duration) time.Duration {
+	d := retryAfter
+	if d <= 0 {
+		d = p.BaseDelay << attempt
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d
+}
+
+// isRetryableStatus reports whether an HTTP status is worth retrying.
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date.
+// Returns zero if the header is absent or invalid.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// sendWithRetry sends req, retrying on transport errors and retryable statuses.
+// When retries are exhausted on HTTP 429 it returns ErrRateLimited; other
+// retryable statuses are returned to the caller as-is.
+func (c *Client) sendWithRetry(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		resp, err := c.httpClient.Do(req.Clone(ctx))
+		lastAttempt := attempt >= c.retry.MaxRetries
+
+		var retryAfter time.Duration
+		switch {
+		case err != nil:
+			if ctx.Err() != nil || lastAttempt {
+				return nil, err
+			}
+		case isRetryableStatus(resp.StatusCode):
+			if lastAttempt {
+				if resp.StatusCode == http.StatusTooManyRequests {
+					resp.Body.Close()
+					return nil, fmt.Errorf("%w: %s after %d attempt(s)", ErrRateLimited, req.URL.Host, attempt+1)
+				}
+				return resp, nil
+			}
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+			resp.Body.Close()
+		default:
+			return resp, nil
+		}
+
+		if err := sleepContext(ctx, c.retry.delay(attempt, retryAfter)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,87 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		header string
+		want   time.Duration
+	}{
+		{name: "empty", header: "", want: 0},
+		{name: "seconds", header: "3", want: 3 * time.Second},
+		{name: "negative seconds", header: "-1", want: 0},
+		{name: "http date in future", header: now.Add(10 * time.Second).Format(http.TimeFormat), want: 10 * time.Second},
+		{name: "http date in past", header: now.Add(-10 * time.Second).Format(http.TimeFormat), want: 0},
+		{name: "garbage", header: "soon", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseRetryAfter(tt.header, now))
+		})
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	p := RetryPolicy{MaxRetries: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	assert.Equal(t, 100*time.Millisecond, p.delay(0, 0))
+	assert.Equal(t, 200*time.Millisecond, p.delay(1, 0))
+	assert.Equal(t, 800*time.Millisecond, p.delay(3, 0))
+	assert.Equal(t, time.Second, p.delay(4, 0), "backoff is capped at MaxDelay")
+	assert.Equal(t, 300*time.Millisecond, p.delay(0, 300*time.Millisecond), "Retry-After wins over backoff")
+	assert.Equal(t, time.Second, p.delay(0, time.Minute), "Retry-After is capped at MaxDelay")
+}
+
+func TestClient_RetriesRateLimitedRequests(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("succeeds after transient 429s", func(t *testing.T) {
+		fr := newFakeRegistry(t, "")
+		fr.addManifest("rancher/app", "v1", fakeManifest{digest: "sha256:aaa"})
+		fr.rateLimitRemaining = 2
+		fr.retryAfter = "0"
+
+		result := fr.client(t, nil).CheckImage(ctx, fr.host()+"/rancher/app:v1")
+		assert.Equal(t, StatusFound, result.Status)
+		assert.Equal(t, 3, fr.manifestRequests)
+	})
+
+	t.Run("reports rate limited when retries are exhausted", func(t *testing.T) {
+		fr := newFakeRegistry(t, "")
+		fr.addManifest("rancher/app", "v1", fakeManifest{digest: "sha256:aaa"})
+		fr.rateLimitRemaining = 10
+
+		result := fr.client(t, nil).CheckImage(ctx, fr.host()+"/rancher/app:v1")
+		assert.Equal(t, StatusRateLimited, result.Status)
+		assert.ErrorIs(t, result.Err, ErrRateLimited)
+		assert.Equal(t, 3, fr.manifestRequests, "first attempt plus MaxRetries")
+	})
+
+	t.Run("respects context cancellation while backing off", func(t *testing.T) {
+		fr := newFakeRegistry(t, "")
+		fr.rateLimitRemaining = 10
+		fr.retryAfter = "60"
+		c := fr.client(t, nil)
+		c.SetRetryPolicy(RetryPolicy{MaxRetries: 3, BaseDelay: time.Minute, MaxDelay: time.Minute})
+
+		cancelCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		result := c.CheckImage(cancelCtx, fr.host()+"/rancher/app:v1")
+		require.Error(t, result.Err)
+		assert.ErrorIs(t, result.Err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+}
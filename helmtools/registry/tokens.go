@@ -0,0 +1,81 @@
+package registry
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultTokenLifetime is assumed when a token service omits expires_in,
+// as specified by the Docker token authentication spec.
+const defaultTokenLifetime = 60 * time.Second
+
+// tokenExpiryMargin is subtracted from token lifetimes so a token is not
+// used right as it expires mid-request.
+const tokenExpiryMargin = 5 * time.Second
+
+type cachedToken struct {
+	token   string
+	expires time.Time
+}
+
+// tokenCache remembers bearer challenges per registry host and tokens per
+// (host, scope), so checking many images in the same repository or registry
+// does not repeat the 401 round-trip and token request for every image.
+// tokenCache is safe for concurrent use.
+type tokenCache struct {
+	mu         sync.Mutex
+	challenges map[string]Challenge
+	tokens     map[string]cachedToken
+}
+
+func newTokenCache() *tokenCache {
+	return &tokenCache{
+		challenges: make(map[string]Challenge),
+		tokens:     make(map[string]cachedToken),
+	}
+}
+
+func tokenKey(host, scope string) string {
+	return host + "|" + scope
+}
+
+func (tc *tokenCache) challenge(host string) (Challenge, bool) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	ch, ok := tc.challenges[host]
+	return ch, ok
+}
+
+func (tc *tokenCache) setChallenge(host string, ch Challenge) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.challenges[host] = ch
+}
+
+func (tc *tokenCache) token(host, scope string, now time.Time) (string, bool) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	t, ok := tc.tokens[tokenKey(host, scope)]
+	if !ok || !now.Before(t.expires) {
+		return "", false
+	}
+	return t.token, true
+}
+
+func (tc *tokenCache) setToken(host, scope, token string, lifetime time.Duration, now time.Time) {
+	if lifetime <= 0 {
+		lifetime = defaultTokenLifetime
+	}
+	if lifetime > tokenExpiryMargin {
+		lifetime -= tokenExpiryMargin
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.tokens[tokenKey(host, scope)] = cachedToken{token: token, expires: now.Add(lifetime)}
+}
+
+func (tc *tokenCache) invalidate(host, scope string) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	delete(tc.tokens, tokenKey(host, scope))
+}
@@ -0,0 +1,37 @@
+// Package render renders Helm charts in-process using the Helm Go SDK, as an
+// alternative to shelling out to `helm template`.
+//
+// # Basic Usage
+//
+// Render a chart directory (including vendored subcharts) with values overlays:
+//
+//	result, err := render.RenderDir("charts/rancher-monitoring/108.0.0+up77.9.1", render.Options{
+//		ReleaseName: "rancher-monitoring",
+//		Namespace:   "cattle-monitoring-system",
+//		Values: render.ValuesOptions{
+//			ValueFiles: []string{"debug.yaml"},
+//			Values:     []string{"prometheus.enabled=true"},
+//		},
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Print(result.String()) // same layout as `helm template`
+//
+// Each rendered template is available separately:
+//
+//	for _, m := range result.Manifests {
+//		fmt.Println(m.Path)
+//	}
+//
+// The default values.yaml of the chart and each subchart, with the values
+// prefix the parent uses for it, is available via ValuesFiles:
+//
+//	for _, f := range render.ValuesFiles(result.Chart) {
+//		fmt.Println(f.Prefix, f.Chart) // "" for the chart itself, "grafana", ...
+//	}
+//
+// Rendering is client-only: lookup calls return empty results and
+// .Capabilities uses Helm's defaults unless Options.KubeVersion or
+// Options.APIVersions are set.
+package render
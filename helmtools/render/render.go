@@ -0,0 +1,166 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+)
+
+// DefaultReleaseName is used when Options.ReleaseName is empty.
+const DefaultReleaseName = "release-name"
+
+// DefaultNamespace is used when Options.Namespace is empty.
+const DefaultNamespace = "default"
+
+// Options configures an in-process equivalent of `helm template`.
+type Options struct {
+	// ReleaseName is exposed to templates as .Release.Name.
+	ReleaseName string
+	// Namespace is exposed to templates as .Release.Namespace.
+	Namespace string
+	// Values holds the -f/--set/--set-string overrides.
+	Values ValuesOptions
+	// KubeVersion overrides .Capabilities.KubeVersion (e.g. "v1.33.0").
+	// Empty uses Helm's default client-only capabilities.
+	KubeVersion string
+	// APIVersions are added to .Capabilities.APIVersions, like `helm template --api-versions`.
+	APIVersions []string
+	// IncludeCRDs adds the files under each chart's crds/ directory to the output.
+	IncludeCRDs bool
+}
+
+// Manifest is a single rendered template.
+type Manifest struct {
+	// Path is the template path including the chart name, e.g. "rancher-monitoring/templates/rbac.yaml".
+	// Subchart templates are nested as "<parent>/charts/<subchart>/templates/...".
+	Path    string
+	Content string
+}
+
+// Result holds the rendered manifests of a chart.
+type Result struct {
+	Chart     *chart.Chart
+	Manifests []Manifest
+}
+
+// String joins the manifests into a single YAML stream with "# Source:" comments,
+// matching the output of `helm template`.
+func (r *Result) String() string {
+	var b strings.Builder
+	for _, m := range r.Manifests {
+		fmt.Fprintf(&b, "---\n# Source: %s\n%s\n", m.Path, strings.TrimSpace(m.Content))
+	}
+	return b.String()
+}
+
+// LoadChart loads a chart from a directory or packaged archive, including the
+// subcharts vendored under its charts/ directory.
+func LoadChart(chartPath string) (*chart.Chart, error) {
+	chrt, err := loader.Load(chartPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chart %s: %w", chartPath, err)
+	}
+	return chrt, nil
+}
+
+// RenderDir loads the chart at chartPath and renders it with opts.
+func RenderDir(chartPath string, opts Options) (*Result, error) {
+	chrt, err := LoadChart(chartPath)
+	if err != nil {
+		return nil, err
+	}
+	return Render(chrt, opts)
+}
+
+// Render renders a loaded chart without contacting a cluster. Subchart
+// conditions, tags and import-values are processed as `helm template` does;
+// NOTES.txt, partials and templates that render to nothing are omitted.
+func Render(chrt *chart.Chart, opts Options) (*Result, error) {
+	if chrt == nil {
+		return nil, errors.New("chart cannot be nil")
+	}
+
+	vals, err := opts.Values.MergeValues()
+	if err != nil {
+		return nil, err
+	}
+	if err := chartutil.ProcessDependenciesWithMerge(chrt, vals); err != nil {
+		return nil, fmt.Errorf("failed to process chart dependencies: %w", err)
+	}
+
+	caps, err := opts.capabilities()
+	if err != nil {
+		return nil, err
+	}
+	if chrt.Metadata.KubeVersion != "" && !chartutil.IsCompatibleRange(chrt.Metadata.KubeVersion, caps.KubeVersion.String()) {
+		return nil, fmt.Errorf("chart requires kubeVersion %s which is incompatible with Kubernetes %s; set a compatible KubeVersion",
+			chrt.Metadata.KubeVersion, caps.KubeVersion.String())
+	}
+
+	releaseOptions := chartutil.ReleaseOptions{
+		Name:      valueOrDefault(opts.ReleaseName, DefaultReleaseName),
+		Namespace: valueOrDefault(opts.Namespace, DefaultNamespace),
+		Revision:  1,
+		IsInstall: true,
+	}
+	renderValues, err := chartutil.ToRenderValues(chrt, vals, releaseOptions, caps)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute render values: %w", err)
+	}
+
+	rendered, err := engine.Render(chrt, renderValues)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render chart %s: %w", chrt.Name(), err)
+	}
+
+	result := &Result{Chart: chrt}
+	if opts.IncludeCRDs {
+		for _, crd := range chrt.CRDObjects() {
+			result.Manifests = append(result.Manifests, Manifest{Path: crd.Filename, Content: string(crd.File.Data)})
+		}
+	}
+
+	templatePaths := make([]string, 0, len(rendered))
+	for templatePath := range rendered {
+		templatePaths = append(templatePaths, templatePath)
+	}
+	sort.Strings(templatePaths)
+	for _, templatePath := range templatePaths {
+		content := rendered[templatePath]
+		base := path.Base(templatePath)
+		if base == "NOTES.txt" || strings.HasPrefix(base, "_") || strings.TrimSpace(content) == "" {
+			continue
+		}
+		result.Manifests = append(result.Manifests, Manifest{Path: templatePath, Content: content})
+	}
+
+	return result, nil
+}
+
+// capabilities builds the client-only capabilities used for rendering.
+func (o Options) capabilities() (*chartutil.Capabilities, error) {
+	caps := chartutil.DefaultCapabilities.Copy()
+	if o.KubeVersion != "" {
+		kubeVersion, err := chartutil.ParseKubeVersion(o.KubeVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kube version %q: %w", o.KubeVersion, err)
+		}
+		caps.KubeVersion = *kubeVersion
+	}
+	caps.APIVersions = append(caps.APIVersions, o.APIVersions...)
+	return caps, nil
+}
+
+func valueOrDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,240 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeChart writes files (relative path → content) under a new temporary directory
+// and returns its path.
+func writeChart(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func testChart(t *testing.T) string {
+	t.Helper()
+	return writeChart(t, map[string]string{
+		"Chart.yaml": `apiVersion: v2
+name: parent
+version: 1.0.0
+dependencies:
+  - name: exporter
+    version: 0.1.0
+    condition: exporter.enabled
+`,
+		"values.yaml": `image:
+  repository: rancher/mirrored-app
+  tag: v1.0.0
+exporter:
+  enabled: true
+`,
+		"templates/_helpers.tpl": `{{- define "parent.image" -}}{{ .Values.image.repository }}:{{ .Values.image.tag }}{{- end -}}`,
+		"templates/deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Release.Name }}
+  namespace: {{ .Release.Namespace }}
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          image: {{ include "parent.image" . }}
+`,
+		"templates/optional.yaml": `{{- if .Values.optional }}
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: optional
+{{- end }}
+`,
+		"templates/NOTES.txt": `Thanks for installing {{ .Chart.Name }}`,
+		"crds/crd.yaml": `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+`,
+		"charts/exporter/Chart.yaml": `apiVersion: v2
+name: exporter
+version: 0.1.0
+`,
+		"charts/exporter/values.yaml": `image: rancher/mirrored-exporter:v0.1.0
+`,
+		"charts/exporter/templates/daemonset.yaml": `apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: exporter
+spec:
+  template:
+    spec:
+      containers:
+        - name: exporter
+          image: {{ .Values.image }}
+`,
+	})
+}
+
+func manifestPaths(result *Result) []string {
+	paths := make([]string, len(result.Manifests))
+	for i, m := range result.Manifests {
+		paths[i] = m.Path
+	}
+	return paths
+}
+
+func TestRenderDir(t *testing.T) {
+	chartDir := testChart(t)
+
+	t.Run("defaults", func(t *testing.T) {
+		result, err := RenderDir(chartDir, Options{ReleaseName: "mon", Namespace: "cattle-monitoring-system"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"parent/charts/exporter/templates/daemonset.yaml",
+			"parent/templates/deployment.yaml",
+		}, manifestPaths(result))
+
+		out := result.String()
+		assert.Contains(t, out, "# Source: parent/templates/deployment.yaml")
+		assert.Contains(t, out, "name: mon\n  namespace: cattle-monitoring-system")
+		assert.Contains(t, out, "image: rancher/mirrored-app:v1.0.0")
+		assert.Contains(t, out, "image: rancher/mirrored-exporter:v0.1.0")
+		assert.NotContains(t, out, "Thanks for installing")
+	})
+
+	t.Run("values files and set overrides", func(t *testing.T) {
+		overlay := filepath.Join(t.TempDir(), "debug.yaml")
+		require.NoError(t, os.WriteFile(overlay, []byte("image:\n  tag: v2.0.0\noptional: true\n"), 0o644))
+
+		result, err := RenderDir(chartDir, Options{Values: ValuesOptions{
+			ValueFiles: []string{overlay},
+			Values:     []string{"exporter.enabled=false", "image.repository=rancher/mirrored-other"},
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"parent/templates/deployment.yaml",
+			"parent/templates/optional.yaml",
+		}, manifestPaths(result))
+		assert.Contains(t, result.String(), "image: rancher/mirrored-other:v2.0.0")
+		assert.Contains(t, result.String(), "name: "+DefaultReleaseName)
+	})
+
+	t.Run("include CRDs", func(t *testing.T) {
+		result, err := RenderDir(chartDir, Options{IncludeCRDs: true})
+		require.NoError(t, err)
+		assert.Equal(t, "parent/crds/crd.yaml", result.Manifests[0].Path)
+	})
+
+	t.Run("missing chart", func(t *testing.T) {
+		_, err := RenderDir(filepath.Join(t.TempDir(), "missing"), Options{})
+		assert.Error(t, err)
+	})
+
+	t.Run("missing values file", func(t *testing.T) {
+		_, err := RenderDir(chartDir, Options{Values: ValuesOptions{ValueFiles: []string{"does-not-exist.yaml"}}})
+		assert.Error(t, err)
+	})
+}
+
+func TestRender_KubeVersion(t *testing.T) {
+	chartDir := writeChart(t, map[string]string{
+		"Chart.yaml": `apiVersion: v2
+name: versioned
+version: 1.0.0
+kubeVersion: ">= 1.30.0-0 < 1.32.0-0"
+`,
+		"templates/cm.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: kube-{{ .Capabilities.KubeVersion.Minor }}
+`,
+	})
+	chrt, err := LoadChart(chartDir)
+	require.NoError(t, err)
+
+	result, err := Render(chrt, Options{KubeVersion: "v1.31.2"})
+	require.NoError(t, err)
+	assert.Contains(t, result.String(), "name: kube-31")
+
+	_, err = Render(chrt, Options{KubeVersion: "v1.35.0"})
+	assert.ErrorContains(t, err, "incompatible")
+
+	_, err = Render(chrt, Options{KubeVersion: "not-a-version"})
+	assert.Error(t, err)
+
+	_, err = Render(nil, Options{})
+	assert.Error(t, err)
+}
+
+func TestValuesOptions_MergeValues(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.yaml")
+	second := filepath.Join(dir, "second.yaml")
+	require.NoError(t, os.WriteFile(first, []byte("a:\n  b: 1\n  c: 2\nlist: [1, 2]\n"), 0o644))
+	require.NoError(t, os.WriteFile(second, []byte("a:\n  c: 3\nlist: [3]\n"), 0o644))
+
+	vals, err := ValuesOptions{
+		ValueFiles:   []string{first, second},
+		Values:       []string{"a.d=4", "enabled=true"},
+		StringValues: []string{"version=1.10"},
+	}.MergeValues()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"a":       map[string]interface{}{"b": float64(1), "c": float64(3), "d": int64(4)},
+		"list":    []interface{}{float64(3)},
+		"enabled": true,
+		"version": "1.10",
+	}, vals)
+
+	_, err = ValuesOptions{Values: []string{"a.b.c"}}.MergeValues()
+	assert.Error(t, err)
+}
+
+func TestValuesFiles(t *testing.T) {
+	chartDir := writeChart(t, map[string]string{
+		"Chart.yaml": `apiVersion: v2
+name: parent
+version: 1.0.0
+appVersion: v1.0.0
+dependencies:
+  - name: exporter
+    version: 0.1.0
+    alias: node-exporter
+`,
+		"values.yaml":                         "image:\n  repository: rancher/mirrored-app\n",
+		"charts/exporter/Chart.yaml":          "apiVersion: v2\nname: exporter\nversion: 0.1.0\nappVersion: v0.1.0\n",
+		"charts/exporter/values.yaml":         "image:\n  repository: rancher/mirrored-exporter\n",
+		"charts/vendored/Chart.yaml":          "apiVersion: v2\nname: vendored\nversion: 0.2.0\n",
+		"charts/vendored/values.yaml":         "enabled: true\n",
+		"charts/vendored/charts/x/Chart.yaml": "apiVersion: v2\nname: x\nversion: 0.0.1\n",
+	})
+	chrt, err := LoadChart(chartDir)
+	require.NoError(t, err)
+
+	files := ValuesFiles(chrt)
+	got := make(map[string]ValuesFile, len(files))
+	for _, f := range files {
+		got[f.Prefix] = f
+	}
+	require.Len(t, got, 4)
+	assert.Empty(t, files[0].Prefix)
+	assert.Equal(t, "parent", got[""].Chart)
+	assert.Equal(t, "1.0.0", got[""].Version)
+	assert.Equal(t, "v1.0.0", got[""].AppVersion)
+	assert.Contains(t, string(got[""].Data), "rancher/mirrored-app")
+	assert.Equal(t, "exporter", got["node-exporter"].Chart)
+	assert.Equal(t, "v0.1.0", got["node-exporter"].AppVersion)
+	assert.Contains(t, string(got["node-exporter"].Data), "rancher/mirrored-exporter")
+	assert.Contains(t, got, "vendored")
+	assert.Empty(t, got["vendored.x"].Data)
+}
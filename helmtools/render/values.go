@@ -0,0 +1,67 @@
+package render
+
+import (
+	"fmt"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/strvals"
+)
+
+// ValuesOptions mirrors the values flags accepted by `helm template`.
+type ValuesOptions struct {
+	// ValueFiles are values.yaml overlays (-f/--values), applied in order.
+	ValueFiles []string
+	// Values are key=value overrides (--set), applied after ValueFiles.
+	Values []string
+	// StringValues are key=value overrides forced to strings (--set-string), applied last.
+	StringValues []string
+}
+
+// MergeValues reads the value files and applies --set style overrides, returning
+// the user-supplied values. Later sources override earlier ones; nested maps are
+// merged rather than replaced. Chart defaults are not included.
+func (o ValuesOptions) MergeValues() (map[string]interface{}, error) {
+	base := map[string]interface{}{}
+
+	for _, filePath := range o.ValueFiles {
+		current, err := chartutil.ReadValuesFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read values file %s: %w", filePath, err)
+		}
+		base = mergeMaps(base, current)
+	}
+
+	for _, value := range o.Values {
+		if err := strvals.ParseInto(value, base); err != nil {
+			return nil, fmt.Errorf("failed parsing --set data %q: %w", value, err)
+		}
+	}
+
+	for _, value := range o.StringValues {
+		if err := strvals.ParseIntoString(value, base); err != nil {
+			return nil, fmt.Errorf("failed parsing --set-string data %q: %w", value, err)
+		}
+	}
+
+	return base, nil
+}
+
+// mergeMaps recursively merges b into a, with values from b taking precedence.
+func mergeMaps(a, b map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(a))
+	for k, v := range a {
+		out[k] = v
+	}
+	for k, v := range b {
+		if v, ok := v.(map[string]interface{}); ok {
+			if bv, ok := out[k]; ok {
+				if bv, ok := bv.(map[string]interface{}); ok {
+					out[k] = mergeMaps(bv, v)
+					continue
+				}
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package render
+
+import (
+	"slices"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+// ValuesFile is the default values.yaml of a chart or one of its subcharts.
+type ValuesFile struct {
+	// Prefix is the dotted values path the top-level chart uses to configure this
+	// chart: "" for the chart itself, otherwise the subchart name or alias, nested
+	// for subcharts of subcharts (e.g. "grafana" or "parent.child").
+	Prefix     string
+	Chart      string
+	Version    string
+	AppVersion string
+	Data       []byte
+}
+
+// ValuesFiles returns the values.yaml of chrt and of every subchart it vendors,
+// parents first. A subchart used under several aliases is returned once per alias.
+func ValuesFiles(chrt *chart.Chart) []ValuesFile {
+	var files []ValuesFile
+	collectValuesFiles(chrt, "", &files)
+	return files
+}
+
+func collectValuesFiles(chrt *chart.Chart, prefix string, files *[]ValuesFile) {
+	file := ValuesFile{Prefix: prefix, Chart: chrt.Name()}
+	if chrt.Metadata != nil {
+		file.Version = chrt.Metadata.Version
+		file.AppVersion = chrt.Metadata.AppVersion
+	}
+	for _, raw := range chrt.Raw {
+		if raw.Name == chartutil.ValuesfileName {
+			file.Data = raw.Data
+			break
+		}
+	}
+	*files = append(*files, file)
+
+	for _, sub := range chrt.Dependencies() {
+		for _, name := range dependencyNames(chrt, sub.Name()) {
+			subPrefix := name
+			if prefix != "" {
+				subPrefix = prefix + "." + name
+			}
+			collectValuesFiles(sub, subPrefix, files)
+		}
+	}
+}
+
+// dependencyNames returns the names (aliases, where set) under which the parent
+// chart's Chart.yaml declares the subchart. Vendored subcharts that are not
+// declared keep their own name.
+func dependencyNames(parent *chart.Chart, subchart string) []string {
+	var names []string
+	if parent.Metadata != nil {
+		for _, dep := range parent.Metadata.Dependencies {
+			if dep == nil || dep.Name != subchart {
+				continue
+			}
+			name := dep.Name
+			if dep.Alias != "" {
+				name = dep.Alias
+			}
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		names = []string{subchart}
+	}
+	return names
+}
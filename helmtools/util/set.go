@@ -2,6 +2,8 @@ package util
 
 import (
 	"encoding/json"
+
+	"go.yaml.in/yaml/v3"
 )
 
 // Set is a generic set implementation backed by a map.
@@ -69,3 +71,29 @@ func (s Set[T]) MarshalJSON() ([]byte, error) {
 func (s Set[T]) MarshalYAML() (interface{}, error) {
 	return s.Values(), nil // Serialize as a slice
 }
+
+func (s *Set[T]) UnmarshalJSON(data []byte) error {
+	var values []T
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	*s = setOf(values)
+	return nil
+}
+
+func (s *Set[T]) UnmarshalYAML(node *yaml.Node) error {
+	var values []T
+	if err := node.Decode(&values); err != nil {
+		return err
+	}
+	*s = setOf(values)
+	return nil
+}
+
+func setOf[T comparable](values []T) Set[T] {
+	result := make(Set[T], len(values))
+	for _, item := range values {
+		result.Add(item)
+	}
+	return result
+}
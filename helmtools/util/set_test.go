@@ -351,3 +351,40 @@ func TestSet_MarshalYAML(t *testing.T) {
 		assert.Contains(t, string(data), "[]\n")
 	})
 }
+
+func TestSet_Unmarshal(t *testing.T) {
+	t.Run("round trips through JSON", func(t *testing.T) {
+		s := NewSet[string]()
+		s.Add("apple")
+		s.Add("banana")
+		data, err := json.Marshal(s)
+		require.NoError(t, err)
+
+		var decoded Set[string]
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, s, decoded)
+	})
+
+	t.Run("round trips through YAML", func(t *testing.T) {
+		s := NewSet[string]()
+		s.Add("one")
+		s.Add("two")
+		data, err := yaml.Marshal(s)
+		require.NoError(t, err)
+
+		var decoded Set[string]
+		require.NoError(t, yaml.Unmarshal(data, &decoded))
+		assert.Equal(t, s, decoded)
+	})
+
+	t.Run("duplicates collapse", func(t *testing.T) {
+		var decoded Set[int]
+		require.NoError(t, yaml.Unmarshal([]byte("[1, 2, 2]"), &decoded))
+		assert.Equal(t, 2, decoded.Size())
+	})
+
+	t.Run("rejects non-sequences", func(t *testing.T) {
+		var decoded Set[int]
+		assert.Error(t, yaml.Unmarshal([]byte("key: value"), &decoded))
+	})
+}
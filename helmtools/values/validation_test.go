@@ -36,3 +36,30 @@ func TestGetMapByPath_Validation(t *testing.T) {
 		assert.Nil(t, result)
 	})
 }
+
+func TestSetByPath(t *testing.T) {
+	t.Run("creates intermediate maps", func(t *testing.T) {
+		data := map[string]interface{}{}
+		assert.NoError(t, values.SetByPath(data, "prometheus.image.sha", "abc"))
+		value, found := values.GetByPath(data, "prometheus.image.sha")
+		assert.True(t, found)
+		assert.Equal(t, "abc", value)
+	})
+
+	t.Run("keeps sibling keys", func(t *testing.T) {
+		data := map[string]interface{}{"image": map[string]interface{}{"tag": "v1"}}
+		assert.NoError(t, values.SetByPath(data, "image.sha", "abc"))
+		assert.Equal(t, map[string]interface{}{"tag": "v1", "sha": "abc"}, data["image"])
+	})
+
+	t.Run("replaces non-map values along the path", func(t *testing.T) {
+		data := map[string]interface{}{"image": "nginx"}
+		assert.NoError(t, values.SetByPath(data, "image.sha", "abc"))
+		assert.Equal(t, map[string]interface{}{"sha": "abc"}, data["image"])
+	})
+
+	t.Run("rejects nil data and empty path", func(t *testing.T) {
+		assert.Error(t, values.SetByPath(nil, "image.sha", "abc"))
+		assert.Error(t, values.SetByPath(map[string]interface{}{}, "", "abc"))
+	})
+}
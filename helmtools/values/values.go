@@ -56,3 +56,26 @@ func GetMapByPath(data map[string]interface{}, keyPath string) (map[string]inter
 	}
 	return current, true
 }
+
+// SetByPath sets the value at a dotted key path (e.g. "image.sha") in a parsed YAML map,
+// creating intermediate maps as needed. Non-map values along the path are replaced.
+func SetByPath(data map[string]interface{}, keyPath string, value interface{}) error {
+	if data == nil {
+		return fmt.Errorf("cannot set %q on a nil map", keyPath)
+	}
+	if keyPath == "" {
+		return fmt.Errorf("key path cannot be empty")
+	}
+	parts := strings.Split(keyPath, ".")
+	current := data
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			current[part] = next
+		}
+		current = next
+	}
+	current[parts[len(parts)-1]] = value
+	return nil
+}
@@ -0,0 +1,62 @@
+package version
+
+import (
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Bump classifies the change between two versions.
+type Bump string
+
+const (
+	// BumpNone means both versions are equal.
+	BumpNone Bump = "none"
+	// BumpMajor means the major version increased.
+	BumpMajor Bump = "major"
+	// BumpMinor means the minor version increased.
+	BumpMinor Bump = "minor"
+	// BumpPatch means the patch version increased.
+	BumpPatch Bump = "patch"
+	// BumpPrerelease means only the pre-release or build metadata changed upwards,
+	// e.g. "v1.2.3-rc.1" → "v1.2.3".
+	BumpPrerelease Bump = "prerelease"
+	// BumpDowngrade means the new version is lower than the old one.
+	BumpDowngrade Bump = "downgrade"
+	// BumpUnknown means at least one version is not semver (e.g. "latest" or "ltsc2022").
+	BumpUnknown Bump = "unknown"
+)
+
+// ClassifyBump compares two version strings, such as image tags or chart
+// versions, and reports the kind of change. A leading "v" and a trailing
+// "@sha256:..." digest are ignored.
+func ClassifyBump(oldVersion, newVersion string) Bump {
+	oldV, errOld := parseLenient(oldVersion)
+	newV, errNew := parseLenient(newVersion)
+	if errOld != nil || errNew != nil {
+		if oldVersion == newVersion {
+			return BumpNone
+		}
+		return BumpUnknown
+	}
+
+	switch {
+	case newV.LessThan(oldV):
+		return BumpDowngrade
+	case newV.Major() != oldV.Major():
+		return BumpMajor
+	case newV.Minor() != oldV.Minor():
+		return BumpMinor
+	case newV.Patch() != oldV.Patch():
+		return BumpPatch
+	case newV.Equal(oldV) && newV.Metadata() == oldV.Metadata():
+		return BumpNone
+	default:
+		return BumpPrerelease
+	}
+}
+
+func parseLenient(v string) (*semver.Version, error) {
+	v, _, _ = strings.Cut(v, "@")
+	return semver.NewVersion(v)
+}
@@ -1,2 +1,7 @@
 // Package version provides utilities for Helm chart version comparison and validation.
+//
+// Classify the change between two versions or image tags:
+//
+//	version.ClassifyBump("v1.8.2", "v2.0.0") // version.BumpMajor
+//	version.ClassifyBump("v1.9.0", "v1.8.2") // version.BumpDowngrade
 package version
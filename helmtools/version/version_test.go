@@ -301,3 +301,29 @@ func TestVerifyTagsInValues(t *testing.T) {
 		})
 	}
 }
+
+func TestClassifyBump(t *testing.T) {
+	tests := []struct {
+		oldVersion, newVersion string
+		want                   Bump
+	}{
+		{"v1.8.2", "v1.8.2", BumpNone},
+		{"v1.8.2", "v2.0.0", BumpMajor},
+		{"v1.8.2", "v1.9.0", BumpMinor},
+		{"1.8.2", "v1.8.3", BumpPatch},
+		{"v1.8.2-rc.1", "v1.8.2", BumpPrerelease},
+		{"v1.8.2+build1", "v1.8.2+build2", BumpPrerelease},
+		{"v1.9.0", "v1.8.2", BumpDowngrade},
+		{"v1.8.2", "v1.8.2-rc.1", BumpDowngrade},
+		{"v1.8.2@sha256:abc", "v1.8.3@sha256:def", BumpPatch},
+		{"latest", "v1.0.0", BumpUnknown},
+		{"ltsc2022", "ltsc2022", BumpNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.oldVersion+"->"+tt.newVersion, func(t *testing.T) {
+			if got := ClassifyBump(tt.oldVersion, tt.newVersion); got != tt.want {
+				t.Errorf("ClassifyBump(%q, %q) = %q, want %q", tt.oldVersion, tt.newVersion, got, tt.want)
+			}
+		})
+	}
+}
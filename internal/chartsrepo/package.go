@@ -0,0 +1,62 @@
+package chartsrepo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Masterminds/semver/v3"
+	"go.yaml.in/yaml/v3"
+)
+
+// PackageYAML represents the structure of a package.yaml file
+type PackageYAML struct {
+	Version string `yaml:"version"`
+	Commit  string `yaml:"commit"`
+}
+
+// CurrentPackage returns the path and contents of the package.yaml of the newest version
+// directory of a package in the charts repository at repoPath.
+func CurrentPackage(repoPath, packageName string) (string, PackageYAML, error) {
+	var pkgYAML PackageYAML
+	packageDir := filepath.Join(repoPath, "packages", packageName)
+	entries, err := os.ReadDir(packageDir)
+	if err != nil {
+		return "", pkgYAML, fmt.Errorf("failed to read package %s: %w", packageName, err)
+	}
+
+	// packages/<name>/package.yaml, for packages without version directories
+	candidates := []string{filepath.Join(packageDir, "package.yaml")}
+	var newest *semver.Version
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		v, err := semver.NewVersion(entry.Name())
+		if err != nil || (newest != nil && !v.GreaterThan(newest)) {
+			continue
+		}
+		newest = v
+		// rancher-monitoring nests the package under packages/<name>/<version>/<name>/
+		candidates = []string{
+			filepath.Join(packageDir, entry.Name(), packageName, "package.yaml"),
+			filepath.Join(packageDir, entry.Name(), "package.yaml"),
+		}
+	}
+
+	for _, candidate := range candidates {
+		data, err := os.ReadFile(candidate)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", pkgYAML, fmt.Errorf("failed to read package.yaml: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &pkgYAML); err != nil {
+			return "", pkgYAML, fmt.Errorf("failed to parse %s: %w", candidate, err)
+		}
+		return candidate, pkgYAML, nil
+	}
+	return "", pkgYAML, fmt.Errorf("no package.yaml found for package %s", packageName)
+}
@@ -0,0 +1,39 @@
+package chartsrepo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestCurrentPackage(t *testing.T) {
+	repo := t.TempDir()
+	writeFile(t, filepath.Join(repo, "packages/rancher-monitoring/69.8/rancher-monitoring/package.yaml"),
+		"version: 69.8.2-rancher.5\ncommit: aaa\n")
+	writeFile(t, filepath.Join(repo, "packages/rancher-monitoring/77.9/rancher-monitoring/package.yaml"),
+		"version: 77.9.1-rancher.3\ncommit: bbb\n")
+	writeFile(t, filepath.Join(repo, "packages/rancher-logging/4.10/package.yaml"),
+		"version: 4.10.0-rancher.2\ncommit: ccc\n")
+
+	path, pkgYAML, err := CurrentPackage(repo, "rancher-monitoring")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(repo, "packages/rancher-monitoring/77.9/rancher-monitoring/package.yaml"), path)
+	assert.Equal(t, PackageYAML{Version: "77.9.1-rancher.3", Commit: "bbb"}, pkgYAML)
+
+	path, pkgYAML, err = CurrentPackage(repo, "rancher-logging")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(repo, "packages/rancher-logging/4.10/package.yaml"), path)
+	assert.Equal(t, "ccc", pkgYAML.Commit)
+
+	_, _, err = CurrentPackage(repo, "rancher-project-monitoring")
+	assert.Error(t, err)
+}
@@ -0,0 +1,423 @@
+package applyrebase
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/rancher/ob-charts-tool/internal/chartsrepo"
+	"github.com/rancher/ob-charts-tool/internal/rebase"
+	internalvalues "github.com/rancher/ob-charts-tool/internal/values"
+)
+
+// Result describes the edits made to the charts repository by Apply. Paths are relative
+// to the charts repository.
+type Result struct {
+	PackageYAML string
+	OldVersion  string
+	NewVersion  string
+	// CopiedFrom is the previous version directory the new one was copied from, or empty
+	// when the package was edited in place
+	CopiedFrom string
+	// VersionDir is the package version directory that was edited
+	VersionDir string
+	// TagUpdates are the subchart image tags set to their expected values
+	TagUpdates []TagUpdate
+	// MissingTags are the expected subchart tags that were not found in a values file
+	MissingTags []TagUpdate
+	// StalePatches are the values patches that change keys of expected tags. Patches are
+	// not edited, so these are left stale until regenerated with the chart build scripts
+	StalePatches []string
+}
+
+// TagUpdate is an expected subchart tag value in a values file.
+type TagUpdate struct {
+	File     string
+	Key      string
+	OldValue string
+	NewValue string
+}
+
+// Apply rebases the current package of packageName in the charts repository at repoPath
+// onto the upstream commit of a rebase.yaml: when the upstream major.minor changes, the
+// current version directory is copied to a new one; the package.yaml commit and version
+// are updated and the SubchartTagExpectations are applied to the values files of the
+// package. Values patches touching the expected tags cannot be edited in place and are
+// reported in StalePatches. The edits are left uncommitted for review.
+func Apply(repoPath, packageName string, info rebase.ChartRebaseInfo) (*Result, error) {
+	if info.FoundChart.CommitHash == "" || info.FoundChart.ChartVersion == "" {
+		return nil, fmt.Errorf("rebase info has no upstream commit and chart version")
+	}
+
+	pkgYAMLPath, pkgYAML, err := chartsrepo.CurrentPackage(repoPath, packageName)
+	if err != nil {
+		return nil, err
+	}
+	if pkgYAML.Commit == info.FoundChart.CommitHash {
+		return nil, fmt.Errorf("%s already uses upstream commit %s", relPath(repoPath, pkgYAMLPath), pkgYAML.Commit)
+	}
+	newVersion, err := rebase.NextPackageVersion(pkgYAML.Version, info.FoundChart.ChartVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		OldVersion: pkgYAML.Version,
+		NewVersion: newVersion,
+	}
+
+	versionDir := packageVersionDir(repoPath, packageName, pkgYAMLPath)
+	newVersionDir := versionDir
+	if versionDir != "" {
+		if newDirName, ok := versionDirName(info.FoundChart.ChartVersion); ok && newDirName != filepath.Base(versionDir) {
+			newVersionDir = filepath.Join(filepath.Dir(versionDir), newDirName)
+			if _, err := os.Stat(newVersionDir); err == nil {
+				return nil, fmt.Errorf("version directory %s already exists", relPath(repoPath, newVersionDir))
+			}
+			if err := copyDir(versionDir, newVersionDir); err != nil {
+				return nil, fmt.Errorf("failed to copy %s: %w", relPath(repoPath, versionDir), err)
+			}
+			result.CopiedFrom = relPath(repoPath, versionDir)
+			pkgYAMLPath = filepath.Join(newVersionDir, strings.TrimPrefix(pkgYAMLPath, versionDir))
+		}
+		result.VersionDir = relPath(repoPath, newVersionDir)
+	} else {
+		newVersionDir = filepath.Dir(pkgYAMLPath)
+		result.VersionDir = relPath(repoPath, newVersionDir)
+	}
+	result.PackageYAML = relPath(repoPath, pkgYAMLPath)
+
+	if err := updatePackageYAML(pkgYAMLPath, newVersion, info.FoundChart.CommitHash); err != nil {
+		return nil, err
+	}
+	if err := applyTagExpectations(repoPath, newVersionDir, info.SubchartTagExpectations, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// packageVersionDir returns the packages/<name>/<version> directory containing pkgYAMLPath,
+// or empty for packages without version directories.
+func packageVersionDir(repoPath, packageName, pkgYAMLPath string) string {
+	packageDir := filepath.Join(repoPath, "packages", packageName)
+	rel, err := filepath.Rel(packageDir, pkgYAMLPath)
+	if err != nil {
+		return ""
+	}
+	parts := strings.Split(rel, string(filepath.Separator))
+	if len(parts) < 2 {
+		return ""
+	}
+	return filepath.Join(packageDir, parts[0])
+}
+
+// versionDirName returns the "<major>.<minor>" version directory name of a chart version.
+func versionDirName(chartVersion string) (string, bool) {
+	v, err := semver.NewVersion(chartVersion)
+	if err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("%d.%d", v.Major(), v.Minor()), true
+}
+
+// updatePackageYAML sets the version and commit values of a package.yaml, keeping the rest
+// of the file, including comments and anchors, as is.
+func updatePackageYAML(path, version, commit string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read package.yaml: %w", err)
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	lines := strings.Split(string(data), "\n")
+	for _, field := range []struct{ key, value string }{{"version", version}, {"commit", commit}} {
+		node := lookupScalar(&root, field.key)
+		if node == nil {
+			return fmt.Errorf("%s has no top-level %s", path, field.key)
+		}
+		line, ok := replaceScalar(lines[node.Line-1], node, field.value)
+		if !ok {
+			return fmt.Errorf("%s: cannot update the %s value in place", path, field.key)
+		}
+		lines[node.Line-1] = line
+	}
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644)
+}
+
+// applyTagExpectations sets the expected tags in the values files of the package: in the
+// values of a subchart directory named after an expectation, and under the subchart key
+// in the values of the chart itself. Values patches changing an expected key are listed
+// in StalePatches.
+func applyTagExpectations(repoPath, dir string, expectations []rebase.SubchartTagExpectation, result *Result) error {
+	expected := make(map[string]rebase.SubchartTagExpectation)
+	for _, exp := range expectations {
+		expected[internalvalues.NormalizeName(exp.Name)] = exp
+	}
+
+	return filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		name, isPatch := strings.CutSuffix(d.Name(), ".patch")
+		if !isValuesFile(name) {
+			return nil
+		}
+
+		var exps []rebase.SubchartTagExpectation
+		subchart := subchartOf(dir, path)
+		if subchart == "" {
+			// the chart itself overrides subchart values under their chart name
+			for _, exp := range expectations {
+				exps = append(exps, prefixExpectation(exp))
+			}
+		} else if exp, ok := expected[internalvalues.NormalizeName(subchart)]; ok {
+			exps = append(exps, exp)
+		}
+		if len(exps) == 0 {
+			return nil
+		}
+
+		if isPatch {
+			stale, err := patchChangesKeys(path, exps)
+			if err != nil {
+				return err
+			}
+			if stale {
+				result.StalePatches = append(result.StalePatches, relPath(repoPath, path))
+			}
+			return nil
+		}
+		return setExpectedTags(repoPath, path, exps, subchart != "", result)
+	})
+}
+
+// isValuesFile reports whether name is a values.yaml or values-<variant>.yaml file.
+func isValuesFile(name string) bool {
+	return name == "values.yaml" || (strings.HasPrefix(name, "values-") && strings.HasSuffix(name, ".yaml"))
+}
+
+// subchartOf returns the name of the subchart directory containing path, i.e. the
+// directory following its last charts/ element, or empty for files of the chart itself.
+func subchartOf(dir, path string) string {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return ""
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for i := len(parts) - 3; i >= 0; i-- {
+		if parts[i] == "charts" {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
+
+// prefixExpectation returns exp with its keys nested under the subchart chart name, as
+// they are set from the values of the parent chart.
+func prefixExpectation(exp rebase.SubchartTagExpectation) rebase.SubchartTagExpectation {
+	prefixed := exp
+	prefixed.ExpectedTags = make(map[string]string, len(exp.ExpectedTags))
+	for key, value := range exp.ExpectedTags {
+		prefixed.ExpectedTags[exp.Name+"."+key] = value
+	}
+	return prefixed
+}
+
+// patchChangesKeys reports whether a values patch adds or removes a line setting the last
+// segment of an expected key. Hunks do not carry the enclosing keys, so the match is on the
+// key name alone.
+func patchChangesKeys(path string, exps []rebase.SubchartTagExpectation) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	names := make(map[string]bool)
+	for _, exp := range exps {
+		for key := range exp.ExpectedTags {
+			names[key[strings.LastIndex(key, ".")+1:]] = true
+		}
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---") ||
+			!(strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-")) {
+			continue
+		}
+		name, _, ok := strings.Cut(strings.TrimSpace(line[1:]), ":")
+		if ok && names[strings.Trim(name, `"'`)] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// setExpectedTags rewrites the expected tag values of a values file in place. Keys missing
+// from the file are reported in MissingTags when reportMissing is set.
+func setExpectedTags(repoPath, path string, exps []rebase.SubchartTagExpectation, reportMissing bool, result *Result) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	expectedTags := make(map[string]string)
+	for _, exp := range exps {
+		for key, value := range exp.ExpectedTags {
+			expectedTags[key] = value
+		}
+	}
+	keys := make([]string, 0, len(expectedTags))
+	for key := range expectedTags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lines := strings.Split(string(data), "\n")
+	changed := false
+	for _, key := range keys {
+		update := TagUpdate{File: relPath(repoPath, path), Key: key, NewValue: expectedTags[key]}
+		node := lookupScalar(&root, key)
+		if node == nil {
+			if reportMissing {
+				result.MissingTags = append(result.MissingTags, update)
+			}
+			continue
+		}
+		update.OldValue = node.Value
+		if node.Value == update.NewValue {
+			continue
+		}
+		line, ok := replaceScalar(lines[node.Line-1], node, update.NewValue)
+		if !ok {
+			result.MissingTags = append(result.MissingTags, update)
+			continue
+		}
+		lines[node.Line-1] = line
+		changed = true
+		result.TagUpdates = append(result.TagUpdates, update)
+	}
+	if !changed {
+		return nil
+	}
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644)
+}
+
+// lookupScalar returns the scalar node at a dotted values path, or nil.
+func lookupScalar(root *yaml.Node, key string) *yaml.Node {
+	if len(root.Content) == 0 {
+		return nil
+	}
+	node := root.Content[0]
+	for _, segment := range strings.Split(key, ".") {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == segment {
+				next = node.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	if node.Kind != yaml.ScalarNode {
+		return nil
+	}
+	return node
+}
+
+// replaceScalar replaces the scalar of node on its line with value, keeping its quoting,
+// anchor and trailing comment.
+func replaceScalar(line string, node *yaml.Node, value string) (string, bool) {
+	start := node.Column - 1
+	if start < 0 || start > len(line) {
+		return "", false
+	}
+	if node.Anchor != "" {
+		anchor := "&" + node.Anchor
+		if !strings.HasPrefix(line[start:], anchor) {
+			return "", false
+		}
+		start += len(anchor)
+		for start < len(line) && line[start] == ' ' {
+			start++
+		}
+	}
+	var oldToken, newToken string
+	switch node.Style {
+	case yaml.DoubleQuotedStyle:
+		oldToken, newToken = `"`+node.Value+`"`, `"`+value+`"`
+	case yaml.SingleQuotedStyle:
+		oldToken, newToken = "'"+node.Value+"'", "'"+value+"'"
+	case 0:
+		oldToken, newToken = node.Value, value
+	default:
+		return "", false
+	}
+	if !strings.HasPrefix(line[start:], oldToken) {
+		return "", false
+	}
+	return line[:start] + newToken + line[start+len(oldToken):], true
+}
+
+// copyDir copies the files of src to dst, keeping their permissions.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm())
+		}
+		return copyFile(path, target, info.Mode().Perm())
+	})
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func relPath(repoPath, path string) string {
+	rel, err := filepath.Rel(repoPath, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
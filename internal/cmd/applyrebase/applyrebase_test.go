@@ -0,0 +1,110 @@
+package applyrebase
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rancher/ob-charts-tool/internal/rebase"
+)
+
+const (
+	oldDir     = "packages/rancher-monitoring/77.9/"
+	oldPkgYAML = oldDir + "rancher-monitoring/package.yaml"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for relPath, content := range files {
+		path := filepath.Join(dir, relPath)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func chartsRepo(t *testing.T) string {
+	t.Helper()
+	repo := t.TempDir()
+	writeFiles(t, repo, map[string]string{
+		oldPkgYAML: "url: https://github.com/prometheus-community/helm-charts.git\n" +
+			"subdirectory: charts/kube-prometheus-stack\n" +
+			"commit: aaa # upstream commit\n" +
+			"version: &version 77.9.1-rancher.3 # rancher version\n",
+		oldDir + "rancher-monitoring/overlay/charts/grafana/values.yaml":                                  "image:\n  repository: rancher/mirrored-grafana-grafana\n  tag: \"12.0.0\" # keep comment\n",
+		oldDir + "rancher-monitoring/overlay/charts/rancher-windows-exporter/values.yaml":                 "image:\n  repository: rancher/windows-exporter\n",
+		oldDir + "rancher-monitoring/generated-changes/patch/charts/kube-state-metrics/values.yaml.patch": "--- a\n+++ b\n@@ -1,2 +1,2 @@\n image:\n-  tag: v2.16.0\n+  tag: v2.16.0-rancher\n",
+		oldDir + "rancher-monitoring/generated-changes/patch/charts/grafana/values.yaml.patch":            "--- a\n+++ b\n@@ -1,2 +1,2 @@\n image:\n-  registry: docker.io\n+  registry: \"\"\n",
+		oldDir + "rancher-monitoring/overlay/values-windows.yaml":                                         "grafana:\n  image:\n    tag: 12.0.0\n",
+	})
+	return repo
+}
+
+func rebaseInfo(chartVersion string) rebase.ChartRebaseInfo {
+	return rebase.ChartRebaseInfo{
+		FoundChart: rebase.FoundChart{Name: "kube-prometheus-stack", CommitHash: "bbb", ChartVersion: chartVersion},
+		SubchartTagExpectations: []rebase.SubchartTagExpectation{
+			{Name: "grafana", AppVersion: "12.1.0", ExpectedTags: map[string]string{"image.tag": "12.1.0"}},
+			{Name: "kube-state-metrics", AppVersion: "2.17.0", ExpectedTags: map[string]string{"image.tag": "v2.17.0"}},
+			{Name: "windows-exporter", AppVersion: "0.31.0", ExpectedTags: map[string]string{"image.tag": "0.31.0"}},
+		},
+	}
+}
+
+func TestApply_NewVersionDir(t *testing.T) {
+	repo := chartsRepo(t)
+
+	result, err := Apply(repo, "rancher-monitoring", rebaseInfo("78.0.0"))
+	require.NoError(t, err)
+	assert.Equal(t, "packages/rancher-monitoring/77.9", result.CopiedFrom)
+	assert.Equal(t, "packages/rancher-monitoring/78.0", result.VersionDir)
+	assert.Equal(t, "packages/rancher-monitoring/78.0/rancher-monitoring/package.yaml", result.PackageYAML)
+	assert.Equal(t, "78.0.0-rancher.1", result.NewVersion)
+
+	assert.Equal(t, "url: https://github.com/prometheus-community/helm-charts.git\n"+
+		"subdirectory: charts/kube-prometheus-stack\n"+
+		"commit: bbb # upstream commit\n"+
+		"version: &version 78.0.0-rancher.1 # rancher version\n", readFile(t, filepath.Join(repo, result.PackageYAML)))
+	assert.Contains(t, readFile(t, filepath.Join(repo, oldPkgYAML)), "commit: aaa", "the previous version is left as is")
+
+	newValues := "packages/rancher-monitoring/78.0/rancher-monitoring/overlay/charts/grafana/values.yaml"
+	assert.Equal(t, "image:\n  repository: rancher/mirrored-grafana-grafana\n  tag: \"12.1.0\" # keep comment\n",
+		readFile(t, filepath.Join(repo, newValues)))
+	newParentValues := "packages/rancher-monitoring/78.0/rancher-monitoring/overlay/values-windows.yaml"
+	assert.Equal(t, "grafana:\n  image:\n    tag: 12.1.0\n", readFile(t, filepath.Join(repo, newParentValues)))
+	assert.ElementsMatch(t, []TagUpdate{
+		{File: newValues, Key: "image.tag", OldValue: "12.0.0", NewValue: "12.1.0"},
+		{File: newParentValues, Key: "grafana.image.tag", OldValue: "12.0.0", NewValue: "12.1.0"},
+	}, result.TagUpdates)
+	assert.Equal(t, []TagUpdate{{
+		File:     "packages/rancher-monitoring/78.0/rancher-monitoring/overlay/charts/rancher-windows-exporter/values.yaml",
+		Key:      "image.tag",
+		NewValue: "0.31.0",
+	}}, result.MissingTags)
+	assert.Equal(t, []string{
+		"packages/rancher-monitoring/78.0/rancher-monitoring/generated-changes/patch/charts/kube-state-metrics/values.yaml.patch",
+	}, result.StalePatches, "only the patches changing an expected tag are stale")
+
+	_, err = Apply(repo, "rancher-monitoring", rebaseInfo("78.0.0"))
+	assert.ErrorContains(t, err, "already uses upstream commit")
+}
+
+func TestApply_SameMinor(t *testing.T) {
+	repo := chartsRepo(t)
+
+	result, err := Apply(repo, "rancher-monitoring", rebaseInfo("77.9.2"))
+	require.NoError(t, err)
+	assert.Empty(t, result.CopiedFrom, "the package is edited in place")
+	assert.Equal(t, oldPkgYAML, result.PackageYAML)
+	assert.Equal(t, "77.9.2-rancher.1", result.NewVersion)
+	assert.Contains(t, readFile(t, filepath.Join(repo, oldPkgYAML)), "version: &version 77.9.2-rancher.1 # rancher version\n")
+}
@@ -1,187 +1,354 @@
 package branchverifycheck
 
 import (
-	"errors"
+	"bytes"
 	"fmt"
+	"os"
+	"slices"
+	"sync"
 
-	"github.com/go-git/go-git/v5"
+	gitpkg "github.com/rancher/ob-charts-tool/internal/git"
+	log "github.com/rancher/ob-charts-tool/internal/logging"
 )
 
-// globalCheck prints msg, registers check globally, prints passMsg/failMsg, and returns Passed.
-func globalCheck(p *ProgressPrinter, r *VerificationResult, msg string, check CheckResult, passMsg, failMsg string) bool {
-	p.Print(msg)
-	r.AddGlobalCheck(check)
-	if check.Passed {
-		p.Println(passMsg)
-	} else {
-		p.Println(failMsg)
+// Options configures VerifyBranch.
+type Options struct {
+	// Format selects how the result is written; empty means OutputFormatHuman. Machine-readable
+	// formats written to stdout suppress progress output.
+	Format OutputFormat
+	// OutputFile, when set, receives the result in Format while the human-readable result
+	// and progress are still printed to stdout.
+	OutputFile string
+	// ImagePolicy is used by the package images check; nil means DefaultImagePolicy.
+	ImagePolicy *ImagePolicy
+	// Registry holds the checks to run; nil means DefaultRegistry.
+	Registry *Registry
+	// Only restricts the run to these check IDs and their dependencies.
+	Only []string
+	// Skip excludes these check IDs and every check depending on them.
+	Skip []string
+	// InPlace runs isolated checks (such as the build) in the checkout itself instead of
+	// throwaway worktrees of HEAD; the checkout must then be clean for the build check.
+	InPlace bool
+	// UpstreamURL is the canonical upstream repository; empty means CanonicalUpstreamURL.
+	UpstreamURL string
+	// BaseRef is a local ref or revision (e.g. "origin/release-v2.12") to verify the branch
+	// against instead of the upstream main branch. Nothing is fetched when it is set.
+	BaseRef string
+	// NoFetch uses the main branch of an existing remote pointing to the upstream as last
+	// fetched instead of fetching it.
+	NoFetch bool
+	// CommitRange is a range such as "A..B" whose commits VerifyCommits verifies one by one.
+	CommitRange string
+	// PerCommit makes VerifyCommits verify each commit of the branch since its merge base.
+	PerCommit bool
+	// Concurrency is how many packages are verified in parallel worktrees; values below 1 mean 1.
+	Concurrency int
+}
+
+// upstreamURL returns UpstreamURL, or CanonicalUpstreamURL when it is not set.
+func (o Options) upstreamURL() string {
+	if o.UpstreamURL == "" {
+		return CanonicalUpstreamURL
 	}
-	return check.Passed
+	return o.UpstreamURL
 }
 
-// packageCheck prints msg, registers check on the package result, and prints the outcome.
-// On pass, prints "OK - <check.Message>" so developers see a brief status without needing JSON output.
-// On fail, prints failMsg (e.g. "FAILED" or "WARN").
-func packageCheck(p *ProgressPrinter, pr *PackageResult, msg string, check CheckResult, failMsg string) {
-	p.Print(msg)
-	pr.AddCheck(check)
-	if check.Passed {
-		p.Println("OK - " + check.Message)
-	} else {
-		p.Println(failMsg)
+// validateOutput checks that the output options can be combined.
+func (o Options) validateOutput() error {
+	if o.OutputFile != "" && !o.Format.IsMachineReadable() {
+		return fmt.Errorf("an output file requires a machine-readable format (one of json, sarif, junit)")
+	}
+	return nil
+}
+
+// checkOutcome is what happened to a check, used to decide whether its dependents run.
+type checkOutcome int
+
+const (
+	outcomeNotRun checkOutcome = iota
+	outcomePassed
+	outcomeWarned
+	outcomeFailed
+)
+
+// satisfied reports whether dependents of a check with this outcome may run.
+func (o checkOutcome) satisfied() bool {
+	return o == outcomePassed || o == outcomeWarned
+}
+
+func outcomeOf(check CheckResult) checkOutcome {
+	switch {
+	case check.Passed:
+		return outcomePassed
+	case check.Critical:
+		return outcomeFailed
+	default:
+		return outcomeWarned
 	}
 }
 
 // VerifyBranch takes a file path containing the ob-team-charts repo and verifies the branch state.
 // It specifically is a tool to analyze the branch not the chart itself.
 //
-// Verification steps:
-// - Basic checks: is path git repo, is upstream containing `ob-team-charts`, is on branch, is branch not main
-// - Verify branch only modifies a single Package (soft-fail/warning)
-// - Verify that the package+chart created is sequential to the last built chart (hard fail)
-// - If chart build scripts are run targeting the modified package, no uncommitted changes are found (hard fail)
-func VerifyBranch(path string, jsonOutput bool) (*VerificationResult, error) {
+// It runs the checks selected from opts.Registry (DefaultRegistry unless set) in registration
+// order: global checks once, and each run of consecutive package checks for every modified package.
+func VerifyBranch(path string, opts Options) (*VerificationResult, error) {
+	registry := opts.Registry
+	if registry == nil {
+		registry = DefaultRegistry()
+	}
+	checks, err := registry.Select(opts.Only, opts.Skip)
+	if err != nil {
+		return nil, err
+	}
+	if err := opts.validateOutput(); err != nil {
+		return nil, err
+	}
+
 	result := &VerificationResult{
 		Success:        true,
 		GlobalChecks:   []CheckResult{},
 		PackageResults: []PackageResult{},
 	}
+	env := &CheckEnv{Path: path, Options: opts}
+	// Ensure cleanup of any temporary remote we created
+	defer func() {
+		if env.Repo != nil {
+			CleanupToolRemote(env.Repo)
+		}
+	}()
 
 	// Use progress printer from output.go
-	progress := NewProgressPrinter(jsonOutput)
+	progress := NewProgressPrinter(opts.Format.IsMachineReadable() && opts.OutputFile == "")
 
 	progress.Println("Starting branch verification...")
 	progress.Println("")
 
-	// ============================================
-	// GLOBAL CHECKS - Run once for the whole branch
-	// ============================================
+	runChecks(env, checks, result, progress)
 
-	// Step 1: Check if path is a git repository
-	progress.Print("Checking if path is a git repository... ")
-	gitRepoCheck := CheckIsGitRepo(path)
-	result.AddGlobalCheck(gitRepoCheck)
-	if !gitRepoCheck.Passed {
-		progress.Println("FAILED")
-		return result, errors.New("path is not a git repository")
-	}
-	progress.Println("OK")
+	// Determine overall success
+	result.Success = !result.HasCriticalFailure()
 
-	// Open the repository
-	repo, err := git.PlainOpen(path)
-	if err != nil {
-		return result, fmt.Errorf("failed to open git repo: %w", err)
+	// Output results
+	result.Branch = env.BranchName
+	if err := OutputResults(result, opts); err != nil && env.err == nil {
+		env.err = err
 	}
 
-	// Step 2: Check upstream remote
-	globalCheck(progress, result, "Checking upstream repository... ", CheckHasObTeamChartsRemote(repo), "OK", "FAILED")
+	return result, env.err
+}
 
-	// Step 3: Check branch status (not on main/master)
-	branchName, branchCheck := CheckOnFeatureBranch(repo)
-	globalCheck(progress, result, "Checking branch status... ", branchCheck,
-		fmt.Sprintf("OK (on branch '%s')", branchName), "FAILED")
+// runChecks runs checks in order, recording each result on result. Global checks run once;
+// consecutive package checks run as a group for each package in env.Packages, which
+// global checks earlier in the list populate.
+func runChecks(env *CheckEnv, checks []Check, result *VerificationResult, progress *ProgressPrinter) {
+	globalOutcomes := make(map[string]checkOutcome)
+	packageOutcomes := make(map[string]map[string]checkOutcome)
 
-	// Step 4: Get git refs (ensures upstream remote, fetches, finds merge-base)
-	progress.Print("Setting up upstream remote and fetching... ")
-	refs, err := GetGitRefs(repo, path)
-	// Ensure cleanup of any temporary remote we created
-	defer CleanupToolRemote(repo)
-	if err != nil {
-		progress.Println("FAILED")
-		result.AddGlobalCheck(CheckResult{
-			Name:     "Git References",
-			Passed:   false,
-			Message:  fmt.Sprintf("Failed to get git refs: %v", err),
-			Critical: true,
-		})
-		// Continue to output results
-		outputResults(result, branchName, jsonOutput)
-		return result, err
+	for i := 0; i < len(checks); {
+		if checks[i].Scope() == ScopeGlobal {
+			check := checks[i]
+			progress.Print(check.Description() + "... ")
+			if dep, ok := unmetDependency(check, globalOutcomes, nil); ok {
+				progress.Printf("SKIPPED (requires %s)\n", dep)
+			} else {
+				res := runCheck(env, check, PackageInfo{})
+				result.AddGlobalCheck(res)
+				globalOutcomes[check.ID()] = outcomeOf(res)
+				printOutcome(progress, res)
+			}
+			i++
+			continue
+		}
+
+		// Group consecutive package checks so each package's checks run together
+		end := i
+		for end < len(checks) && checks[end].Scope() == ScopePackage {
+			end++
+		}
+		for _, pkg := range env.Packages {
+			if packageOutcomes[pkg.FullPath] == nil {
+				packageOutcomes[pkg.FullPath] = make(map[string]checkOutcome)
+			}
+		}
+		runPackageStage(env, checks[i:end], globalOutcomes, packageOutcomes, result, progress)
+		i = end
 	}
-	progress.Println("OK")
+}
 
-	// Step 5: Check if branch is current with upstream
-	branchInfo, currentCheck := CheckBranchCurrent(refs, repo)
-	behindMsg := "WARN - Branch is behind main"
-	if branchInfo.CommitsBehind != 0 {
-		behindMsg = fmt.Sprintf("WARN - Branch is %d commit(s) behind main", branchInfo.CommitsBehind)
+// runPackageStage runs a group of package checks for every package in env.Packages.
+// When every check of the group is isolated, packages run concurrently (up to
+// Options.Concurrency), each in its own worktree; a package's progress is then printed
+// in one piece once it finishes. Results are recorded in package order.
+func runPackageStage(env *CheckEnv, checks []Check, globalOutcomes map[string]checkOutcome,
+	packageOutcomes map[string]map[string]checkOutcome, result *VerificationResult, progress *ProgressPrinter) {
+	if len(env.Packages) == 0 {
+		return
 	}
-	globalCheck(progress, result, "Checking if branch is current with upstream... ", currentCheck, "OK", behindMsg)
 
-	// Step 6: Find modified packages
-	// First find package indexes to make finding modified packages easier
-	packageIndexes := FindAllPackages(path)
+	concurrency := 1
+	if !env.Options.InPlace && !slices.ContainsFunc(checks, func(c Check) bool { return !c.Isolated() }) {
+		concurrency = min(max(env.Options.Concurrency, 1), len(env.Packages))
+	}
 
-	progress.Print("Finding modified packages... ")
-	packages, packagesCheck := FindModifiedPackages(packageIndexes, refs)
-	result.AddGlobalCheck(packagesCheck)
-	if len(packages) > 0 {
-		progress.Printf("OK (found: %v)\n", packageNames(packages))
+	progress.Println("")
+	if concurrency > 1 {
+		progress.Printf("Checking %d packages in parallel worktrees (up to %d at a time)...\n", len(env.Packages), concurrency)
+	}
+
+	results := make([][]CheckResult, len(env.Packages))
+	if concurrency == 1 {
+		for idx, pkg := range env.Packages {
+			results[idx] = runPackageChecks(env, checks, pkg, globalOutcomes, packageOutcomes[pkg.FullPath], progress)
+		}
 	} else {
-		progress.Println("NONE")
+		runPackagesConcurrently(env, checks, concurrency, globalOutcomes, packageOutcomes, results, progress)
 	}
 	progress.Println("")
 
-	// ============================================
-	// PER-PACKAGE CHECKS - Run for each modified package
-	// ============================================
-
-	for _, pkg := range packages {
+	for idx, pkg := range env.Packages {
 		pkgResult := result.GetOrCreatePackageResult(pkg)
-
-		packageCheck(progress, pkgResult, fmt.Sprintf("Checking sequential version for %s... ", pkg.FullPath),
-			CheckSequentialVersion(path, pkg), "FAILED")
-		packageCheck(progress, pkgResult, fmt.Sprintf("Checking chart built for %s... ", pkg.FullPath),
-			CheckChartBuilt(path, pkg), "FAILED")
+		for _, res := range results[idx] {
+			pkgResult.AddCheck(res)
+		}
 	}
+}
 
-	// ============================================
-	// GLOBAL CHECK - Repository cleanliness before build
-	// ============================================
+// runPackagesConcurrently runs checks for every package with up to concurrency packages
+// at a time, storing each package's results at its index in results.
+func runPackagesConcurrently(env *CheckEnv, checks []Check, concurrency int, globalOutcomes map[string]checkOutcome,
+	packageOutcomes map[string]map[string]checkOutcome, results [][]CheckResult, progress *ProgressPrinter) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for idx, pkg := range env.Packages {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
 
-	repoIsClean := globalCheck(progress, result, "Checking repository is clean before build... ",
-		CheckRepoClean(repo), "OK", "DIRTY (skipping build checks)")
+			var buf bytes.Buffer
+			results[idx] = runPackageChecks(env, checks, pkg, globalOutcomes, packageOutcomes[pkg.FullPath], progress.buffered(&buf))
 
-	// ============================================
-	// PER-PACKAGE CHECK - Build verification (only if repo was clean)
-	// ============================================
+			mu.Lock()
+			defer mu.Unlock()
+			progress.Print(buf.String())
+		})
+	}
+	wg.Wait()
+}
 
-	if repoIsClean {
-		for _, pkg := range packages {
-			pkgResult := result.GetOrCreatePackageResult(pkg)
+// runPackageChecks runs checks for a single package, in a throwaway worktree of HEAD
+// for isolated checks unless Options.InPlace is set. The worktree is removed afterwards.
+func runPackageChecks(env *CheckEnv, checks []Check, pkg PackageInfo, globalOutcomes, outcomes map[string]checkOutcome,
+	progress *ProgressPrinter) []CheckResult {
+	var results []CheckResult
+	var worktree *gitpkg.Worktree
+	defer func() {
+		if worktree != nil {
+			if err := worktree.Remove(); err != nil {
+				log.Log.Warnf("Failed to remove worktree %s: %v", worktree.Path, err)
+			}
+		}
+	}()
 
-			packageCheck(progress, pkgResult, fmt.Sprintf("Running build check for %s (this may take a while)... ", pkg.FullPath),
-				CheckBuildNoChanges(path, pkg), "FAILED")
-			packageCheck(progress, pkgResult, fmt.Sprintf("Running package image check for %s (this may take a while)... ", pkg.FullPath),
-				CheckPackageImages(path, pkg), "FAILED")
-			packageCheck(progress, pkgResult, fmt.Sprintf("Checking subchart appVersion tags for %s... ", pkg.FullPath),
-				CheckSubchartAppVersionTags(path, pkg), "WARN")
+	for _, check := range checks {
+		progress.Printf("%s for %s... ", check.Description(), pkg.FullPath)
+		if dep, ok := unmetDependency(check, globalOutcomes, outcomes); ok {
+			progress.Printf("SKIPPED (requires %s)\n", dep)
+			continue
 		}
-	}
 
-	// Output results
-	outputResults(result, branchName, jsonOutput)
+		checkEnv := env
+		if check.Isolated() && !env.Options.InPlace {
+			if worktree == nil {
+				wt, err := gitpkg.AddWorktree(env.Path, "HEAD")
+				if err != nil {
+					res := CheckResult{
+						Name:     fmt.Sprintf("%s (%s)", check.ID(), pkg.FullPath),
+						Message:  fmt.Sprintf("Failed to create worktree: %v", err),
+						Critical: check.Severity() == SeverityCritical,
+					}
+					results = append(results, res)
+					outcomes[check.ID()] = outcomeOf(res)
+					printOutcome(progress, res)
+					continue
+				}
+				worktree = wt
+			}
+			isolatedEnv := *env
+			isolatedEnv.Path = worktree.Path
+			isolatedEnv.Isolated = true
+			checkEnv = &isolatedEnv
+		}
 
-	// Determine overall success
-	result.Success = !result.HasCriticalFailure()
+		res := runCheck(checkEnv, check, pkg)
+		results = append(results, res)
+		outcomes[check.ID()] = outcomeOf(res)
+		printOutcome(progress, res)
+	}
+	return results
+}
 
-	return result, nil
+// runCheck runs a check and applies its registered severity to the result.
+func runCheck(env *CheckEnv, check Check, pkg PackageInfo) CheckResult {
+	res := check.Run(env, pkg)
+	res.ID = check.ID()
+	res.Critical = check.Severity() == SeverityCritical
+	return res
 }
 
-// outputResults handles outputting results in the appropriate format
-func outputResults(result *VerificationResult, branchName string, jsonOutput bool) {
-	if jsonOutput {
-		OutputJSON(result)
-	} else {
-		OutputHuman(result, branchName)
+// unmetDependency returns the first dependency of check that did not run or failed
+// critically. Package-scoped dependencies are looked up in packageOutcomes.
+func unmetDependency(check Check, globalOutcomes, packageOutcomes map[string]checkOutcome) (string, bool) {
+	for _, dep := range check.Dependencies() {
+		outcome, ok := globalOutcomes[dep]
+		if !ok {
+			outcome = packageOutcomes[dep]
+		}
+		if !outcome.satisfied() {
+			return dep, true
+		}
+	}
+	return "", false
+}
+
+// printOutcome prints "OK - <message>" on pass so developers see a brief status without
+// needing JSON output, and FAILED or WARN with the message otherwise.
+func printOutcome(p *ProgressPrinter, check CheckResult) {
+	switch outcomeOf(check) {
+	case outcomePassed:
+		p.Println("OK - " + check.Message)
+	case outcomeFailed:
+		p.Println("FAILED - " + check.Message)
+	default:
+		p.Println("WARN - " + check.Message)
 	}
 }
 
-// packageNames extracts the FullPath from a slice of PackageInfo for display
-func packageNames(packages []PackageInfo) []string {
-	names := make([]string, len(packages))
-	for i, pkg := range packages {
-		names[i] = pkg.FullPath
+// OutputResults outputs results in the format of opts.Format: to opts.OutputFile
+// alongside the human-readable output when set, otherwise to stdout.
+func OutputResults(result *VerificationResult, opts Options) error {
+	if err := opts.validateOutput(); err != nil {
+		return err
+	}
+
+	if opts.OutputFile != "" {
+		f, err := os.Create(opts.OutputFile)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer f.Close()
+		if err := WriteResult(f, result, opts.Format); err != nil {
+			return err
+		}
+		OutputHuman(result, result.Branch)
+		return nil
+	}
+
+	if opts.Format.IsMachineReadable() {
+		return WriteResult(os.Stdout, result, opts.Format)
 	}
-	return names
+	OutputHuman(result, result.Branch)
+	return nil
 }
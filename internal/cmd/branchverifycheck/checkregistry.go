@@ -0,0 +1,223 @@
+package branchverifycheck
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// CheckScope tells whether a check runs once per branch or once per modified package.
+type CheckScope string
+
+const (
+	ScopeGlobal  CheckScope = "global"
+	ScopePackage CheckScope = "package"
+)
+
+// Severity tells whether a failing check fails the verification (critical) or only warns.
+type Severity string
+
+const (
+	SeverityCritical Severity = "critical"
+	SeverityWarning  Severity = "warning"
+)
+
+// Check is a single verification step run by VerifyBranch.
+type Check interface {
+	// ID is the stable identifier used by --only and --skip, e.g. "sequential-version".
+	ID() string
+	// Description is printed as the check's progress message, e.g. "Checking sequential version".
+	Description() string
+	Scope() CheckScope
+	Severity() Severity
+	// Dependencies lists the IDs of the checks that must run first. A check is skipped
+	// when a dependency did not run or failed with critical severity; for package checks
+	// a package-scoped dependency is evaluated per package.
+	Dependencies() []string
+	// Isolated reports whether the check runs in a throwaway worktree of HEAD rather than
+	// in the checkout, e.g. because it modifies files. Isolated package checks of
+	// different packages run concurrently.
+	Isolated() bool
+	// Run runs the check. pkg is the zero PackageInfo for global checks.
+	Run(env *CheckEnv, pkg PackageInfo) CheckResult
+}
+
+// CheckEnv is the state shared by the checks of one VerifyBranch run. Checks that set
+// up state (the repository, git refs, modified packages) store it for their dependents.
+type CheckEnv struct {
+	// Path is the ob-team-charts checkout being verified
+	Path    string
+	Options Options
+
+	Repo       *git.Repository
+	BranchName string
+	Refs       *GitRefs
+	// Packages are the packages the branch modifies; package checks run for each of them
+	Packages []PackageInfo
+	// Isolated is set while an isolated check runs; Path is then the package's worktree
+	Isolated bool
+
+	// err is the first error that prevents verification from completing
+	err error
+}
+
+// fail records err as the reason verification could not complete.
+func (e *CheckEnv) fail(err error) {
+	if e.err == nil {
+		e.err = err
+	}
+}
+
+// CheckSpec describes a check created with NewCheck.
+type CheckSpec struct {
+	ID           string
+	Description  string
+	Scope        CheckScope
+	Severity     Severity
+	Dependencies []string
+	Isolated     bool
+}
+
+// NewCheck returns a Check that runs fn.
+func NewCheck(spec CheckSpec, fn func(env *CheckEnv, pkg PackageInfo) CheckResult) Check {
+	return &funcCheck{spec: spec, fn: fn}
+}
+
+type funcCheck struct {
+	spec CheckSpec
+	fn   func(env *CheckEnv, pkg PackageInfo) CheckResult
+}
+
+func (c *funcCheck) ID() string             { return c.spec.ID }
+func (c *funcCheck) Description() string    { return c.spec.Description }
+func (c *funcCheck) Scope() CheckScope      { return c.spec.Scope }
+func (c *funcCheck) Severity() Severity     { return c.spec.Severity }
+func (c *funcCheck) Dependencies() []string { return c.spec.Dependencies }
+func (c *funcCheck) Isolated() bool         { return c.spec.Isolated }
+
+func (c *funcCheck) Run(env *CheckEnv, pkg PackageInfo) CheckResult {
+	return c.fn(env, pkg)
+}
+
+// Registry holds checks in the order VerifyBranch runs them.
+type Registry struct {
+	checks []Check
+	byID   map[string]Check
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{byID: make(map[string]Check)}
+}
+
+// Register appends a check. IDs must be unique and dependencies must already be
+// registered, so the registration order is always a valid run order.
+func (r *Registry) Register(check Check) error {
+	if check.ID() == "" {
+		return fmt.Errorf("check ID cannot be empty")
+	}
+	if _, exists := r.byID[check.ID()]; exists {
+		return fmt.Errorf("check %q is already registered", check.ID())
+	}
+	if check.Scope() != ScopeGlobal && check.Scope() != ScopePackage {
+		return fmt.Errorf("check %q has unknown scope %q", check.ID(), check.Scope())
+	}
+	for _, dep := range check.Dependencies() {
+		depCheck, ok := r.byID[dep]
+		if !ok {
+			return fmt.Errorf("check %q depends on %q, which is not registered before it", check.ID(), dep)
+		}
+		if check.Scope() == ScopeGlobal && depCheck.Scope() == ScopePackage {
+			return fmt.Errorf("global check %q cannot depend on package check %q", check.ID(), dep)
+		}
+	}
+	r.checks = append(r.checks, check)
+	r.byID[check.ID()] = check
+	return nil
+}
+
+// MustRegister is like Register but panics on error.
+func (r *Registry) MustRegister(checks ...Check) {
+	for _, check := range checks {
+		if err := r.Register(check); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Checks returns the registered checks in run order.
+func (r *Registry) Checks() []Check {
+	return slices.Clone(r.checks)
+}
+
+// Get returns the check with the given ID.
+func (r *Registry) Get(id string) (Check, bool) {
+	check, ok := r.byID[id]
+	return check, ok
+}
+
+// Select returns the checks to run, in run order. When only is set, just those checks
+// and their transitive dependencies are selected. Checks in skip are then removed,
+// together with every check that depends on them.
+func (r *Registry) Select(only, skip []string) ([]Check, error) {
+	if err := r.validateIDs(append(slices.Clone(only), skip...)); err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]bool, len(r.checks))
+	if len(only) == 0 {
+		for _, check := range r.checks {
+			selected[check.ID()] = true
+		}
+	} else {
+		var include func(id string)
+		include = func(id string) {
+			if selected[id] {
+				return
+			}
+			selected[id] = true
+			for _, dep := range r.byID[id].Dependencies() {
+				include(dep)
+			}
+		}
+		for _, id := range only {
+			include(id)
+		}
+	}
+
+	// Dependencies are registered before their dependents, so a single pass
+	// removes skipped checks transitively
+	var checks []Check
+	for _, check := range r.checks {
+		if !selected[check.ID()] {
+			continue
+		}
+		if slices.Contains(skip, check.ID()) || slices.ContainsFunc(check.Dependencies(), func(dep string) bool {
+			return !selected[dep]
+		}) {
+			selected[check.ID()] = false
+			continue
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+func (r *Registry) validateIDs(ids []string) error {
+	var unknown []string
+	for _, id := range ids {
+		if _, ok := r.byID[id]; !ok {
+			unknown = append(unknown, id)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	known := make([]string, len(r.checks))
+	for i, check := range r.checks {
+		known[i] = check.ID()
+	}
+	return fmt.Errorf("unknown check(s) %s (available: %s)", strings.Join(unknown, ", "), strings.Join(known, ", "))
+}
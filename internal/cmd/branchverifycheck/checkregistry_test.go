@@ -0,0 +1,236 @@
+package branchverifycheck
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCheck returns a check that records its runs and returns a result with the given pass state.
+func fakeCheck(id string, scope CheckScope, severity Severity, passed bool, runs *[]string, deps ...string) Check {
+	return NewCheck(CheckSpec{
+		ID:           id,
+		Description:  "Running " + id,
+		Scope:        scope,
+		Severity:     severity,
+		Dependencies: deps,
+	}, func(_ *CheckEnv, pkg PackageInfo) CheckResult {
+		name := id
+		if pkg.FullPath != "" {
+			name += "(" + pkg.FullPath + ")"
+		}
+		*runs = append(*runs, name)
+		return CheckResult{Name: name, Passed: passed, Message: name}
+	})
+}
+
+func checkIDs(checks []Check) []string {
+	ids := make([]string, len(checks))
+	for i, check := range checks {
+		ids[i] = check.ID()
+	}
+	return ids
+}
+
+func TestRegistryRegister(t *testing.T) {
+	var runs []string
+	r := NewRegistry()
+	require.NoError(t, r.Register(fakeCheck("a", ScopeGlobal, SeverityCritical, true, &runs)))
+	require.NoError(t, r.Register(fakeCheck("b", ScopePackage, SeverityCritical, true, &runs, "a")))
+
+	cases := []struct {
+		name    string
+		check   Check
+		wantErr string
+	}{
+		{"duplicate ID", fakeCheck("a", ScopeGlobal, SeverityCritical, true, &runs), "already registered"},
+		{"empty ID", fakeCheck("", ScopeGlobal, SeverityCritical, true, &runs), "cannot be empty"},
+		{"unknown dependency", fakeCheck("c", ScopeGlobal, SeverityCritical, true, &runs, "missing"), "not registered before it"},
+		{"global depends on package", fakeCheck("c", ScopeGlobal, SeverityCritical, true, &runs, "b"), "cannot depend on package check"},
+		{"unknown scope", fakeCheck("c", CheckScope("branch"), SeverityCritical, true, &runs), "unknown scope"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := r.Register(tc.check)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+	assert.Equal(t, []string{"a", "b"}, checkIDs(r.Checks()))
+}
+
+func TestRegistrySelect(t *testing.T) {
+	r := DefaultRegistry()
+
+	cases := []struct {
+		name string
+		only []string
+		skip []string
+		want []string
+	}{
+		{
+			name: "all checks by default",
+			want: checkIDs(r.Checks()),
+		},
+		{
+			name: "only includes transitive dependencies",
+			only: []string{CheckIDSequentialVersion},
+			want: []string{CheckIDGitRepo, CheckIDGitRefs, CheckIDModifiedPackages, CheckIDSequentialVersion},
+		},
+		{
+			name: "skip removes dependents",
+			skip: []string{CheckIDRepoClean, CheckIDChartBuilt},
+			want: []string{
+				CheckIDGitRepo, CheckIDUpstreamRemote, CheckIDFeatureBranch, CheckIDGitRefs,
+				CheckIDBranchCurrent, CheckIDModifiedPackages, CheckIDSequentialVersion, CheckIDBuildNoChanges,
+			},
+		},
+		{
+			name: "only and skip combined",
+			only: []string{CheckIDSequentialVersion, CheckIDPackageImages},
+			skip: []string{CheckIDPackageImages},
+			want: []string{CheckIDGitRepo, CheckIDGitRefs, CheckIDModifiedPackages, CheckIDSequentialVersion, CheckIDChartBuilt},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := r.Select(tc.only, tc.skip)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, checkIDs(got))
+		})
+	}
+
+	t.Run("unknown IDs", func(t *testing.T) {
+		_, err := r.Select([]string{"nope"}, []string{"also-nope"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "nope, also-nope")
+	})
+}
+
+func TestRunChecks(t *testing.T) {
+	var runs []string
+	pkgs := []PackageInfo{makePackageInfo("rancher-logging", "4.1"), makePackageInfo("rancher-monitoring", "77.9")}
+
+	setup := NewCheck(CheckSpec{ID: "packages", Scope: ScopeGlobal, Severity: SeverityWarning},
+		func(env *CheckEnv, _ PackageInfo) CheckResult {
+			env.Packages = pkgs
+			// A warning does not block dependents
+			return CheckResult{Name: "packages", Passed: false}
+		})
+	failsForLogging := NewCheck(CheckSpec{ID: "built", Scope: ScopePackage, Severity: SeverityCritical, Dependencies: []string{"packages"}},
+		func(_ *CheckEnv, pkg PackageInfo) CheckResult {
+			runs = append(runs, "built("+pkg.FullPath+")")
+			return CheckResult{Name: "built", Passed: pkg.Name != "rancher-logging", Critical: false}
+		})
+
+	checks := []Check{
+		setup,
+		fakeCheck("version", ScopePackage, SeverityCritical, true, &runs, "packages"),
+		failsForLogging,
+		fakeCheck("dirty", ScopeGlobal, SeverityCritical, false, &runs),
+		fakeCheck("build", ScopePackage, SeverityCritical, true, &runs, "packages", "dirty"),
+		fakeCheck("images", ScopePackage, SeverityWarning, false, &runs, "built"),
+	}
+
+	result := &VerificationResult{}
+	runChecks(&CheckEnv{}, checks, result, NewProgressPrinter(true))
+
+	assert.Equal(t, []string{
+		"version(rancher-logging/4.1)", "built(rancher-logging/4.1)",
+		"version(rancher-monitoring/77.9)", "built(rancher-monitoring/77.9)",
+		"dirty",
+		"images(rancher-monitoring/77.9)",
+	}, runs, "package checks are grouped per package and skipped when a dependency failed critically")
+
+	require.Len(t, result.GlobalChecks, 2)
+	require.Len(t, result.PackageResults, 2)
+	assert.Len(t, result.PackageResults[0].Checks, 2)
+	assert.Len(t, result.PackageResults[1].Checks, 3)
+
+	built := result.PackageResults[0].Checks[1]
+	assert.True(t, built.Critical, "registered severity overrides the result")
+	assert.True(t, result.HasCriticalFailure())
+}
+
+func TestRunChecksIsolated(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git CLI not available")
+	}
+
+	m := newMockRepo(t)
+	m.commit(t, map[string]string{"file.txt": "committed\n"}, "initial")
+	// A dirty checkout must neither leak into nor be touched by isolated checks
+	writeFile(t, filepath.Join(m.dir, "file.txt"), "dirty\n")
+
+	pkgs := []PackageInfo{
+		makePackageInfo("rancher-logging", "4.1"),
+		makePackageInfo("rancher-monitoring", "77.9"),
+		makePackageInfo("rancher-project-monitoring", "0.5"),
+	}
+	setup := NewCheck(CheckSpec{ID: "packages", Scope: ScopeGlobal, Severity: SeverityCritical},
+		func(env *CheckEnv, _ PackageInfo) CheckResult {
+			env.Packages = pkgs
+			return CheckResult{Name: "packages", Passed: true}
+		})
+
+	var mu sync.Mutex
+	worktrees := map[string]string{}
+	build := NewCheck(CheckSpec{ID: "build", Scope: ScopePackage, Severity: SeverityCritical, Dependencies: []string{"packages"}, Isolated: true},
+		func(env *CheckEnv, pkg PackageInfo) CheckResult {
+			data, err := os.ReadFile(filepath.Join(env.Path, "file.txt"))
+			if err != nil {
+				return CheckResult{Name: "build", Message: err.Error()}
+			}
+			writeFile(t, filepath.Join(env.Path, "file.txt"), "built "+pkg.Name+"\n")
+			mu.Lock()
+			worktrees[pkg.FullPath] = env.Path
+			mu.Unlock()
+			return CheckResult{Name: "build", Passed: env.Isolated && string(data) == "committed\n", Message: string(data)}
+		})
+	images := NewCheck(CheckSpec{ID: "images", Scope: ScopePackage, Severity: SeverityCritical, Dependencies: []string{"build"}, Isolated: true},
+		func(env *CheckEnv, pkg PackageInfo) CheckResult {
+			data, err := os.ReadFile(filepath.Join(env.Path, "file.txt"))
+			if err != nil {
+				return CheckResult{Name: "images", Message: err.Error()}
+			}
+			// Checks of one package share its worktree
+			return CheckResult{Name: "images", Passed: string(data) == "built "+pkg.Name+"\n", Message: string(data)}
+		})
+
+	result := &VerificationResult{}
+	env := &CheckEnv{Path: m.dir, Options: Options{Concurrency: 2}}
+	runChecks(env, []Check{setup, build, images}, result, NewProgressPrinter(true))
+
+	require.Len(t, result.PackageResults, len(pkgs))
+	for i, pkgResult := range result.PackageResults {
+		assert.Equal(t, pkgs[i].FullPath, pkgResult.Package.FullPath, "results are recorded in package order")
+		require.Len(t, pkgResult.Checks, 2)
+		for _, check := range pkgResult.Checks {
+			assert.True(t, check.Passed, "%s (%s): %s", check.Name, pkgResult.Package.FullPath, check.Message)
+		}
+	}
+
+	require.Len(t, worktrees, len(pkgs))
+	for pkg, path := range worktrees {
+		assert.NotEqual(t, m.dir, path, "%s should run in a worktree", pkg)
+		assert.NoDirExists(t, path, "worktree of %s should be removed", pkg)
+	}
+	data, err := os.ReadFile(filepath.Join(m.dir, "file.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "dirty\n", string(data))
+
+	t.Run("in place", func(t *testing.T) {
+		result := &VerificationResult{}
+		env := &CheckEnv{Path: m.dir, Options: Options{InPlace: true}}
+		runChecks(env, []Check{setup, build}, result, NewProgressPrinter(true))
+		for _, pkgResult := range result.PackageResults {
+			assert.False(t, pkgResult.Checks[0].Passed, "in-place checks see the checkout")
+		}
+		assert.Equal(t, m.dir, worktrees[pkgs[0].FullPath])
+	})
+}
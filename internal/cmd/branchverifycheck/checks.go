@@ -6,15 +6,19 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/rancher/ob-charts-tool/helmtools/chart"
 	"github.com/rancher/ob-charts-tool/helmtools/values"
 	"github.com/rancher/ob-charts-tool/helmtools/version"
+	"github.com/rancher/ob-charts-tool/internal/chartsrepo"
 	"github.com/rancher/ob-charts-tool/internal/config"
 	gitpkg "github.com/rancher/ob-charts-tool/internal/git"
+	"github.com/rancher/ob-charts-tool/internal/rebase"
 	internalvalues "github.com/rancher/ob-charts-tool/internal/values"
 	"go.yaml.in/yaml/v3"
 )
@@ -44,6 +48,12 @@ func CheckIsGitRepo(path string) CheckResult {
 //   - https://github.com/rancher/ob-team-charts
 //   - git@github.com:rancher/ob-team-charts.git
 func CheckHasObTeamChartsRemote(repo *git.Repository) CheckResult {
+	return CheckHasUpstreamRemote(repo, CanonicalUpstreamURL)
+}
+
+// CheckHasUpstreamRemote verifies the repo has a remote pointing to upstreamURL.
+// GitHub remotes match in either HTTPS or SSH format.
+func CheckHasUpstreamRemote(repo *git.Repository, upstreamURL string) CheckResult {
 	check := CheckResult{
 		Name:     "Upstream Repository",
 		Critical: true,
@@ -56,21 +66,25 @@ func CheckHasObTeamChartsRemote(repo *git.Repository) CheckResult {
 		return check
 	}
 
-	for remoteName, urls := range remotes {
-		for _, url := range urls {
-			if gitpkg.IsGitHubRepoURL(url, "rancher", "ob-team-charts") {
-				check.Passed = true
-				check.Message = fmt.Sprintf("Found canonical upstream in remote '%s'", remoteName)
-				return check
-			}
-		}
+	if remoteName := upstreamRemoteName(remotes, upstreamURL); remoteName != "" {
+		check.Passed = true
+		check.Message = fmt.Sprintf("Found canonical upstream in remote '%s'", remoteName)
+		return check
 	}
 
 	check.Passed = false
-	check.Message = "No remote points to canonical upstream (rancher/ob-team-charts)"
+	check.Message = fmt.Sprintf("No remote points to canonical upstream (%s)", upstreamDisplayName(upstreamURL))
 	return check
 }
 
+// upstreamDisplayName returns owner/repo for GitHub URLs and the URL itself otherwise.
+func upstreamDisplayName(upstreamURL string) string {
+	if repoPath := gitpkg.ExtractGitHubRepoPath(upstreamURL); repoPath != "" {
+		return repoPath
+	}
+	return upstreamURL
+}
+
 // CheckOnFeatureBranch verifies we're on a feature branch, not main/master.
 // Returns the branch name and the check result.
 func CheckOnFeatureBranch(repo *git.Repository) (string, CheckResult) {
@@ -97,7 +111,7 @@ func CheckOnFeatureBranch(repo *git.Repository) (string, CheckResult) {
 	return branchName, check
 }
 
-// CheckBranchCurrent checks if the branch is up-to-date with upstream/main.
+// CheckBranchCurrent checks if the branch is up-to-date with upstream/main (or the base ref).
 func CheckBranchCurrent(refs *GitRefs, repo *git.Repository) (BranchInfo, CheckResult) {
 	check := CheckResult{
 		Name:     "Branch Current with Upstream",
@@ -110,7 +124,7 @@ func CheckBranchCurrent(refs *GitRefs, repo *git.Repository) (BranchInfo, CheckR
 
 	if refs.MergeBaseCommit.Hash == refs.UpstreamCommit.Hash {
 		check.Passed = true
-		check.Message = "Branch is up-to-date with upstream/main"
+		check.Message = fmt.Sprintf("Branch is up-to-date with %s", refs.upstreamName())
 		branchInfo.IsUpToDate = true
 		return branchInfo, check
 	}
@@ -121,9 +135,9 @@ func CheckBranchCurrent(refs *GitRefs, repo *git.Repository) (BranchInfo, CheckR
 	check.Passed = false
 	branchInfo.IsUpToDate = false
 	if branchInfo.CommitsBehind > 0 {
-		check.Message = fmt.Sprintf("Branch is %d commit(s) behind upstream/main - consider rebasing to ensure version checks are accurate", branchInfo.CommitsBehind)
+		check.Message = fmt.Sprintf("Branch is %d commit(s) behind %s - consider rebasing to ensure version checks are accurate", branchInfo.CommitsBehind, refs.upstreamName())
 	} else {
-		check.Message = "Branch is behind upstream/main - consider rebasing to ensure version checks are accurate"
+		check.Message = fmt.Sprintf("Branch is behind %s - consider rebasing to ensure version checks are accurate", refs.upstreamName())
 	}
 	return branchInfo, check
 }
@@ -150,7 +164,14 @@ func FindModifiedPackages(packageIndexes map[string]PackageInfo, refs *GitRefs)
 		return nil, check
 	}
 
-	changes, err := mergeBaseTree.Diff(headTree)
+	return findModifiedPackagesBetween(packageIndexes, mergeBaseTree, headTree, "in this branch", check)
+}
+
+// findModifiedPackagesBetween finds the packages with changes between two trees and
+// completes check with the outcome; scope describes the changes in messages, e.g. "in this branch".
+func findModifiedPackagesBetween(packageIndexes map[string]PackageInfo, fromTree, toTree *object.Tree, scope string,
+	check CheckResult) ([]PackageInfo, CheckResult) {
+	changes, err := fromTree.Diff(toTree)
 	if err != nil {
 		check.Passed = false
 		check.Message = fmt.Sprintf("Failed to diff trees: %v", err)
@@ -189,16 +210,17 @@ func FindModifiedPackages(packageIndexes map[string]PackageInfo, refs *GitRefs)
 		}
 	}
 
-	// Convert to slice
+	// Convert to slice, sorted so packages are always reported in the same order
 	var packages []PackageInfo
 	for _, pkg := range modifiedPackages {
 		packages = append(packages, pkg)
 	}
+	slices.SortFunc(packages, func(a, b PackageInfo) int { return strings.Compare(a.FullPath, b.FullPath) })
 
 	if len(packages) == 0 {
 		// No packages modified is not a failure - branch might modify docs, CI, etc.
 		check.Passed = true
-		check.Message = "No packages modified in this branch (nothing to verify)"
+		check.Message = fmt.Sprintf("No packages modified %s (nothing to verify)", scope)
 		return packages, check
 	}
 
@@ -262,7 +284,7 @@ func getPackageVersionInfo(repoPath string, pkg PackageInfo) (*PackageVersionInf
 		return nil, fmt.Errorf("failed to read package.yaml: %w", err)
 	}
 
-	var pkgYAML PackageYAML
+	var pkgYAML chartsrepo.PackageYAML
 	if err := yaml.Unmarshal(data, &pkgYAML); err != nil {
 		return nil, fmt.Errorf("failed to parse package.yaml: %w", err)
 	}
@@ -342,6 +364,11 @@ func CheckSequentialVersion(repoPath string, pkg PackageInfo) CheckResult {
 		return check
 	}
 
+	return sequentialVersionResult(info, check)
+}
+
+// sequentialVersionResult completes check with whether info.Version is sequential to info.ExistingVersions.
+func sequentialVersionResult(info *PackageVersionInfo, check CheckResult) CheckResult {
 	// If there are no existing versions or only the current version, this is the first version
 	versionsExcludingCurrent := 0
 	for v := range info.ExistingVersions {
@@ -356,7 +383,7 @@ func CheckSequentialVersion(repoPath string, pkg PackageInfo) CheckResult {
 	}
 
 	// Check if this is a rancher-suffixed version
-	currentRancherRelease, rancherErr := extractRancherRelease(info.Version)
+	currentRancherRelease, rancherErr := rebase.ExtractRancherRelease(info.Version)
 
 	if rancherErr == nil {
 		// Has rancher suffix - check that n-1 exists
@@ -419,17 +446,6 @@ func CheckSequentialVersion(repoPath string, pkg PackageInfo) CheckResult {
 	return check
 }
 
-func extractRancherRelease(version string) (int, error) {
-	re := regexp.MustCompile(`-rancher\.(\d+)`)
-	matches := re.FindStringSubmatch(version)
-	if len(matches) < 2 {
-		return 0, fmt.Errorf("no rancher release found in version: %s", version)
-	}
-	var release int
-	_, err := fmt.Sscanf(matches[1], "%d", &release)
-	return release, err
-}
-
 // RepoStatus holds the result of checking repository cleanliness.
 type RepoStatus struct {
 	IsClean       bool
@@ -510,7 +526,8 @@ func CheckBuildNoChanges(repoPath string, pkg PackageInfo) CheckResult {
 	}
 
 	// Check for uncommitted changes after build
-	repo, err := git.PlainOpen(repoPath)
+	// repoPath may be a linked worktree, whose objects live in the main repository
+	repo, err := git.PlainOpenWithOptions(repoPath, &git.PlainOpenOptions{EnableDotGitCommonDir: true})
 	if err != nil {
 		check.Passed = false
 		check.Message = fmt.Sprintf("Failed to open repo after build: %v", err)
@@ -553,7 +570,16 @@ func CheckBuildNoChanges(repoPath string, pkg PackageInfo) CheckResult {
 	return check
 }
 
+// CheckPackageImages verifies the images in a package's built chart against DefaultImagePolicy.
 func CheckPackageImages(repoPath string, pkg PackageInfo) CheckResult {
+	return CheckPackageImagesWithPolicy(repoPath, pkg, DefaultImagePolicy())
+}
+
+// CheckPackageImagesWithPolicy verifies that every image definition in the values.yaml
+// files of a package's built chart and its companion CRD chart, and every image hardcoded
+// in their templates, satisfies policy. Violations waived by one of the
+// policy's exemptions are reported along with their justification but do not fail the check.
+func CheckPackageImagesWithPolicy(repoPath string, pkg PackageInfo, policy *ImagePolicy) CheckResult {
 	check := CheckResult{
 		Name:     fmt.Sprintf("Package Images origins (%s)", pkg.FullPath),
 		Critical: false,
@@ -566,72 +592,130 @@ func CheckPackageImages(repoPath string, pkg PackageInfo) CheckResult {
 		return check
 	}
 
-	if pkg.Name == "kube-prometheus-stack" {
-		// Skip package images check for kube-prometheus-stack because it should not have images modified at all
-		// The version of the package is only a slightly modified version of the upstream chart
+	pkgPolicy := policy.forPackage(pkg)
+	if exemption, ok := pkgPolicy.packageExemption(); ok {
+		// e.g. kube-prometheus-stack should not have images modified at all
 		check.Passed = true
 		check.Critical = false
-		check.Message = "Skipping package images check for kube-prometheus-stack"
+		check.Message = fmt.Sprintf("Skipping package images check for %s (%s)", pkg.Name, exemption.Justification)
 		return check
 	}
 
-	chartPath := filepath.Join(repoPath, "charts", pkg.Name, info.Version)
-
-	// TODO eventually we should also check CRD charts values.yaml just in case
+	chartsRoot := filepath.Join(repoPath, "charts")
+	chartPath := filepath.Join(chartsRoot, pkg.Name, info.Version)
 
-	// Find all values.yaml files in the chart directory
-	valuesFiles, err := findValuesYAMLFiles(chartPath)
-	if err != nil {
-		check.Passed = false
-		check.Message = fmt.Sprintf("Failed to find values.yaml files: %v", err)
-		return check
+	// The companion CRD chart (e.g. its CRD install job) must follow the same rules
+	chartDirs := []string{chartPath}
+	if crdChartPath, ok := chart.CompanionCRDChartDir(chartPath); ok {
+		chartDirs = append(chartDirs, crdChartPath)
 	}
 
-	if len(valuesFiles) == 0 {
-		check.Passed = true
-		check.Message = "No values.yaml files found to check"
-		return check
-	}
+	// Track any invalid images found, including those with only exempted issues
+	var foundImages []InvalidImage
+	details := &ImageCheckDetails{}
 
-	// Track any invalid images found
-	var invalidImages []InvalidImage
+	for _, chartDir := range chartDirs {
+		// Paths are reported relative to the package chart; those of the CRD chart
+		// relative to the charts directory (e.g. "rancher-monitoring-crd/1.0.0/values.yaml")
+		relBase := chartPath
+		if chartDir != chartPath {
+			relBase = chartsRoot
+		}
+		relPath := func(path string) string {
+			rel, err := filepath.Rel(relBase, path)
+			if err != nil {
+				return path
+			}
+			return rel
+		}
 
-	// Iterate through each values.yaml file
-	for _, valuesFile := range valuesFiles {
-		data, err := os.ReadFile(valuesFile)
+		// Find all values.yaml files in the chart directory
+		valuesFiles, err := findValuesYAMLFiles(chartDir)
 		if err != nil {
 			check.Passed = false
-			check.Message = fmt.Sprintf("Failed to read %s: %v", valuesFile, err)
+			check.Message = fmt.Sprintf("Failed to find values.yaml files: %v", err)
 			return check
 		}
 
-		var values map[string]interface{}
-		if err := yaml.Unmarshal(data, &values); err != nil {
+		// Iterate through each values.yaml file
+		for _, valuesFile := range valuesFiles {
+			data, err := os.ReadFile(valuesFile)
+			if err != nil {
+				check.Passed = false
+				check.Message = fmt.Sprintf("Failed to read %s: %v", valuesFile, err)
+				return check
+			}
+
+			var values map[string]interface{}
+			if err := yaml.Unmarshal(data, &values); err != nil {
+				check.Passed = false
+				check.Message = fmt.Sprintf("Failed to parse %s: %v", valuesFile, err)
+				return check
+			}
+
+			// Recursively find all image definitions and validate them
+			found := len(foundImages)
+			findInvalidImages(values, relPath(valuesFile), pkgPolicy, &foundImages)
+			for i := found; i < len(foundImages); i++ {
+				valuesPath := strings.TrimPrefix(foundImages[i].Path, relPath(valuesFile)+".")
+				foundImages[i].Location = sourceLocation(repoPath, valuesFile, valuesLine(data, valuesPath))
+			}
+		}
+
+		// Images hardcoded in templates bypass values.yaml entirely
+		templateFiles, err := findTemplateFiles(chartDir)
+		if err != nil {
 			check.Passed = false
-			check.Message = fmt.Sprintf("Failed to parse %s: %v", valuesFile, err)
+			check.Message = fmt.Sprintf("Failed to find template files: %v", err)
 			return check
 		}
 
-		// Recursively find all image definitions and validate them
-		relPath, err := filepath.Rel(chartPath, valuesFile)
-		if err != nil {
-			relPath = valuesFile
+		for _, templateFile := range templateFiles {
+			data, err := os.ReadFile(templateFile)
+			if err != nil {
+				check.Passed = false
+				check.Message = fmt.Sprintf("Failed to read %s: %v", templateFile, err)
+				return check
+			}
+			found := len(foundImages)
+			findLiteralTemplateImages(data, relPath(templateFile), pkgPolicy, &foundImages)
+			for i := found; i < len(foundImages); i++ {
+				foundImages[i].Location = sourceLocation(repoPath, templateFile, foundImages[i].Line)
+			}
 		}
-		findInvalidImages(values, relPath, &invalidImages)
+
+		details.FilesChecked += len(valuesFiles)
+		details.TemplatesChecked += len(templateFiles)
 	}
 
-	if len(invalidImages) > 0 {
-		check.Passed = false
-		check.Message = fmt.Sprintf("Found %d invalid image(s)", len(invalidImages))
-		check.Details = &ImageCheckDetails{
-			InvalidImages: invalidImages,
-			FilesChecked:  len(valuesFiles),
+	if details.FilesChecked == 0 && details.TemplatesChecked == 0 {
+		check.Passed = true
+		check.Message = "No values.yaml or template files found to check"
+		return check
+	}
+
+	for _, img := range foundImages {
+		if len(img.Issues) > 0 {
+			details.InvalidImages = append(details.InvalidImages, img)
+		} else {
+			details.ExemptedImages = append(details.ExemptedImages, img)
 		}
+	}
+
+	if len(details.InvalidImages) > 0 {
+		check.Passed = false
+		check.Message = fmt.Sprintf("Found %d invalid image(s)", len(details.InvalidImages))
+		check.Details = details
 		return check
 	}
 
 	check.Passed = true
-	check.Message = fmt.Sprintf("All images use registry=\"\" and start with \"rancher/\" (checked %d values.yaml file(s))", len(valuesFiles))
+	check.Message = fmt.Sprintf("All images %s (checked %d values.yaml file(s) and %d template(s))",
+		policy.Summary(), details.FilesChecked, details.TemplatesChecked)
+	if len(details.ExemptedImages) > 0 {
+		check.Message += fmt.Sprintf(", %d image(s) exempted by policy", len(details.ExemptedImages))
+		check.Details = details
+	}
 	return check
 }
 
@@ -653,17 +737,14 @@ func findValuesYAMLFiles(dir string) ([]string, error) {
 }
 
 // findInvalidImages recursively searches for image definitions in a YAML structure
-// and adds any invalid images to the invalidImages slice.
-// An image is invalid if:
-//   - registry field is not empty string
-//   - repository field does not start with "rancher/"
-func findInvalidImages(data interface{}, path string, invalidImages *[]InvalidImage) {
+// and adds any image that violates policy to the invalidImages slice.
+func findInvalidImages(data interface{}, path string, policy packageImagePolicy, invalidImages *[]InvalidImage) {
 	switch v := data.(type) {
 	case map[string]interface{}:
 		// Check if this map represents an image definition; if so, validate it
 		// and stop recursing — its children are image fields, not nested images.
 		if isImageDefinition(v) {
-			validateImageDefinition(v, path, invalidImages)
+			validateImageDefinition(v, path, policy, invalidImages)
 			return
 		}
 
@@ -675,14 +756,14 @@ func findInvalidImages(data interface{}, path string, invalidImages *[]InvalidIm
 			} else {
 				newPath = key
 			}
-			findInvalidImages(value, newPath, invalidImages)
+			findInvalidImages(value, newPath, policy, invalidImages)
 		}
 
 	case []interface{}:
 		// Recursively search arrays
 		for i, item := range v {
 			newPath := fmt.Sprintf("%s[%d]", path, i)
-			findInvalidImages(item, newPath, invalidImages)
+			findInvalidImages(item, newPath, policy, invalidImages)
 		}
 	}
 }
@@ -756,7 +837,8 @@ func CheckSubchartAppVersionTags(repoPath string, pkg PackageInfo) CheckResult {
 		}
 
 		// Read values.yaml
-		valuesBytes, readErr := os.ReadFile(filepath.Join(subchartPath, "values.yaml"))
+		valuesPath := filepath.Join(subchartPath, "values.yaml")
+		valuesBytes, readErr := os.ReadFile(valuesPath)
 		if readErr != nil {
 			continue
 		}
@@ -772,6 +854,7 @@ func CheckSubchartAppVersionTags(repoPath string, pkg PackageInfo) CheckResult {
 				ValuesKey:     m.ValuesKey,
 				ActualValue:   m.ActualValue,
 				ExpectedValue: m.ExpectedValue,
+				Location:      sourceLocation(repoPath, valuesPath, valuesLine(valuesBytes, m.ValuesKey)),
 			})
 		}
 	}
@@ -788,12 +871,14 @@ func CheckSubchartAppVersionTags(repoPath string, pkg PackageInfo) CheckResult {
 	return check
 }
 
-// validateImageDefinition checks if an image definition meets the requirements:
+// validateImageDefinition checks if an image definition satisfies policy (see ImagePolicy).
+// With DefaultImagePolicy:
 // - registry must be empty string (or not set)
 // - repository must start with "rancher/"
-func validateImageDefinition(m map[string]interface{}, path string, invalidImages *[]InvalidImage) {
+//
+// Violations waived by an exemption are recorded in InvalidImage.Exempted instead of Issues.
+func validateImageDefinition(m map[string]interface{}, path string, policy packageImagePolicy, invalidImages *[]InvalidImage) {
 	repository, hasRepository := m["repository"]
-	registry, hasRegistry := m["registry"]
 
 	// If no repository field, this might not be a real image definition
 	if !hasRepository {
@@ -806,26 +891,20 @@ func validateImageDefinition(m map[string]interface{}, path string, invalidImage
 	}
 
 	// Collect all issues for this image definition
-	var issues []string
-
-	// Check registry field - it should be empty string or not present
-	if hasRegistry {
-		registryStr, ok := registry.(string)
-		if !ok || registryStr != "" {
-			issues = append(issues, fmt.Sprintf("registry=%v (expected \"\")", registry))
+	img := InvalidImage{Path: path}
+	for _, violation := range policy.violations(m, repositoryStr) {
+		if exemption, ok := policy.exemption(violation.rule, path, repositoryStr); ok {
+			img.Exempted = append(img.Exempted, ExemptedIssue{
+				Issue:         violation.message,
+				Justification: exemption.Justification,
+			})
+			continue
 		}
-	}
-
-	// Check repository starts with "rancher/"
-	if !strings.HasPrefix(repositoryStr, "rancher/") {
-		issues = append(issues, fmt.Sprintf("repository=%s (expected rancher/...)", repositoryStr))
+		img.Issues = append(img.Issues, violation.message)
 	}
 
 	// Add all issues found for this image
-	if len(issues) > 0 {
-		*invalidImages = append(*invalidImages, InvalidImage{
-			Path:   path,
-			Issues: issues,
-		})
+	if len(img.Issues) > 0 || len(img.Exempted) > 0 {
+		*invalidImages = append(*invalidImages, img)
 	}
 }
@@ -121,34 +121,6 @@ func setBranch(t *testing.T, repo *git.Repository, branchName string) {
 // Pure logic tests
 // =============================================================================
 
-func TestExtractRancherRelease(t *testing.T) {
-	cases := []struct {
-		input       string
-		wantRelease int
-		wantErr     bool
-	}{
-		{"1.0.0-rancher.1", 1, false},
-		{"1.0.0-rancher.3", 3, false},
-		{"1.0.0-rancher.10", 10, false},
-		{"77.0.0+up12.0.0-rancher.2", 2, false},
-		{"1.0.0", 0, true},
-		{"1.0.0-rc.1", 0, true},
-		{"", 0, true},
-		{"rancher.3", 0, true}, // no dash before "rancher"
-	}
-	for _, tc := range cases {
-		t.Run(tc.input, func(t *testing.T) {
-			got, err := extractRancherRelease(tc.input)
-			if tc.wantErr {
-				assert.Error(t, err, "extractRancherRelease(%q)", tc.input)
-			} else {
-				assert.NoError(t, err, "extractRancherRelease(%q)", tc.input)
-				assert.Equal(t, tc.wantRelease, got)
-			}
-		})
-	}
-}
-
 func TestIsImageDefinition(t *testing.T) {
 	cases := []struct {
 		name string
@@ -228,7 +200,7 @@ func TestValidateImageDefinition(t *testing.T) {
 				m[k] = v
 			}
 			var invalidImages []InvalidImage
-			validateImageDefinition(m, "test.path", &invalidImages)
+			validateImageDefinition(m, "test.path", DefaultImagePolicy().forPackage(PackageInfo{}), &invalidImages)
 
 			totalIssues := 0
 			for _, img := range invalidImages {
@@ -327,7 +299,7 @@ func TestFindInvalidImages(t *testing.T) {
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
 			var invalidImages []InvalidImage
-			findInvalidImages(tc.data, "", &invalidImages)
+			findInvalidImages(tc.data, "", DefaultImagePolicy().forPackage(PackageInfo{}), &invalidImages)
 			assert.Len(t, invalidImages, tc.wantInvalidCount, "findInvalidImages: %+v", invalidImages)
 			if tc.wantPath != "" && len(invalidImages) > 0 {
 				assert.Equal(t, tc.wantPath, invalidImages[0].Path)
@@ -678,6 +650,11 @@ func TestCheckPackageImages(t *testing.T) {
 `)
 		result := CheckPackageImages(repoPath, pkg)
 		assert.False(t, result.Passed, "should fail when one of multiple values files has invalid image")
+		details, ok := result.Details.(*ImageCheckDetails)
+		require.True(t, ok, "Details should be *ImageCheckDetails")
+		require.Len(t, details.InvalidImages, 1)
+		assert.Equal(t, &SourceLocation{File: "charts/rancher-logging/1.0.0/charts/sub/values.yaml", Line: 1},
+			details.InvalidImages[0].Location)
 	})
 }
 
@@ -841,6 +818,29 @@ func TestCheckHasObTeamChartsRemote(t *testing.T) {
 	})
 }
 
+func TestCheckHasUpstreamRemote(t *testing.T) {
+	cases := []struct {
+		name        string
+		remoteURL   string
+		upstreamURL string
+		wantPassed  bool
+	}{
+		{"fork on GitHub via SSH", "git@github.com:example/ob-team-charts.git", "https://github.com/example/ob-team-charts.git", true},
+		{"canonical remote with fork upstream", "https://github.com/rancher/ob-team-charts.git", "https://github.com/example/ob-team-charts.git", false},
+		{"non-GitHub URL without .git", "https://git.example.com/team/charts", "https://git.example.com/team/charts.git", true},
+		{"non-GitHub URL mismatch", "https://git.example.com/team/other.git", "https://git.example.com/team/charts.git", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, repo := makeCommittedGitRepo(t)
+			addGitRemote(t, repo, "origin", tc.remoteURL)
+			result := CheckHasUpstreamRemote(repo, tc.upstreamURL)
+			assert.Equal(t, tc.wantPassed, result.Passed, "CheckHasUpstreamRemote: %s", result.Message)
+		})
+	}
+}
+
 func TestCheckOnFeatureBranch(t *testing.T) {
 	cases := []struct {
 		name           string
@@ -0,0 +1,319 @@
+package branchverifycheck
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/rancher/ob-charts-tool/internal/chartsrepo"
+	gitpkg "github.com/rancher/ob-charts-tool/internal/git"
+	"go.yaml.in/yaml/v3"
+)
+
+// CommitResult groups the check results for a single commit of a verified range
+type CommitResult struct {
+	// Commit is the full commit hash
+	Commit string `json:"commit"`
+	// Subject is the first line of the commit message
+	Subject  string        `json:"subject"`
+	Packages []PackageInfo `json:"packages"`
+	Checks   []CheckResult `json:"checks"`
+}
+
+// ShortCommit returns the abbreviated commit hash.
+func (c *CommitResult) ShortCommit() string {
+	if len(c.Commit) > 7 {
+		return c.Commit[:7]
+	}
+	return c.Commit
+}
+
+// HasCriticalFailure returns true if any critical check failed
+func (c *CommitResult) HasCriticalFailure() bool {
+	for _, check := range c.Checks {
+		if !check.Passed && check.Critical {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseCommitRange splits a range such as "A..B" into its ends. As with git, an omitted
+// end means HEAD; a single revision "A" means "A..HEAD".
+func ParseCommitRange(commitRange string) (from, to string, err error) {
+	if strings.Contains(commitRange, "...") {
+		return "", "", fmt.Errorf("symmetric difference ranges are not supported: %s", commitRange)
+	}
+	from, to, found := strings.Cut(commitRange, "..")
+	if !found {
+		to = "HEAD"
+	}
+	if from == "" {
+		from = "HEAD"
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+	if from == to {
+		return "", "", fmt.Errorf("commit range %q is empty", commitRange)
+	}
+	return from, to, nil
+}
+
+// RangeCommits returns the commits reachable from to but not from from, oldest first.
+// Only first parents are followed, so a merged branch is verified as its merge commit.
+func RangeCommits(repo *git.Repository, from, to *object.Commit) ([]*object.Commit, error) {
+	excluded := make(map[string]bool)
+	iter, err := repo.Log(&git.LogOptions{From: from.Hash})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list history of %s: %w", from.Hash.String()[:7], err)
+	}
+	err = iter.ForEach(func(c *object.Commit) error {
+		excluded[c.Hash.String()] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list history of %s: %w", from.Hash.String()[:7], err)
+	}
+
+	var commits []*object.Commit
+	for c := to; !excluded[c.Hash.String()]; {
+		commits = append(commits, c)
+		if c.NumParents() == 0 {
+			break
+		}
+		if c, err = c.Parent(0); err != nil {
+			return nil, fmt.Errorf("failed to get parent commit: %w", err)
+		}
+	}
+	slices.Reverse(commits)
+	return commits, nil
+}
+
+// resolveCommitRange returns the commits to verify: those of Options.CommitRange, or for
+// Options.PerCommit those between the merge base with the upstream (see GetGitRefs) and HEAD.
+func resolveCommitRange(repo *git.Repository, repoPath string, opts Options) ([]*object.Commit, error) {
+	var from, to *object.Commit
+	if opts.CommitRange != "" {
+		fromRev, toRev, err := ParseCommitRange(opts.CommitRange)
+		if err != nil {
+			return nil, err
+		}
+		if from, err = resolveCommit(repo, fromRev); err != nil {
+			return nil, err
+		}
+		if to, err = resolveCommit(repo, toRev); err != nil {
+			return nil, err
+		}
+	} else {
+		refs, err := GetGitRefs(repo, repoPath, opts)
+		if err != nil {
+			return nil, err
+		}
+		from, to = refs.MergeBaseCommit, refs.HeadCommit
+	}
+	return RangeCommits(repo, from, to)
+}
+
+func resolveCommit(repo *git.Repository, rev string) (*object.Commit, error) {
+	ref, err := ResolveBaseRef(repo, rev)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit for '%s': %w", rev, err)
+	}
+	return commit, nil
+}
+
+// VerifyCommits verifies each commit of a range individually, without checking any of
+// them out: it reports the packages each commit modifies and whether their versions are
+// sequential at that commit, so that a broken intermediate commit of a PR is caught.
+//
+// The range is opts.CommitRange, or with opts.PerCommit the commits of the branch since
+// its merge base with the upstream.
+func VerifyCommits(path string, opts Options) (*VerificationResult, error) {
+	if opts.CommitRange == "" && !opts.PerCommit {
+		return nil, errors.New("a commit range or per-commit verification is required")
+	}
+	if err := opts.validateOutput(); err != nil {
+		return nil, err
+	}
+
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repo: %w", err)
+	}
+	defer CleanupToolRemote(repo)
+
+	commits, err := resolveCommitRange(repo, path, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &VerificationResult{
+		Success:        true,
+		GlobalChecks:   []CheckResult{},
+		PackageResults: []PackageResult{},
+		CommitResults:  []CommitResult{},
+	}
+	progress := NewProgressPrinter(opts.Format.IsMachineReadable() && opts.OutputFile == "")
+	progress.Printf("Verifying %d commit(s)...\n\n", len(commits))
+
+	for _, commit := range commits {
+		commitResult := VerifyCommit(commit)
+		progress.Printf("%s %s\n", commitResult.ShortCommit(), commitResult.Subject)
+		for _, check := range commitResult.Checks {
+			progress.Printf("  %s... ", check.Name)
+			printOutcome(progress, check)
+		}
+		result.CommitResults = append(result.CommitResults, commitResult)
+	}
+	progress.Println("")
+
+	result.Success = !result.HasCriticalFailure()
+
+	result.Branch = opts.CommitRange
+	if result.Branch == "" {
+		result.Branch, _ = gitpkg.FindRepoBranchName(repo)
+	}
+	if err := OutputResults(result, opts); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// VerifyCommit reports the packages a commit modifies compared to its first parent and
+// runs the sequential version check for each of them against the commit's tree.
+func VerifyCommit(commit *object.Commit) CommitResult {
+	subject, _, _ := strings.Cut(commit.Message, "\n")
+	commitResult := CommitResult{
+		Commit:   commit.Hash.String(),
+		Subject:  subject,
+		Packages: []PackageInfo{},
+		Checks:   []CheckResult{},
+	}
+
+	modifiedCheck := CheckResult{
+		ID:       CheckIDModifiedPackages,
+		Name:     "Modified Packages",
+		Critical: false, // Soft fail
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		modifiedCheck.Message = fmt.Sprintf("Failed to get commit tree: %v", err)
+		commitResult.Checks = append(commitResult.Checks, modifiedCheck)
+		return commitResult
+	}
+	// A root commit is compared with the empty tree
+	parentTree := &object.Tree{}
+	if commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err == nil {
+			parentTree, err = parent.Tree()
+		}
+		if err != nil {
+			modifiedCheck.Message = fmt.Sprintf("Failed to get parent tree: %v", err)
+			commitResult.Checks = append(commitResult.Checks, modifiedCheck)
+			return commitResult
+		}
+	}
+
+	packages, modifiedCheck := findModifiedPackagesBetween(findPackagesInTree(tree), parentTree, tree, "in this commit", modifiedCheck)
+	commitResult.Checks = append(commitResult.Checks, modifiedCheck)
+	commitResult.Packages = append(commitResult.Packages, packages...)
+
+	for _, pkg := range packages {
+		check := CheckSequentialVersionInTree(tree, pkg)
+		check.ID = CheckIDSequentialVersion
+		commitResult.Checks = append(commitResult.Checks, check)
+	}
+	return commitResult
+}
+
+// findPackagesInTree is FindAllPackages for a commit tree. Package paths are relative
+// to the repository root.
+func findPackagesInTree(tree *object.Tree) map[string]PackageInfo {
+	packages := make(map[string]PackageInfo)
+	_ = tree.Files().ForEach(func(f *object.File) error {
+		if !strings.HasPrefix(f.Name, "packages/") || path.Base(f.Name) != "package.yaml" {
+			return nil
+		}
+		pkg := newPackageInfo(filepath.FromSlash(f.Name), "")
+		if pkg.Name != "" {
+			packages[pkg.Name] = pkg
+		}
+		return nil
+	})
+	return packages
+}
+
+// CheckSequentialVersionInTree is CheckSequentialVersion for a package as of a commit tree.
+func CheckSequentialVersionInTree(tree *object.Tree, pkg PackageInfo) CheckResult {
+	check := CheckResult{
+		Name:     fmt.Sprintf("Sequential Version (%s)", pkg.FullPath),
+		Critical: true,
+	}
+
+	info, err := getPackageVersionInfoInTree(tree, pkg)
+	if err != nil {
+		check.Passed = false
+		check.Message = err.Error()
+		return check
+	}
+
+	return sequentialVersionResult(info, check)
+}
+
+// getPackageVersionInfoInTree is getPackageVersionInfo for a commit tree.
+func getPackageVersionInfoInTree(tree *object.Tree, pkg PackageInfo) (*PackageVersionInfo, error) {
+	info := &PackageVersionInfo{
+		PackageYAMLPath:  filepath.ToSlash(getPackageYAMLPath("", pkg)),
+		ChartsDir:        path.Join("charts", pkg.Name),
+		ExistingVersions: make(map[string]bool),
+	}
+
+	file, err := tree.File(info.PackageYAMLPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package.yaml: %w", err)
+	}
+	reader, err := file.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package.yaml: %w", err)
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package.yaml: %w", err)
+	}
+
+	var pkgYAML chartsrepo.PackageYAML
+	if err := yaml.Unmarshal(data, &pkgYAML); err != nil {
+		return nil, fmt.Errorf("failed to parse package.yaml: %w", err)
+	}
+	info.Version = pkgYAML.Version
+
+	chartsTree, err := tree.Tree(info.ChartsDir)
+	if err != nil {
+		if errors.Is(err, object.ErrDirectoryNotFound) {
+			// Charts directory doesn't exist yet - that's ok, we'll return empty map
+			return info, nil
+		}
+		return nil, fmt.Errorf("failed to read charts directory: %w", err)
+	}
+	for _, entry := range chartsTree.Entries {
+		if entry.Mode == filemode.Dir {
+			info.ExistingVersions[entry.Name] = true
+		}
+	}
+
+	return info, nil
+}
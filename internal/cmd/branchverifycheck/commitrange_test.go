@@ -0,0 +1,126 @@
+package branchverifycheck
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCommitRange(t *testing.T) {
+	cases := []struct {
+		in       string
+		wantFrom string
+		wantTo   string
+		wantErr  bool
+	}{
+		{"main..feature", "main", "feature", false},
+		{"main..", "main", "HEAD", false},
+		{"..feature", "HEAD", "feature", false},
+		{"abc123", "abc123", "HEAD", false},
+		{"main...feature", "", "", true},
+		{"HEAD..HEAD", "", "", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			from, to, err := ParseCommitRange(tc.in)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantFrom, from)
+			assert.Equal(t, tc.wantTo, to)
+		})
+	}
+}
+
+// commitRangeRepo builds a history where the second of three commits on top of base
+// skips a rancher release, so only that commit fails the sequential version check.
+func commitRangeRepo(t *testing.T) (m *mockRepo, base *object.Commit, commits []*object.Commit) {
+	t.Helper()
+	m = newMockRepo(t)
+	const pkgYAML = "packages/rancher-logging/4.1/package.yaml"
+	base = m.commit(t, map[string]string{
+		pkgYAML: "version: 4.1.0-rancher.1\n",
+		"charts/rancher-logging/4.1.0-rancher.1/Chart.yaml": "name: rancher-logging\n",
+	}, "Add rancher-logging 4.1.0-rancher.1")
+	commits = append(commits,
+		m.commit(t, map[string]string{
+			pkgYAML: "version: 4.1.0-rancher.2\n",
+			"charts/rancher-logging/4.1.0-rancher.2/Chart.yaml": "name: rancher-logging\n",
+		}, "Bump rancher-logging to 4.1.0-rancher.2"),
+		m.commit(t, map[string]string{
+			pkgYAML: "version: 4.1.0-rancher.4\n",
+			"charts/rancher-logging/4.1.0-rancher.4/Chart.yaml": "name: rancher-logging\n",
+		}, "Bump rancher-logging to 4.1.0-rancher.4\n\nSkips a release by mistake."),
+		m.commit(t, map[string]string{"README.md": "# docs\n"}, "Update docs"),
+	)
+	return m, base, commits
+}
+
+func TestRangeCommits(t *testing.T) {
+	m, base, commits := commitRangeRepo(t)
+
+	got, err := RangeCommits(m.repo, base, commits[2])
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	for i, c := range got {
+		assert.Equal(t, commits[i].Hash, c.Hash, "commits should be oldest first")
+	}
+
+	got, err = RangeCommits(m.repo, commits[2], base)
+	require.NoError(t, err)
+	assert.Empty(t, got, "no commits are reachable from an ancestor but not from its descendant")
+}
+
+func TestVerifyCommit(t *testing.T) {
+	_, _, commits := commitRangeRepo(t)
+
+	good := VerifyCommit(commits[0])
+	assert.Equal(t, "Bump rancher-logging to 4.1.0-rancher.2", good.Subject)
+	require.Len(t, good.Packages, 1)
+	assert.Equal(t, "rancher-logging/4.1", good.Packages[0].FullPath)
+	require.Len(t, good.Checks, 2)
+	assert.Equal(t, CheckIDModifiedPackages, good.Checks[0].ID)
+	assert.Equal(t, CheckIDSequentialVersion, good.Checks[1].ID)
+	assert.True(t, good.Checks[1].Passed, good.Checks[1].Message)
+	assert.False(t, good.HasCriticalFailure())
+
+	broken := VerifyCommit(commits[1])
+	assert.Equal(t, "Bump rancher-logging to 4.1.0-rancher.4", broken.Subject)
+	require.Len(t, broken.Checks, 2)
+	assert.False(t, broken.Checks[1].Passed)
+	assert.Contains(t, broken.Checks[1].Message, "previous version 4.1.0-rancher.3 not found")
+	assert.True(t, broken.HasCriticalFailure())
+
+	docs := VerifyCommit(commits[2])
+	assert.Empty(t, docs.Packages)
+	require.Len(t, docs.Checks, 1)
+	assert.Equal(t, "No packages modified in this commit (nothing to verify)", docs.Checks[0].Message)
+}
+
+func TestVerifyCommits(t *testing.T) {
+	m, base, commits := commitRangeRepo(t)
+
+	opts := Options{
+		CommitRange: base.Hash.String() + "..HEAD",
+		Format:      OutputFormatJSON,
+		OutputFile:  filepath.Join(t.TempDir(), "result.json"),
+	}
+	result, err := VerifyCommits(m.dir, opts)
+	require.NoError(t, err)
+	assert.False(t, result.Success, "the commit skipping a release should fail verification")
+	require.Len(t, result.CommitResults, 3)
+	assert.Equal(t, commits[1].Hash.String(), result.CommitResults[1].Commit)
+
+	passed, failed, warnings := result.CountResults()
+	assert.Equal(t, 4, passed)
+	assert.Equal(t, 1, failed)
+	assert.Equal(t, 0, warnings)
+
+	_, err = VerifyCommits(m.dir, Options{})
+	assert.Error(t, err, "a range or per-commit verification is required")
+}
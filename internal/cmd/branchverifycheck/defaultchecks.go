@@ -0,0 +1,200 @@
+package branchverifycheck
+
+import (
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// IDs of the built-in checks, as accepted by --only and --skip.
+const (
+	CheckIDGitRepo           = "git-repo"
+	CheckIDUpstreamRemote    = "upstream-remote"
+	CheckIDFeatureBranch     = "feature-branch"
+	CheckIDGitRefs           = "git-refs"
+	CheckIDBranchCurrent     = "branch-current"
+	CheckIDModifiedPackages  = "modified-packages"
+	CheckIDSequentialVersion = "sequential-version"
+	CheckIDChartBuilt        = "chart-built"
+	CheckIDRepoClean         = "repo-clean"
+	CheckIDBuildNoChanges    = "build-no-changes"
+	CheckIDPackageImages     = "package-images"
+	CheckIDSubchartTags      = "subchart-tags"
+)
+
+// DefaultRegistry returns the built-in checks:
+//   - Basic checks: is path git repo, is upstream containing `ob-team-charts`, is on branch, is branch not main
+//   - Verify branch only modifies a single Package (soft-fail/warning)
+//   - Verify that the package+chart created is sequential to the last built chart (hard fail)
+//   - If chart build scripts are run targeting the modified package, no uncommitted changes are found (hard fail);
+//     builds run in a throwaway worktree of HEAD, so uncommitted changes in the checkout only warn
+//   - Package images follow the image policy and subchart image tags match their appVersion (soft-fail/warning)
+func DefaultRegistry() *Registry {
+	r := NewRegistry()
+	r.MustRegister(
+		NewCheck(CheckSpec{
+			ID:          CheckIDGitRepo,
+			Description: "Checking if path is a git repository",
+			Scope:       ScopeGlobal,
+			Severity:    SeverityCritical,
+		}, func(env *CheckEnv, _ PackageInfo) CheckResult {
+			check := CheckIsGitRepo(env.Path)
+			if !check.Passed {
+				env.fail(fmt.Errorf("path is not a git repository"))
+				return check
+			}
+			repo, err := git.PlainOpen(env.Path)
+			if err != nil {
+				env.fail(fmt.Errorf("failed to open git repo: %w", err))
+				check.Passed = false
+				check.Message = fmt.Sprintf("Failed to open git repo: %v", err)
+				return check
+			}
+			env.Repo = repo
+			return check
+		}),
+		NewCheck(CheckSpec{
+			ID:           CheckIDUpstreamRemote,
+			Description:  "Checking upstream repository",
+			Scope:        ScopeGlobal,
+			Severity:     SeverityCritical,
+			Dependencies: []string{CheckIDGitRepo},
+		}, func(env *CheckEnv, _ PackageInfo) CheckResult {
+			if env.Options.BaseRef != "" {
+				return CheckResult{
+					Name:     "Upstream Repository",
+					Passed:   true,
+					Critical: true,
+					Message:  fmt.Sprintf("Not required when verifying against base ref '%s'", env.Options.BaseRef),
+				}
+			}
+			return CheckHasUpstreamRemote(env.Repo, env.Options.upstreamURL())
+		}),
+		NewCheck(CheckSpec{
+			ID:           CheckIDFeatureBranch,
+			Description:  "Checking branch status",
+			Scope:        ScopeGlobal,
+			Severity:     SeverityCritical,
+			Dependencies: []string{CheckIDGitRepo},
+		}, func(env *CheckEnv, _ PackageInfo) CheckResult {
+			branchName, check := CheckOnFeatureBranch(env.Repo)
+			env.BranchName = branchName
+			return check
+		}),
+		NewCheck(CheckSpec{
+			ID:           CheckIDGitRefs,
+			Description:  "Resolving upstream and merge base",
+			Scope:        ScopeGlobal,
+			Severity:     SeverityCritical,
+			Dependencies: []string{CheckIDGitRepo},
+		}, func(env *CheckEnv, _ PackageInfo) CheckResult {
+			check := CheckResult{Name: "Git References", Critical: true}
+			refs, err := GetGitRefs(env.Repo, env.Path, env.Options)
+			if err != nil {
+				env.fail(err)
+				check.Message = fmt.Sprintf("Failed to get git refs: %v", err)
+				return check
+			}
+			env.Refs = refs
+			check.Passed = true
+			check.Message = fmt.Sprintf("Merge base with %s is %s", refs.upstreamName(), refs.MergeBaseCommit.Hash.String()[:7])
+			return check
+		}),
+		NewCheck(CheckSpec{
+			ID:           CheckIDBranchCurrent,
+			Description:  "Checking if branch is current with upstream",
+			Scope:        ScopeGlobal,
+			Severity:     SeverityWarning,
+			Dependencies: []string{CheckIDGitRefs},
+		}, func(env *CheckEnv, _ PackageInfo) CheckResult {
+			_, check := CheckBranchCurrent(env.Refs, env.Repo)
+			return check
+		}),
+		NewCheck(CheckSpec{
+			ID:           CheckIDModifiedPackages,
+			Description:  "Finding modified packages",
+			Scope:        ScopeGlobal,
+			Severity:     SeverityWarning,
+			Dependencies: []string{CheckIDGitRefs},
+		}, func(env *CheckEnv, _ PackageInfo) CheckResult {
+			// First find package indexes to make finding modified packages easier
+			packages, check := FindModifiedPackages(FindAllPackages(env.Path), env.Refs)
+			env.Packages = packages
+			return check
+		}),
+		NewCheck(CheckSpec{
+			ID:           CheckIDSequentialVersion,
+			Description:  "Checking sequential version",
+			Scope:        ScopePackage,
+			Severity:     SeverityCritical,
+			Dependencies: []string{CheckIDModifiedPackages},
+		}, func(env *CheckEnv, pkg PackageInfo) CheckResult {
+			return CheckSequentialVersion(env.Path, pkg)
+		}),
+		NewCheck(CheckSpec{
+			ID:           CheckIDChartBuilt,
+			Description:  "Checking chart built",
+			Scope:        ScopePackage,
+			Severity:     SeverityCritical,
+			Dependencies: []string{CheckIDModifiedPackages},
+		}, func(env *CheckEnv, pkg PackageInfo) CheckResult {
+			return CheckChartBuilt(env.Path, pkg)
+		}),
+		NewCheck(CheckSpec{
+			ID:           CheckIDRepoClean,
+			Description:  "Checking repository is clean",
+			Scope:        ScopeGlobal,
+			Severity:     SeverityWarning,
+			Dependencies: []string{CheckIDGitRepo},
+		}, func(env *CheckEnv, _ PackageInfo) CheckResult {
+			check := CheckRepoClean(env.Repo)
+			if !check.Passed && !env.Options.InPlace {
+				// Isolated checks run in worktrees of HEAD, so uncommitted changes are not verified
+				check.Message = "Repository has uncommitted changes; they are not verified because builds run in worktrees of HEAD"
+			}
+			return check
+		}),
+		NewCheck(CheckSpec{
+			ID:           CheckIDBuildNoChanges,
+			Description:  "Running build check (this may take a while)",
+			Scope:        ScopePackage,
+			Severity:     SeverityCritical,
+			Dependencies: []string{CheckIDModifiedPackages},
+			Isolated:     true,
+		}, func(env *CheckEnv, pkg PackageInfo) CheckResult {
+			if !env.Isolated {
+				// The build runs in the checkout, which must be clean to attribute changes to it
+				if check := CheckRepoClean(env.Repo); !check.Passed {
+					check.Name = fmt.Sprintf("Build Check (%s)", pkg.FullPath)
+					return check
+				}
+			}
+			return CheckBuildNoChanges(env.Path, pkg)
+		}),
+		NewCheck(CheckSpec{
+			ID:           CheckIDPackageImages,
+			Description:  "Running package image check",
+			Scope:        ScopePackage,
+			Severity:     SeverityWarning,
+			Dependencies: []string{CheckIDChartBuilt},
+			Isolated:     true,
+		}, func(env *CheckEnv, pkg PackageInfo) CheckResult {
+			policy := env.Options.ImagePolicy
+			if policy == nil {
+				policy = DefaultImagePolicy()
+			}
+			return CheckPackageImagesWithPolicy(env.Path, pkg, policy)
+		}),
+		NewCheck(CheckSpec{
+			ID:           CheckIDSubchartTags,
+			Description:  "Checking subchart appVersion tags",
+			Scope:        ScopePackage,
+			Severity:     SeverityWarning,
+			Dependencies: []string{CheckIDChartBuilt},
+			Isolated:     true,
+		}, func(env *CheckEnv, pkg PackageInfo) CheckResult {
+			return CheckSubchartAppVersionTags(env.Path, pkg)
+		}),
+	)
+	return r
+}
@@ -0,0 +1,161 @@
+package branchverifycheck
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Kinds of the built-in CheckDetails, used as the "kind" of their JSON envelope.
+const (
+	DetailsKindBuildDiff   = "build-diff"
+	DetailsKindImageCheck  = "image-check"
+	DetailsKindSubchartTag = "subchart-tag"
+)
+
+// verificationResultSchema is the JSON Schema of the JSON output of VerificationResult.
+//
+//go:embed verification-result.schema.json
+var verificationResultSchema []byte
+
+// JSONSchema returns the JSON Schema describing VerificationResult as written by WriteResult
+// with OutputFormatJSON and read by ReadResult.
+func JSONSchema() []byte {
+	return bytes.Clone(verificationResultSchema)
+}
+
+var (
+	detailsKindsMu sync.RWMutex
+	// detailsKinds maps each details kind to a constructor used when unmarshalling
+	detailsKinds = map[string]func() CheckDetails{
+		DetailsKindBuildDiff:   func() CheckDetails { return &BuildDiffDetails{} },
+		DetailsKindImageCheck:  func() CheckDetails { return &ImageCheckDetails{} },
+		DetailsKindSubchartTag: func() CheckDetails { return &SubchartTagCheckDetails{} },
+	}
+)
+
+// RegisterDetailsKind makes the details of a custom check unmarshallable. newDetails must
+// return a pointer whose Kind is kind; unregistered kinds are read as *RawDetails.
+func RegisterDetailsKind(kind string, newDetails func() CheckDetails) error {
+	if kind == "" {
+		return fmt.Errorf("details kind cannot be empty")
+	}
+	detailsKindsMu.Lock()
+	defer detailsKindsMu.Unlock()
+	if _, exists := detailsKinds[kind]; exists {
+		return fmt.Errorf("details kind %q is already registered", kind)
+	}
+	detailsKinds[kind] = newDetails
+	return nil
+}
+
+// DetailsEnvelope is the JSON form of CheckDetails: the details' kind and, as data, the
+// details themselves.
+type DetailsEnvelope struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+// RawDetails holds details of a kind that is not registered, so results written by newer
+// tools still round-trip and render.
+type RawDetails struct {
+	DetailsKind string
+	Data        json.RawMessage
+}
+
+// Kind returns the kind the details were written with.
+func (d *RawDetails) Kind() string {
+	return d.DetailsKind
+}
+
+// Format returns the details as indented JSON.
+func (d *RawDetails) Format() string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, d.Data, "", "  "); err != nil {
+		return string(d.Data)
+	}
+	return buf.String()
+}
+
+// MarshalJSON writes the details as their raw data.
+func (d *RawDetails) MarshalJSON() ([]byte, error) {
+	if len(d.Data) == 0 {
+		return []byte("null"), nil
+	}
+	return d.Data, nil
+}
+
+// checkResultJSON is CheckResult without its methods, with Details replaced by its envelope.
+type checkResultJSON struct {
+	ID       string           `json:"id,omitempty"`
+	Name     string           `json:"name"`
+	Passed   bool             `json:"passed"`
+	Message  string           `json:"message"`
+	Critical bool             `json:"critical"`
+	Details  *DetailsEnvelope `json:"details,omitempty"`
+}
+
+// MarshalJSON writes Details wrapped in a DetailsEnvelope so consumers can tell the
+// details types apart.
+func (c CheckResult) MarshalJSON() ([]byte, error) {
+	out := checkResultJSON{
+		ID:       c.ID,
+		Name:     c.Name,
+		Passed:   c.Passed,
+		Message:  c.Message,
+		Critical: c.Critical,
+	}
+	if c.Details != nil {
+		data, err := json.Marshal(c.Details)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s details: %w", c.Details.Kind(), err)
+		}
+		out.Details = &DetailsEnvelope{Kind: c.Details.Kind(), Data: data}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON reads Details from its DetailsEnvelope into the type registered for its kind.
+func (c *CheckResult) UnmarshalJSON(data []byte) error {
+	var in checkResultJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*c = CheckResult{
+		ID:       in.ID,
+		Name:     in.Name,
+		Passed:   in.Passed,
+		Message:  in.Message,
+		Critical: in.Critical,
+	}
+	if in.Details == nil {
+		return nil
+	}
+
+	detailsKindsMu.RLock()
+	newDetails, ok := detailsKinds[in.Details.Kind]
+	detailsKindsMu.RUnlock()
+	if !ok {
+		c.Details = &RawDetails{DetailsKind: in.Details.Kind, Data: in.Details.Data}
+		return nil
+	}
+	details := newDetails()
+	if err := json.Unmarshal(in.Details.Data, details); err != nil {
+		return fmt.Errorf("failed to unmarshal %s details: %w", in.Details.Kind, err)
+	}
+	c.Details = details
+	return nil
+}
+
+// ReadResult reads a VerificationResult saved as JSON, e.g. with --format json, so it can
+// be re-rendered with WriteResult or OutputHuman.
+func ReadResult(r io.Reader) (*VerificationResult, error) {
+	var result VerificationResult
+	if err := json.NewDecoder(r).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to read verification result: %w", err)
+	}
+	return &result, nil
+}
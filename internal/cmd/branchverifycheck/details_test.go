@@ -0,0 +1,112 @@
+package branchverifycheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func detailsTestResult() *VerificationResult {
+	result := reporterTestResult()
+	result.Branch = "feature"
+	result.AddGlobalCheck(CheckResult{
+		ID:       "build",
+		Name:     "Build",
+		Message:  "Build produced changes",
+		Critical: true,
+		Details:  &BuildDiffDetails{ModifiedFiles: []string{"charts/foo/values.yaml"}, Diff: "+foo"},
+	})
+	result.CommitResults = []CommitResult{{
+		Commit:   "0123456789abcdef0123456789abcdef01234567",
+		Subject:  "Bump rancher-monitoring",
+		Packages: []PackageInfo{{FullPath: "rancher-monitoring/77.9", Name: "rancher-monitoring", VersionDir: "77.9"}},
+		Checks:   []CheckResult{{ID: "sequential-version", Name: "Sequential Version", Passed: true, Critical: true}},
+	}}
+	return result
+}
+
+func TestCheckResult_JSONEnvelope(t *testing.T) {
+	data, err := json.Marshal(CheckResult{
+		Name:    "Build",
+		Details: &BuildDiffDetails{ModifiedFiles: []string{"a"}, Diff: "+a"},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"name": "Build", "passed": false, "message": "", "critical": false,
+		"details": {"kind": "build-diff", "data": {"modifiedFiles": ["a"], "diff": "+a"}}
+	}`, string(data))
+
+	data, err = json.Marshal(CheckResult{Name: "Git", Passed: true})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "details")
+}
+
+func TestReadResult_RoundTrip(t *testing.T) {
+	want := detailsTestResult()
+	var buf bytes.Buffer
+	require.NoError(t, WriteResult(&buf, want, OutputFormatJSON))
+
+	got, err := ReadResult(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	assert.IsType(t, &ImageCheckDetails{}, got.PackageResults[0].Checks[0].Details)
+	assert.IsType(t, &SubchartTagCheckDetails{}, got.PackageResults[0].Checks[1].Details)
+}
+
+func TestReadResult_UnknownKind(t *testing.T) {
+	result, err := ReadResult(bytes.NewBufferString(`{
+		"success": true, "globalChecks": [
+			{"name": "Custom", "passed": false, "message": "m", "critical": false,
+			 "details": {"kind": "custom", "data": {"count": 2}}}
+		], "packageResults": []
+	}`))
+	require.NoError(t, err)
+	details, ok := result.GlobalChecks[0].Details.(*RawDetails)
+	require.True(t, ok, "unregistered kinds should be read as *RawDetails")
+	assert.Equal(t, "custom", details.Kind())
+	assert.Equal(t, "{\n  \"count\": 2\n}", details.Format())
+
+	data, err := json.Marshal(result.GlobalChecks[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"details":{"kind":"custom","data":{"count":2}}`)
+}
+
+func TestReadResult_InvalidDetails(t *testing.T) {
+	_, err := ReadResult(bytes.NewBufferString(`{"globalChecks": [
+		{"name": "Build", "details": {"kind": "build-diff", "data": {"modifiedFiles": "not a list"}}}
+	]}`))
+	assert.ErrorContains(t, err, "failed to unmarshal build-diff details")
+}
+
+func TestRegisterDetailsKind(t *testing.T) {
+	assert.ErrorContains(t, RegisterDetailsKind(DetailsKindBuildDiff, func() CheckDetails { return &BuildDiffDetails{} }), "already registered")
+	assert.ErrorContains(t, RegisterDetailsKind("", nil), "cannot be empty")
+}
+
+func TestJSONSchema_ValidatesOutput(t *testing.T) {
+	schemaDoc, err := jsonschema.UnmarshalJSON(bytes.NewReader(JSONSchema()))
+	require.NoError(t, err)
+	compiler := jsonschema.NewCompiler()
+	require.NoError(t, compiler.AddResource("verification-result.schema.json", schemaDoc))
+	schema, err := compiler.Compile("verification-result.schema.json")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteResult(&buf, detailsTestResult(), OutputFormatJSON))
+	output, err := jsonschema.UnmarshalJSON(&buf)
+	require.NoError(t, err)
+	assert.NoError(t, schema.Validate(output))
+
+	invalid, err := jsonschema.UnmarshalJSON(bytes.NewBufferString(`{
+		"success": false, "globalChecks": [
+			{"name": "Build", "passed": false, "message": "", "critical": true,
+			 "details": {"kind": "build-diff", "data": {"diff": 3}}}
+		], "packageResults": []
+	}`))
+	require.NoError(t, err)
+	assert.Error(t, schema.Validate(invalid))
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/rancher/ob-charts-tool/internal/git/remote"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -326,3 +327,90 @@ func TestCountCommitsBehind(t *testing.T) {
 		})
 	}
 }
+
+// =============================================================================
+// TestGetGitRefs_Offline
+// =============================================================================
+
+// TestGetGitRefs_Offline verifies that --base and --no-fetch resolve the upstream
+// from local refs only. The feature branch forks from base, and origin/main has
+// one more commit than base.
+func TestGetGitRefs_Offline(t *testing.T) {
+	setup := func(t *testing.T) (m *mockRepo, base, upstream, head *object.Commit) {
+		t.Helper()
+		m = newMockRepo(t)
+		base = m.commit(t, map[string]string{"README.md": "# base\n"}, "shared base")
+		upstream = m.commit(t, map[string]string{"a.txt": "1\n"}, "upstream commit")
+		m.makeRef(t, "refs/remotes/origin/main", upstream)
+		addGitRemote(t, m.repo, "origin", "git@github.com:rancher/ob-team-charts.git")
+
+		require.NoError(t, m.wt.Checkout(&git.CheckoutOptions{
+			Branch: plumbing.NewBranchReferenceName("my-feature"),
+			Hash:   base.Hash,
+			Create: true,
+		}))
+		head = m.commit(t, map[string]string{"b.txt": "2\n"}, "feature commit")
+		return m, base, upstream, head
+	}
+
+	cases := []struct {
+		name     string
+		opts     func(upstream *object.Commit) Options
+		wantName string
+	}{
+		{"base remote-tracking branch", func(*object.Commit) Options { return Options{BaseRef: "origin/main"} }, "origin/main"},
+		{"base full ref name", func(*object.Commit) Options { return Options{BaseRef: "refs/remotes/origin/main"} }, "refs/remotes/origin/main"},
+		{"base commit hash", func(c *object.Commit) Options { return Options{BaseRef: c.Hash.String()} }, ""},
+		{"no fetch", func(*object.Commit) Options { return Options{NoFetch: true} }, "origin/main"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, base, upstream, head := setup(t)
+			opts := tc.opts(upstream)
+
+			refs, err := GetGitRefs(m.repo, m.dir, opts)
+			require.NoError(t, err)
+			assert.Equal(t, head.Hash, refs.HeadCommit.Hash)
+			assert.Equal(t, upstream.Hash, refs.UpstreamCommit.Hash)
+			assert.Equal(t, base.Hash, refs.MergeBaseCommit.Hash)
+			wantName := tc.wantName
+			if wantName == "" {
+				wantName = opts.BaseRef
+			}
+			assert.Equal(t, wantName, refs.UpstreamName)
+
+			_, err = m.repo.Remote(remote.ToolRemoteName)
+			assert.ErrorIs(t, err, git.ErrRemoteNotFound, "no tool remote should be created")
+		})
+	}
+
+	t.Run("base local branch", func(t *testing.T) {
+		m, base, _, _ := setup(t)
+		refs, err := GetGitRefs(m.repo, m.dir, Options{BaseRef: "master"})
+		require.NoError(t, err)
+		// master is at the upstream commit, whose merge base with the feature is base
+		assert.Equal(t, base.Hash, refs.MergeBaseCommit.Hash)
+		assert.Equal(t, plumbing.NewBranchReferenceName("master"), refs.UpstreamRef.Name())
+	})
+
+	t.Run("unknown base ref", func(t *testing.T) {
+		m, _, _, _ := setup(t)
+		_, err := GetGitRefs(m.repo, m.dir, Options{BaseRef: "does-not-exist"})
+		assert.ErrorContains(t, err, "failed to resolve base ref 'does-not-exist'")
+	})
+
+	t.Run("no fetch with a different upstream URL", func(t *testing.T) {
+		m, _, _, _ := setup(t)
+		_, err := GetGitRefs(m.repo, m.dir, Options{NoFetch: true, UpstreamURL: "https://github.com/example/charts.git"})
+		assert.ErrorContains(t, err, "no remote points to https://github.com/example/charts.git")
+	})
+
+	t.Run("no fetch with a fork upstream URL", func(t *testing.T) {
+		m, _, upstream, _ := setup(t)
+		addGitRemote(t, m.repo, "fork", "https://git.example.com/team/charts")
+		m.makeRef(t, "refs/remotes/fork/master", upstream)
+		refs, err := GetGitRefs(m.repo, m.dir, Options{NoFetch: true, UpstreamURL: "https://git.example.com/team/charts.git"})
+		require.NoError(t, err)
+		assert.Equal(t, "fork/master", refs.UpstreamName)
+	})
+}
@@ -1,8 +1,11 @@
 package branchverifycheck
 
 import (
-	"errors"
 	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
@@ -12,22 +15,43 @@ import (
 )
 
 const (
-	// CanonicalUpstreamURL is the source of truth for the upstream repository
+	// CanonicalUpstreamURL is the default source of truth for the upstream repository;
+	// Options.UpstreamURL overrides it, e.g. for forks with a different canonical repo
 	CanonicalUpstreamURL = "https://github.com/rancher/ob-team-charts.git"
 )
 
-// findExistingCanonicalRemote checks if the repo already has a remote pointing to canonical upstream.
+// isUpstreamURL reports whether a remote URL points to upstreamURL. GitHub URLs are
+// compared by owner/repo so that SSH and HTTPS remotes match; other URLs must be equal
+// apart from a ".git" suffix.
+func isUpstreamURL(url, upstreamURL string) bool {
+	if repoPath := gitpkg.ExtractGitHubRepoPath(upstreamURL); repoPath != "" {
+		owner, name, _ := strings.Cut(repoPath, "/")
+		return gitpkg.IsGitHubRepoURL(url, owner, name)
+	}
+	return strings.TrimSuffix(url, ".git") == strings.TrimSuffix(upstreamURL, ".git")
+}
+
+// findUpstreamRemote checks if the repo already has a remote pointing to upstreamURL.
 // Returns the remote name if found, empty string otherwise.
-func findExistingCanonicalRemote(repo *git.Repository) string {
+func findUpstreamRemote(repo *git.Repository, upstreamURL string) string {
 	remotes, err := gitpkg.GetRemoteURLs(repo)
 	if err != nil {
 		return ""
 	}
+	return upstreamRemoteName(remotes, upstreamURL)
+}
 
-	for remoteName, urls := range remotes {
-		for _, url := range urls {
-			if gitpkg.IsGitHubRepoURL(url, "rancher", "ob-team-charts") {
-				log.Log.Debugf("Found existing canonical remote '%s' with URL '%s'", remoteName, url)
+// upstreamRemoteName returns the first remote in alphabetical order, ignoring the tool
+// remote, with a URL pointing to upstreamURL, or an empty string if there is none.
+func upstreamRemoteName(remotes map[string][]string, upstreamURL string) string {
+	names := slices.Sorted(maps.Keys(remotes))
+	for _, remoteName := range names {
+		if remoteName == remote.ToolRemoteName {
+			continue
+		}
+		for _, url := range remotes[remoteName] {
+			if isUpstreamURL(url, upstreamURL) {
+				log.Log.Debugf("Found existing upstream remote '%s' with URL '%s'", remoteName, url)
 				return remoteName
 			}
 		}
@@ -39,16 +63,16 @@ func findExistingCanonicalRemote(repo *git.Repository) string {
 // EnsureUpstreamRemote ensures we have access to upstream state.
 // It creates a tool-owned remote with HTTPS URL to avoid SSH authentication issues.
 // Returns the reference to upstream/main after fetching.
-func EnsureUpstreamRemote(repo *git.Repository) (*plumbing.Reference, error) {
-	// Check if an existing remote points to canonical upstream (for logging purposes)
-	existingRemote := findExistingCanonicalRemote(repo)
+func EnsureUpstreamRemote(repo *git.Repository, upstreamURL string) (*plumbing.Reference, error) {
+	// Check if an existing remote points to upstream (for logging purposes)
+	existingRemote := findUpstreamRemote(repo, upstreamURL)
 	if existingRemote != "" {
-		log.Log.Debugf("Found existing canonical remote '%s', but using tool remote for reliable HTTPS fetch", existingRemote)
+		log.Log.Debugf("Found existing upstream remote '%s', but using tool remote for reliable HTTPS fetch", existingRemote)
 	}
 
 	// Always use our tool remote with HTTPS URL to avoid SSH auth issues
 	log.Log.Debugf("Setting up tool remote for upstream fetch")
-	_, err := remote.FetchFromURL(repo, CanonicalUpstreamURL)
+	_, err := remote.FetchFromURL(repo, upstreamURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch from upstream: %w", err)
 	}
@@ -63,6 +87,44 @@ func EnsureUpstreamRemote(repo *git.Repository) (*plumbing.Reference, error) {
 	return ref, nil
 }
 
+// LocalUpstreamRef returns the main (or master) branch of an existing remote pointing
+// to upstreamURL as last fetched, without any network access.
+func LocalUpstreamRef(repo *git.Repository, upstreamURL string) (*plumbing.Reference, error) {
+	remoteName := findUpstreamRemote(repo, upstreamURL)
+	if remoteName == "" {
+		return nil, fmt.Errorf("no remote points to %s; add one or use a base ref", upstreamURL)
+	}
+
+	ref, err := remote.GetRemoteRef(repo, remoteName, "main", "master")
+	if err != nil {
+		return nil, fmt.Errorf("could not find a fetched main/master branch: %w", err)
+	}
+
+	log.Log.Debugf("Using ref '%s' as upstream reference without fetching", ref.Name())
+	return ref, nil
+}
+
+// ResolveBaseRef resolves a local branch, remote-tracking branch, tag or revision
+// (e.g. "release-v2.12", "origin/main", "v1.0.0" or a commit hash) to a reference.
+func ResolveBaseRef(repo *git.Repository, baseRef string) (*plumbing.Reference, error) {
+	for _, name := range []plumbing.ReferenceName{
+		plumbing.ReferenceName(baseRef),
+		plumbing.NewBranchReferenceName(baseRef),
+		plumbing.ReferenceName("refs/remotes/" + baseRef),
+		plumbing.NewTagReferenceName(baseRef),
+	} {
+		if ref, err := repo.Reference(name, true); err == nil {
+			return ref, nil
+		}
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(baseRef))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve base ref '%s': %w", baseRef, err)
+	}
+	return plumbing.NewHashReference(plumbing.ReferenceName(baseRef), *hash), nil
+}
+
 // CleanupToolRemote removes the temporary tool-created remote if it was created.
 func CleanupToolRemote(repo *git.Repository) {
 	log.Log.Debugf("Cleaning up tool remote '%s'", remote.ToolRemoteName)
@@ -70,9 +132,14 @@ func CleanupToolRemote(repo *git.Repository) {
 }
 
 // GetGitRefs retrieves all the git references needed for verification.
-// It ensures the upstream remote exists and is up-to-date, then returns
-// HEAD, upstream, and merge-base references.
-func GetGitRefs(repo *git.Repository, repoPath string) (*GitRefs, error) {
+// The upstream is resolved according to opts:
+//   - with BaseRef set, the given local ref is used and nothing is fetched
+//   - with NoFetch set, the main branch of an existing remote pointing to the upstream
+//     URL is used as last fetched
+//   - otherwise the upstream URL is fetched into a tool remote and its main branch used
+//
+// It returns HEAD, upstream, and merge-base references.
+func GetGitRefs(repo *git.Repository, repoPath string, opts Options) (*GitRefs, error) {
 	refs := &GitRefs{}
 
 	// Get HEAD reference
@@ -89,21 +156,40 @@ func GetGitRefs(repo *git.Repository, repoPath string) (*GitRefs, error) {
 	}
 	refs.HeadCommit = headCommit
 
-	// Ensure upstream remote and get reference
-	upstreamRef, err := EnsureUpstreamRemote(repo)
-	if err != nil {
-		return nil, fmt.Errorf("failed to ensure upstream remote: %w", err)
-	}
-	refs.UpstreamRef = upstreamRef
+	upstreamURL := opts.upstreamURL()
+	switch {
+	case opts.BaseRef != "":
+		upstreamRef, err := ResolveBaseRef(repo, opts.BaseRef)
+		if err != nil {
+			return nil, err
+		}
+		refs.UpstreamRef = upstreamRef
+		refs.UpstreamName = opts.BaseRef
+	case opts.NoFetch:
+		upstreamRef, err := LocalUpstreamRef(repo, upstreamURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find upstream without fetching: %w", err)
+		}
+		refs.UpstreamRef = upstreamRef
+		refs.UpstreamName = upstreamRef.Name().Short()
+	default:
+		// Ensure upstream remote and get reference
+		upstreamRef, err := EnsureUpstreamRemote(repo, upstreamURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to ensure upstream remote: %w", err)
+		}
+		refs.UpstreamRef = upstreamRef
+		refs.UpstreamName = "upstream/" + path.Base(upstreamRef.Name().Short())
 
-	// Re-open repo to see the new refs (go-git caches refs)
-	repo, err = git.PlainOpen(repoPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to reopen repo: %w", err)
+		// Re-open repo to see the new refs (go-git caches refs)
+		repo, err = git.PlainOpen(repoPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reopen repo: %w", err)
+		}
 	}
 
 	// Get upstream commit
-	upstreamCommit, err := repo.CommitObject(upstreamRef.Hash())
+	upstreamCommit, err := repo.CommitObject(refs.UpstreamRef.Hash())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get upstream commit: %w", err)
 	}
@@ -116,7 +202,7 @@ func GetGitRefs(repo *git.Repository, repoPath string) (*GitRefs, error) {
 	}
 
 	if len(mergeBaseCommits) == 0 {
-		return nil, errors.New("no common ancestor found between branch and upstream/main")
+		return nil, fmt.Errorf("no common ancestor found between branch and %s", refs.upstreamName())
 	}
 
 	refs.MergeBaseCommit = mergeBaseCommits[0]
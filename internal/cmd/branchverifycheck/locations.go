@@ -0,0 +1,73 @@
+package branchverifycheck
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// indexSuffixPattern matches the "[n]" list indexes that findInvalidImages appends to a path segment.
+var indexSuffixPattern = regexp.MustCompile(`\[(\d+)\]`)
+
+// sourceLocation returns the location of path relative to repoPath, so reporters can
+// annotate it regardless of which worktree the check ran in.
+func sourceLocation(repoPath, path string, line int) *SourceLocation {
+	rel, err := filepath.Rel(repoPath, path)
+	if err != nil {
+		rel = path
+	}
+	return &SourceLocation{File: filepath.ToSlash(rel), Line: line}
+}
+
+// valuesLine returns the 1-based line of the value at a dotted values path such as
+// "prometheus.image" or "containers[0].image" in a values.yaml, or 0 if it is not found.
+func valuesLine(data []byte, path string) int {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil || len(root.Content) == 0 {
+		return 0
+	}
+
+	node := root.Content[0]
+	line := node.Line
+	for _, segment := range strings.Split(path, ".") {
+		key := segment
+		var indexes []int
+		if loc := indexSuffixPattern.FindStringIndex(segment); loc != nil {
+			key = segment[:loc[0]]
+			for _, match := range indexSuffixPattern.FindAllStringSubmatch(segment[loc[0]:], -1) {
+				idx, _ := strconv.Atoi(match[1])
+				indexes = append(indexes, idx)
+			}
+		}
+
+		if key != "" {
+			if node, line = mappingValue(node, key); node == nil {
+				return 0
+			}
+		}
+		for _, idx := range indexes {
+			if node.Kind != yaml.SequenceNode || idx >= len(node.Content) {
+				return 0
+			}
+			node = node.Content[idx]
+			line = node.Line
+		}
+	}
+	return line
+}
+
+// mappingValue returns the value node of key in a mapping node along with the line of the key.
+func mappingValue(node *yaml.Node, key string) (*yaml.Node, int) {
+	if node.Kind != yaml.MappingNode {
+		return nil, 0
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1], node.Content[i].Line
+		}
+	}
+	return nil, 0
+}
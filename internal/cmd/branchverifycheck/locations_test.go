@@ -0,0 +1,42 @@
+package branchverifycheck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValuesLine(t *testing.T) {
+	data := []byte(`global:
+  registry: ""
+prometheus:
+  image:
+    repository: rancher/prometheus
+    tag: v1
+sidecars:
+  - name: reloader
+    image:
+      repository: rancher/reloader
+      tag: v2
+`)
+	cases := []struct {
+		path string
+		want int
+	}{
+		{"global", 1},
+		{"prometheus.image", 4},
+		{"prometheus.image.tag", 6},
+		{"sidecars[0]", 8},
+		{"sidecars[0].image", 9},
+		{"sidecars[1].image", 0},
+		{"missing.key", 0},
+		{"global.registry.nested", 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			assert.Equal(t, tc.want, valuesLine(data, tc.path))
+		})
+	}
+
+	assert.Zero(t, valuesLine([]byte("not: [valid"), "not"))
+}
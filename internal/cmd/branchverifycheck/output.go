@@ -1,8 +1,11 @@
 package branchverifycheck
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"strings"
 
 	log "github.com/rancher/ob-charts-tool/internal/logging"
@@ -12,31 +15,38 @@ import (
 // When jsonOutput is true, progress messages are suppressed.
 type ProgressPrinter struct {
 	jsonOutput bool
+	out        io.Writer
 }
 
 // NewProgressPrinter creates a new progress printer.
 func NewProgressPrinter(jsonOutput bool) *ProgressPrinter {
-	return &ProgressPrinter{jsonOutput: jsonOutput}
+	return &ProgressPrinter{jsonOutput: jsonOutput, out: os.Stdout}
+}
+
+// buffered returns a printer with the same settings that writes to buf, so the
+// progress of checks running concurrently can be printed in one piece.
+func (p *ProgressPrinter) buffered(buf *bytes.Buffer) *ProgressPrinter {
+	return &ProgressPrinter{jsonOutput: p.jsonOutput, out: buf}
 }
 
 // Print prints a progress message without newline (only in human mode).
 func (p *ProgressPrinter) Print(msg string) {
 	if !p.jsonOutput {
-		fmt.Print(msg)
+		fmt.Fprint(p.out, msg)
 	}
 }
 
 // Println prints a progress message with newline (only in human mode).
 func (p *ProgressPrinter) Println(msg string) {
 	if !p.jsonOutput {
-		fmt.Println(msg)
+		fmt.Fprintln(p.out, msg)
 	}
 }
 
 // Printf prints a formatted progress message without newline (only in human mode).
 func (p *ProgressPrinter) Printf(format string, args ...interface{}) {
 	if !p.jsonOutput {
-		fmt.Printf(format, args...)
+		fmt.Fprintf(p.out, format, args...)
 	}
 }
 
@@ -55,9 +65,22 @@ func OutputHuman(result *VerificationResult, branchName string) {
 	fmt.Printf("\n=== Branch Verification Results for '%s' ===\n", branchName)
 
 	// Global checks section
-	fmt.Printf("\n--- Global Checks ---\n\n")
-	for _, check := range result.GlobalChecks {
-		printCheck(check)
+	if len(result.GlobalChecks) > 0 {
+		fmt.Printf("\n--- Global Checks ---\n\n")
+		for _, check := range result.GlobalChecks {
+			printCheck(check)
+		}
+	}
+
+	// Per-commit checks section
+	if len(result.CommitResults) > 0 {
+		fmt.Printf("\n--- Commit Checks ---\n")
+		for _, commitResult := range result.CommitResults {
+			fmt.Printf("\n  Commit: %s %s\n\n", commitResult.ShortCommit(), commitResult.Subject)
+			for _, check := range commitResult.Checks {
+				printCheckIndented(check, "    ")
+			}
+		}
 	}
 
 	// Per-package checks section
@@ -0,0 +1,262 @@
+package branchverifycheck
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/rancher/ob-charts-tool/helmtools/image"
+	"go.yaml.in/yaml/v3"
+)
+
+// Image policy rules. Exemptions name the rules they waive.
+const (
+	PolicyRuleRegistry     = "registry"
+	PolicyRuleRepository   = "repository"
+	PolicyRuleForbiddenTag = "forbidden-tag"
+	PolicyRuleDigest       = "digest"
+)
+
+var policyRules = []string{PolicyRuleRegistry, PolicyRuleRepository, PolicyRuleForbiddenTag, PolicyRuleDigest}
+
+// ImagePolicy declares which image definitions in a package's values.yaml files are acceptable.
+type ImagePolicy struct {
+	// AllowedRegistries lists the accepted values of an image's "registry" field.
+	// "" accepts an empty or absent registry. An empty list accepts any registry.
+	AllowedRegistries []string `yaml:"allowedRegistries" json:"allowedRegistries"`
+	// RepositoryPrefixes lists the accepted repository prefixes, e.g. "rancher/".
+	// An empty list accepts any repository.
+	RepositoryPrefixes []string `yaml:"repositoryPrefixes" json:"repositoryPrefixes"`
+	// ForbiddenTags lists tags images may not use, e.g. "latest". An empty values.yaml tag
+	// defaults to the chart appVersion and is not checked; a literal template reference
+	// without a tag or digest is checked as "latest", the tag it is pulled with.
+	ForbiddenTags []string `yaml:"forbiddenTags,omitempty" json:"forbiddenTags,omitempty"`
+	// RequireDigest requires every image to be pinned by a "digest" or "sha" field,
+	// or by an "@sha256:" suffix on its tag.
+	RequireDigest bool `yaml:"requireDigest,omitempty" json:"requireDigest,omitempty"`
+	// Exemptions waive rules for specific packages or images.
+	Exemptions []ImagePolicyExemption `yaml:"exemptions,omitempty" json:"exemptions,omitempty"`
+}
+
+// ImagePolicyExemption waives policy rules for a package, optionally narrowed to some images.
+type ImagePolicyExemption struct {
+	// Package is the package name ("rancher-monitoring") or full path ("rancher-monitoring/77.9").
+	Package string `yaml:"package" json:"package"`
+	// Path is a glob (see path.Match) matched against the reported image path,
+	// e.g. "charts/grafana/values.yaml.image". Empty matches every image.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+	// Repository is a glob matched against the image repository. Empty matches every image.
+	Repository string `yaml:"repository,omitempty" json:"repository,omitempty"`
+	// Rules lists the waived rules. Empty waives all of them.
+	Rules []string `yaml:"rules,omitempty" json:"rules,omitempty"`
+	// Justification explains why the exemption is needed; it is required.
+	Justification string `yaml:"justification" json:"justification"`
+}
+
+// DefaultImagePolicy returns the policy used when no policy file is given: images must
+// use registry="" and a "rancher/" repository, and kube-prometheus-stack is exempt.
+func DefaultImagePolicy() *ImagePolicy {
+	return &ImagePolicy{
+		AllowedRegistries:  []string{""},
+		RepositoryPrefixes: []string{"rancher/"},
+		Exemptions: []ImagePolicyExemption{
+			{
+				Package:       "kube-prometheus-stack",
+				Justification: "the package is only a slightly modified version of the upstream chart and should not have images modified at all",
+			},
+		},
+	}
+}
+
+// LoadImagePolicy reads an image policy from a YAML file. Fields the file sets replace
+// those of DefaultImagePolicy, so a file that lists exemptions replaces the default ones.
+func LoadImagePolicy(policyPath string) (*ImagePolicy, error) {
+	data, err := os.ReadFile(policyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image policy: %w", err)
+	}
+
+	policy := DefaultImagePolicy()
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(policy); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse image policy %s: %w", policyPath, err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid image policy %s: %w", policyPath, err)
+	}
+	return policy, nil
+}
+
+// Validate checks that every exemption names a package, known rules and valid globs, and is justified.
+func (p *ImagePolicy) Validate() error {
+	var errs []error
+	for i, exemption := range p.Exemptions {
+		if exemption.Package == "" {
+			errs = append(errs, fmt.Errorf("exemption %d: package is required", i))
+		}
+		if strings.TrimSpace(exemption.Justification) == "" {
+			errs = append(errs, fmt.Errorf("exemption %d (%s): justification is required", i, exemption.Package))
+		}
+		for _, rule := range exemption.Rules {
+			if !slices.Contains(policyRules, rule) {
+				errs = append(errs, fmt.Errorf("exemption %d (%s): unknown rule %q (expected one of %s)",
+					i, exemption.Package, rule, strings.Join(policyRules, ", ")))
+			}
+		}
+		for _, pattern := range []string{exemption.Path, exemption.Repository} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				errs = append(errs, fmt.Errorf("exemption %d (%s): invalid glob %q: %w", i, exemption.Package, pattern, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Summary describes what the policy requires, e.g. `use registry="" and start with "rancher/"`.
+func (p *ImagePolicy) Summary() string {
+	var parts []string
+	if len(p.AllowedRegistries) > 0 {
+		parts = append(parts, "use registry="+quoteJoin(p.AllowedRegistries, " or "))
+	}
+	if len(p.RepositoryPrefixes) > 0 {
+		parts = append(parts, "start with "+quoteJoin(p.RepositoryPrefixes, " or "))
+	}
+	if len(p.ForbiddenTags) > 0 {
+		parts = append(parts, "avoid tag "+quoteJoin(p.ForbiddenTags, ", "))
+	}
+	if p.RequireDigest {
+		parts = append(parts, "pin a digest")
+	}
+	if len(parts) == 0 {
+		return "satisfy the image policy"
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return strings.Join(parts[:len(parts)-1], ", ") + " and " + parts[len(parts)-1]
+}
+
+// forPackage returns the policy with only the exemptions that apply to pkg.
+func (p *ImagePolicy) forPackage(pkg PackageInfo) packageImagePolicy {
+	scoped := packageImagePolicy{ImagePolicy: p}
+	for _, exemption := range p.Exemptions {
+		if exemption.Package == pkg.Name || exemption.Package == pkg.FullPath {
+			scoped.exemptions = append(scoped.exemptions, exemption)
+		}
+	}
+	return scoped
+}
+
+// packageImagePolicy is an ImagePolicy applied to a single package.
+type packageImagePolicy struct {
+	*ImagePolicy
+	exemptions []ImagePolicyExemption
+}
+
+// packageExemption returns the exemption that waives every rule for every image of the package, if any.
+func (p packageImagePolicy) packageExemption() (ImagePolicyExemption, bool) {
+	for _, exemption := range p.exemptions {
+		if exemption.Path == "" && exemption.Repository == "" && len(exemption.Rules) == 0 {
+			return exemption, true
+		}
+	}
+	return ImagePolicyExemption{}, false
+}
+
+// exemption returns the exemption that waives rule for the image at imagePath, if any.
+func (p packageImagePolicy) exemption(rule, imagePath, repository string) (ImagePolicyExemption, bool) {
+	for _, exemption := range p.exemptions {
+		if len(exemption.Rules) > 0 && !slices.Contains(exemption.Rules, rule) {
+			continue
+		}
+		if !globMatches(exemption.Path, imagePath) || !globMatches(exemption.Repository, repository) {
+			continue
+		}
+		return exemption, true
+	}
+	return ImagePolicyExemption{}, false
+}
+
+// violations evaluates an image definition against the policy and returns the
+// rule and message of each violation.
+func (p packageImagePolicy) violations(m map[string]interface{}, repository string) []policyViolation {
+	var violations []policyViolation
+
+	// An absent registry is treated like registry=""
+	if registry, hasRegistry := m["registry"]; len(p.AllowedRegistries) > 0 {
+		if !hasRegistry {
+			registry = ""
+		}
+		if registryStr, ok := registry.(string); !ok || !slices.Contains(p.AllowedRegistries, registryStr) {
+			violations = append(violations, policyViolation{PolicyRuleRegistry,
+				fmt.Sprintf("registry=%v (expected %s)", registry, quoteJoin(p.AllowedRegistries, " or "))})
+		}
+	}
+
+	if len(p.RepositoryPrefixes) > 0 && !slices.ContainsFunc(p.RepositoryPrefixes, func(prefix string) bool {
+		return strings.HasPrefix(repository, prefix)
+	}) {
+		expected := make([]string, len(p.RepositoryPrefixes))
+		for i, prefix := range p.RepositoryPrefixes {
+			expected[i] = prefix + "..."
+		}
+		violations = append(violations, policyViolation{PolicyRuleRepository,
+			fmt.Sprintf("repository=%s (expected %s)", repository, strings.Join(expected, " or "))})
+	}
+
+	var tag string
+	if value, ok := m["tag"]; ok && value != nil {
+		tag = fmt.Sprint(value)
+	}
+	// An empty tag defaults to the chart appVersion rather than "latest"
+	if tag != "" && slices.Contains(p.ForbiddenTags, tag) {
+		violations = append(violations, policyViolation{PolicyRuleForbiddenTag,
+			fmt.Sprintf("tag=%s (forbidden by policy)", tag)})
+	}
+
+	if p.RequireDigest && !hasDigest(m, tag) {
+		violations = append(violations, policyViolation{PolicyRuleDigest,
+			"no digest (policy requires images pinned by digest)"})
+	}
+
+	return violations
+}
+
+type policyViolation struct {
+	rule    string
+	message string
+}
+
+// hasDigest reports whether an image definition pins a digest.
+func hasDigest(m map[string]interface{}, tag string) bool {
+	for _, field := range []string{image.DigestFieldDigest, image.DigestFieldSHA} {
+		if value, ok := m[field].(string); ok && value != "" {
+			return true
+		}
+	}
+	return strings.Contains(tag, "@sha256:")
+}
+
+// globMatches reports whether value matches pattern; an empty pattern matches everything.
+func globMatches(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+	matched, err := path.Match(pattern, value)
+	return err == nil && matched
+}
+
+// quoteJoin formats values as `"a" or "b"`.
+func quoteJoin(values []string, sep string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return strings.Join(quoted, sep)
+}
@@ -0,0 +1,242 @@
+package branchverifycheck
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadImagePolicy(t *testing.T) {
+	t.Run("fields set replace defaults", func(t *testing.T) {
+		policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+		writeFile(t, policyPath, `allowedRegistries: ["", "registry.rancher.com"]
+forbiddenTags: [latest]
+requireDigest: true
+exemptions:
+  - package: rancher-monitoring
+    repository: "rancher/mirrored-*"
+    rules: [digest]
+    justification: mirrored images are pinned by image-mirror
+`)
+		policy, err := LoadImagePolicy(policyPath)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"", "registry.rancher.com"}, policy.AllowedRegistries)
+		assert.Equal(t, []string{"rancher/"}, policy.RepositoryPrefixes, "unset fields keep their defaults")
+		assert.Equal(t, []string{"latest"}, policy.ForbiddenTags)
+		assert.True(t, policy.RequireDigest)
+		require.Len(t, policy.Exemptions, 1, "exemptions replace the default ones")
+		assert.Equal(t, "rancher-monitoring", policy.Exemptions[0].Package)
+	})
+
+	t.Run("empty file is the default policy", func(t *testing.T) {
+		policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+		writeFile(t, policyPath, "")
+		policy, err := LoadImagePolicy(policyPath)
+		require.NoError(t, err)
+		assert.Equal(t, DefaultImagePolicy(), policy)
+	})
+
+	cases := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"unknown field", "allowedRegistry: [\"\"]\n", "allowedRegistry"},
+		{"missing justification", "exemptions:\n  - package: rancher-logging\n", "justification is required"},
+		{"missing package", "exemptions:\n  - justification: why\n", "package is required"},
+		{"unknown rule", "exemptions:\n  - package: rancher-logging\n    rules: [tag]\n    justification: why\n", `unknown rule "tag"`},
+		{"invalid glob", "exemptions:\n  - package: rancher-logging\n    path: \"[\"\n    justification: why\n", "invalid glob"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+			writeFile(t, policyPath, tc.content)
+			_, err := LoadImagePolicy(policyPath)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := LoadImagePolicy(filepath.Join(t.TempDir(), "missing.yaml"))
+		assert.Error(t, err)
+	})
+}
+
+func TestImagePolicySummary(t *testing.T) {
+	assert.Equal(t, `use registry="" and start with "rancher/"`, DefaultImagePolicy().Summary())
+
+	policy := &ImagePolicy{
+		AllowedRegistries:  []string{"", "registry.rancher.com"},
+		RepositoryPrefixes: []string{"rancher/"},
+		ForbiddenTags:      []string{"latest"},
+		RequireDigest:      true,
+	}
+	assert.Equal(t, `use registry="" or "registry.rancher.com", start with "rancher/", avoid tag "latest" and pin a digest`, policy.Summary())
+	assert.Equal(t, "satisfy the image policy", (&ImagePolicy{}).Summary())
+}
+
+func TestValidateImageDefinitionWithPolicy(t *testing.T) {
+	policy := &ImagePolicy{
+		AllowedRegistries:  []string{"", "registry.rancher.com"},
+		RepositoryPrefixes: []string{"rancher/", "cattle/"},
+		ForbiddenTags:      []string{"latest"},
+		RequireDigest:      true,
+		Exemptions: []ImagePolicyExemption{
+			{Package: "rancher-logging", Repository: "rancher/mirrored-*", Rules: []string{PolicyRuleDigest}, Justification: "pinned by image-mirror"},
+			{Package: "rancher-monitoring", Justification: "not this package"},
+		},
+	}
+	pkgPolicy := policy.forPackage(makePackageInfo("rancher-logging", "4.1"))
+
+	cases := []struct {
+		name         string
+		m            map[string]interface{}
+		wantIssues   []string
+		wantExempted int
+	}{
+		{
+			name: "valid: allowed registry, prefix and sha",
+			m:    map[string]interface{}{"registry": "registry.rancher.com", "repository": "cattle/foo", "tag": "v1", "sha": "abc"},
+		},
+		{
+			name: "valid: digest in tag",
+			m:    map[string]interface{}{"repository": "rancher/foo", "tag": "v1@sha256:abc"},
+		},
+		{
+			name: "invalid: registry not allowed",
+			m:    map[string]interface{}{"registry": "docker.io", "repository": "rancher/foo", "tag": "v1", "digest": "sha256:abc"},
+			wantIssues: []string{
+				`registry=docker.io (expected "" or "registry.rancher.com")`,
+			},
+		},
+		{
+			name: "invalid: forbidden tag, missing digest and prefix",
+			m:    map[string]interface{}{"repository": "grafana/grafana", "tag": "latest"},
+			wantIssues: []string{
+				"repository=grafana/grafana (expected rancher/... or cattle/...)",
+				"tag=latest (forbidden by policy)",
+				"no digest (policy requires images pinned by digest)",
+			},
+		},
+		{
+			name:       "invalid: empty tag defaults to appVersion, not latest",
+			m:          map[string]interface{}{"repository": "rancher/foo", "tag": ""},
+			wantIssues: []string{"no digest (policy requires images pinned by digest)"},
+		},
+		{
+			name: "invalid: untagged literal template reference resolves to latest",
+			m:    literalDefinition(t, "rancher/foo"),
+			wantIssues: []string{
+				"tag=latest (forbidden by policy)",
+				"no digest (policy requires images pinned by digest)",
+			},
+		},
+		{
+			name: "valid: empty tag pinned by digest",
+			m:    map[string]interface{}{"repository": "rancher/foo", "tag": "", "sha": "abc"},
+		},
+		{
+			name:         "exempted: missing digest on a mirrored image",
+			m:            map[string]interface{}{"repository": "rancher/mirrored-grafana", "tag": "v1"},
+			wantExempted: 1,
+		},
+		{
+			name:         "exemption covers only its rules",
+			m:            map[string]interface{}{"repository": "rancher/mirrored-grafana", "tag": "latest"},
+			wantIssues:   []string{"tag=latest (forbidden by policy)"},
+			wantExempted: 1,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var invalidImages []InvalidImage
+			validateImageDefinition(tc.m, "values.yaml.image", pkgPolicy, &invalidImages)
+			if len(tc.wantIssues) == 0 && tc.wantExempted == 0 {
+				assert.Empty(t, invalidImages)
+				return
+			}
+			require.Len(t, invalidImages, 1)
+			assert.Equal(t, tc.wantIssues, invalidImages[0].Issues)
+			assert.Len(t, invalidImages[0].Exempted, tc.wantExempted)
+			for _, exempted := range invalidImages[0].Exempted {
+				assert.Equal(t, "pinned by image-mirror", exempted.Justification)
+			}
+		})
+	}
+}
+
+func TestCheckPackageImagesWithPolicy(t *testing.T) {
+	const currentVersion = "1.0.0"
+	pkgName := "rancher-logging"
+
+	setup := func(t *testing.T, valuesYAML string) (string, PackageInfo) {
+		t.Helper()
+		repoPath := t.TempDir()
+		pkg := makePackageInfo(pkgName, "4.1")
+		setupPackage(t, repoPath, pkg, currentVersion)
+		setupBuiltChart(t, repoPath, pkgName, currentVersion)
+		writeFile(t, filepath.Join(repoPath, "charts", pkgName, currentVersion, "values.yaml"), valuesYAML)
+		return repoPath, pkg
+	}
+
+	const values = `image:
+  repository: rancher/foo
+  tag: latest
+sidecar:
+  image:
+    repository: quay.io/foo/sidecar
+    tag: v1
+`
+
+	t.Run("violations fail the check", func(t *testing.T) {
+		repoPath, pkg := setup(t, values)
+		policy := DefaultImagePolicy()
+		policy.ForbiddenTags = []string{"latest"}
+
+		result := CheckPackageImagesWithPolicy(repoPath, pkg, policy)
+		assert.False(t, result.Passed, result.Message)
+		details, ok := result.Details.(*ImageCheckDetails)
+		require.True(t, ok, "Details should be *ImageCheckDetails")
+		assert.Len(t, details.InvalidImages, 2)
+		assert.Empty(t, details.ExemptedImages)
+	})
+
+	t.Run("exempted images are reported but pass", func(t *testing.T) {
+		repoPath, pkg := setup(t, values)
+		policy := DefaultImagePolicy()
+		policy.ForbiddenTags = []string{"latest"}
+		policy.Exemptions = append(policy.Exemptions,
+			ImagePolicyExemption{Package: "rancher-logging/4.1", Path: "values.yaml.image", Rules: []string{PolicyRuleForbiddenTag}, Justification: "tracks the dev build"},
+			ImagePolicyExemption{Package: pkgName, Repository: "quay.io/*/*", Justification: "not yet mirrored"},
+		)
+
+		result := CheckPackageImagesWithPolicy(repoPath, pkg, policy)
+		assert.True(t, result.Passed, result.Message)
+		assert.Contains(t, result.Message, "2 image(s) exempted by policy")
+		details, ok := result.Details.(*ImageCheckDetails)
+		require.True(t, ok, "Details should be *ImageCheckDetails")
+		assert.Empty(t, details.InvalidImages)
+		assert.Len(t, details.ExemptedImages, 2)
+		assert.Contains(t, details.Format(), "exempt: not yet mirrored")
+	})
+
+	t.Run("package-wide exemption skips the check", func(t *testing.T) {
+		repoPath, pkg := setup(t, values)
+		policy := DefaultImagePolicy()
+		policy.Exemptions = []ImagePolicyExemption{{Package: pkgName, Justification: "upstream images"}}
+
+		result := CheckPackageImagesWithPolicy(repoPath, pkg, policy)
+		assert.True(t, result.Passed)
+		assert.Contains(t, result.Message, "upstream images")
+	})
+}
+
+func literalDefinition(t *testing.T, literal string) map[string]interface{} {
+	t.Helper()
+	definition, ok := literalImageDefinition(literal)
+	require.True(t, ok, literal)
+	return definition
+}
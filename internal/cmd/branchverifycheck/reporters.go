@@ -0,0 +1,300 @@
+package branchverifycheck
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// OutputFormat selects how a VerificationResult is written.
+type OutputFormat string
+
+const (
+	// OutputFormatHuman is the default human-readable output.
+	OutputFormatHuman OutputFormat = "human"
+	OutputFormatJSON  OutputFormat = "json"
+	// OutputFormatSARIF reports failed checks as SARIF 2.1.0 results, located at the
+	// offending values.yaml or template line when the check details know it.
+	OutputFormatSARIF OutputFormat = "sarif"
+	// OutputFormatJUnit reports one testcase per check, in one testsuite per package or commit.
+	OutputFormatJUnit OutputFormat = "junit"
+)
+
+// OutputFormats lists every supported output format.
+var OutputFormats = []OutputFormat{OutputFormatHuman, OutputFormatJSON, OutputFormatSARIF, OutputFormatJUnit}
+
+// ParseOutputFormat validates a --format flag value.
+func ParseOutputFormat(value string) (OutputFormat, error) {
+	for _, format := range OutputFormats {
+		if strings.EqualFold(value, string(format)) {
+			return format, nil
+		}
+	}
+	names := make([]string, len(OutputFormats))
+	for i, format := range OutputFormats {
+		names[i] = string(format)
+	}
+	return "", fmt.Errorf("unknown output format %q (expected one of: %s)", value, strings.Join(names, ", "))
+}
+
+// IsMachineReadable reports whether the format must not be mixed with progress output.
+func (f OutputFormat) IsMachineReadable() bool {
+	return f != "" && f != OutputFormatHuman
+}
+
+// WriteResult writes the result in a machine-readable format.
+// OutputFormatHuman is printed by OutputHuman and is rejected here.
+func WriteResult(w io.Writer, result *VerificationResult, format OutputFormat) error {
+	switch format {
+	case OutputFormatJSON:
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case OutputFormatSARIF:
+		return writeSARIF(w, result)
+	case OutputFormatJUnit:
+		return writeJUnit(w, result)
+	default:
+		return fmt.Errorf("output format %q cannot be written as a result", format)
+	}
+}
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// sarifFinding is a single issue of a check, optionally tied to a repository file.
+type sarifFinding struct {
+	message  string
+	location *SourceLocation
+}
+
+func writeSARIF(w io.Writer, result *VerificationResult) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "ob-charts-tool branchVerifyCheck",
+			InformationURI: "https://github.com/rancher/ob-charts-tool",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	seenRules := make(map[string]bool)
+
+	addCheck := func(check CheckResult) {
+		if check.Passed {
+			return
+		}
+		ruleID := check.ID
+		if ruleID == "" {
+			ruleID = check.Name
+		}
+		if !seenRules[ruleID] {
+			seenRules[ruleID] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: ruleID})
+		}
+		level := "warning"
+		if check.Critical {
+			level = "error"
+		}
+
+		for _, finding := range sarifFindings(check) {
+			res := sarifResult{RuleID: ruleID, Level: level, Message: sarifMessage{Text: finding.message}}
+			if finding.location != nil {
+				physical := sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: finding.location.File}}
+				if finding.location.Line > 0 {
+					physical.Region = &sarifRegion{StartLine: finding.location.Line}
+				}
+				res.Locations = []sarifLocation{{PhysicalLocation: physical}}
+			}
+			run.Results = append(run.Results, res)
+		}
+	}
+
+	for _, check := range result.GlobalChecks {
+		addCheck(check)
+	}
+	for _, pkgResult := range result.PackageResults {
+		for _, check := range pkgResult.Checks {
+			addCheck(check)
+		}
+	}
+	for _, commitResult := range result.CommitResults {
+		for _, check := range commitResult.Checks {
+			check.Name = fmt.Sprintf("%s at commit %s", check.Name, commitResult.ShortCommit())
+			addCheck(check)
+		}
+	}
+
+	data, err := json.MarshalIndent(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal SARIF: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// sarifFindings splits a failed check into one finding per located issue of its details,
+// falling back to a single unlocated finding with the check message.
+func sarifFindings(check CheckResult) []sarifFinding {
+	var findings []sarifFinding
+	switch details := check.Details.(type) {
+	case *ImageCheckDetails:
+		for _, img := range details.InvalidImages {
+			name := img.Path
+			if img.Image != "" {
+				name = fmt.Sprintf("%s (%s)", img.Path, img.Image)
+			}
+			findings = append(findings, sarifFinding{
+				message:  fmt.Sprintf("%s: %s", name, strings.Join(img.Issues, "; ")),
+				location: img.Location,
+			})
+		}
+	case *SubchartTagCheckDetails:
+		for _, m := range details.Mismatches {
+			findings = append(findings, sarifFinding{
+				message: fmt.Sprintf("%s: %s is %q but Chart.yaml appVersion expects %q",
+					m.SubchartName, m.ValuesKey, m.ActualValue, m.ExpectedValue),
+				location: m.Location,
+			})
+		}
+	}
+	if len(findings) == 0 {
+		findings = append(findings, sarifFinding{message: fmt.Sprintf("%s: %s", check.Name, check.Message)})
+	}
+	return findings
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit writes one testcase per check, grouping global checks in a "global" testsuite,
+// each package's checks in a testsuite named after the package and each commit's checks
+// in a "commit-<short hash>" testsuite. Only critical failures
+// fail a testcase; warnings pass with the message in system-out, matching the exit status.
+func writeJUnit(w io.Writer, result *VerificationResult) error {
+	suites := junitTestSuites{}
+	addSuite := func(name string, checks []CheckResult) {
+		suite := junitTestSuite{Name: name}
+		className := "branchVerifyCheck." + name
+		for _, check := range checks {
+			tc := junitTestCase{Name: check.Name, ClassName: className}
+			var details string
+			if check.Details != nil {
+				details = check.Details.Format()
+			}
+			switch outcomeOf(check) {
+			case outcomeFailed:
+				tc.Failure = &junitMessage{Message: check.Message, Text: details}
+				suite.Failures++
+			case outcomeWarned:
+				tc.SystemOut = strings.TrimRight("WARN - "+check.Message+"\n\n"+details, "\n")
+			default:
+				tc.SystemOut = strings.TrimRight(check.Message+"\n\n"+details, "\n")
+			}
+			suite.Cases = append(suite.Cases, tc)
+		}
+		suite.Tests = len(suite.Cases)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	if len(result.GlobalChecks) > 0 || len(result.CommitResults) == 0 {
+		addSuite("global", result.GlobalChecks)
+	}
+	for _, pkgResult := range result.PackageResults {
+		addSuite(pkgResult.Package.FullPath, pkgResult.Checks)
+	}
+	for _, commitResult := range result.CommitResults {
+		addSuite("commit-"+commitResult.ShortCommit(), commitResult.Checks)
+	}
+
+	data, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JUnit XML: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, data)
+	return err
+}
@@ -0,0 +1,115 @@
+package branchverifycheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func reporterTestResult() *VerificationResult {
+	return &VerificationResult{
+		GlobalChecks: []CheckResult{
+			{ID: "git-repo", Name: "Git Repository", Passed: true, Critical: true, Message: "ok"},
+			{ID: "branch-current", Name: "Branch Current", Passed: false, Critical: true, Message: "3 commits behind"},
+		},
+		PackageResults: []PackageResult{{
+			Package: PackageInfo{FullPath: "rancher-monitoring/77.9"},
+			Checks: []CheckResult{
+				{
+					ID:      "package-images",
+					Name:    "Package Images origins (rancher-monitoring/77.9)",
+					Message: "Found 1 invalid image(s)",
+					Details: &ImageCheckDetails{InvalidImages: []InvalidImage{{
+						Path:     "values.yaml.image",
+						Issues:   []string{"registry must be empty"},
+						Location: &SourceLocation{File: "charts/rancher-monitoring/77.9.0/values.yaml", Line: 12},
+					}}},
+				},
+				{
+					ID:      "subchart-tags",
+					Name:    "Subchart AppVersion Tags (rancher-monitoring/77.9)",
+					Message: "Found 1 subchart image tag mismatch(es)",
+					Details: &SubchartTagCheckDetails{Mismatches: []SubchartTagMismatch{{
+						SubchartName:  "grafana",
+						ValuesKey:     "image.tag",
+						ActualValue:   "1.0",
+						ExpectedValue: "2.0",
+						Location:      &SourceLocation{File: "charts/rancher-monitoring/77.9.0/charts/grafana/values.yaml", Line: 3},
+					}}},
+				},
+			},
+		}},
+	}
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	format, err := ParseOutputFormat("SARIF")
+	require.NoError(t, err)
+	assert.Equal(t, OutputFormatSARIF, format)
+	assert.True(t, format.IsMachineReadable())
+	assert.False(t, OutputFormatHuman.IsMachineReadable())
+
+	_, err = ParseOutputFormat("xml")
+	assert.ErrorContains(t, err, "expected one of: human, json, sarif, junit")
+}
+
+func TestWriteResult_SARIF(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteResult(&buf, reporterTestResult(), OutputFormatSARIF))
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	assert.Equal(t, []sarifRule{{ID: "branch-current"}, {ID: "package-images"}, {ID: "subchart-tags"}}, run.Tool.Driver.Rules)
+	require.Len(t, run.Results, 3)
+
+	assert.Equal(t, "error", run.Results[0].Level)
+	assert.Empty(t, run.Results[0].Locations)
+
+	images := run.Results[1]
+	assert.Equal(t, "warning", images.Level)
+	assert.Equal(t, "values.yaml.image: registry must be empty", images.Message.Text)
+	require.Len(t, images.Locations, 1)
+	assert.Equal(t, "charts/rancher-monitoring/77.9.0/values.yaml", images.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, &sarifRegion{StartLine: 12}, images.Locations[0].PhysicalLocation.Region)
+
+	tags := run.Results[2]
+	require.Len(t, tags.Locations, 1)
+	assert.Equal(t, "charts/rancher-monitoring/77.9.0/charts/grafana/values.yaml", tags.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Contains(t, tags.Message.Text, `image.tag is "1.0"`)
+}
+
+func TestWriteResult_JUnit(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteResult(&buf, reporterTestResult(), OutputFormatJUnit))
+
+	var suites junitTestSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &suites))
+	assert.Equal(t, 4, suites.Tests)
+	assert.Equal(t, 1, suites.Failures)
+	require.Len(t, suites.Suites, 2)
+
+	global := suites.Suites[0]
+	assert.Equal(t, "global", global.Name)
+	require.Len(t, global.Cases, 2)
+	assert.Nil(t, global.Cases[0].Failure)
+	require.NotNil(t, global.Cases[1].Failure)
+	assert.Equal(t, "3 commits behind", global.Cases[1].Failure.Message)
+
+	pkg := suites.Suites[1]
+	assert.Equal(t, "rancher-monitoring/77.9", pkg.Name)
+	assert.Equal(t, 0, pkg.Failures)
+	require.Len(t, pkg.Cases, 2)
+	assert.Equal(t, "branchVerifyCheck.rancher-monitoring/77.9", pkg.Cases[0].ClassName)
+	assert.Contains(t, pkg.Cases[0].SystemOut, "WARN - Found 1 invalid image(s)")
+}
+
+func TestWriteResult_RejectsHuman(t *testing.T) {
+	assert.Error(t, WriteResult(&bytes.Buffer{}, &VerificationResult{}, OutputFormatHuman))
+}
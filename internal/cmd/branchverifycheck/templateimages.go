@@ -0,0 +1,123 @@
+package branchverifycheck
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/rancher/ob-charts-tool/helmtools/image"
+)
+
+// templateImagePattern matches YAML lines that set an image, e.g. `image: rancher/shell:v0.3.0`
+// or `- image: "quay.io/foo/bar:v1"`.
+var templateImagePattern = regexp.MustCompile(`^\s*(?:-\s+)?["']?image["']?\s*:\s*(.*)$`)
+
+var templateExtensions = []string{".yaml", ".yml", ".tpl"}
+
+// findTemplateFiles recursively finds the template files of a chart and its subcharts,
+// i.e. files with a YAML or .tpl extension under a "templates" directory.
+func findTemplateFiles(dir string) ([]string, error) {
+	var templateFiles []string
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !slices.Contains(templateExtensions, filepath.Ext(d.Name())) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(strings.Split(filepath.Dir(rel), string(filepath.Separator)), "templates") {
+			templateFiles = append(templateFiles, path)
+		}
+		return nil
+	})
+
+	return templateFiles, err
+}
+
+// findLiteralTemplateImages finds the images hardcoded in a template, i.e. `image:` lines
+// whose value contains no template expression, and validates each against policy like an
+// image definition from values.yaml. Invalid images are reported as "<relPath>:<line>".
+func findLiteralTemplateImages(data []byte, relPath string, policy packageImagePolicy, invalidImages *[]InvalidImage) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		literal, ok := literalTemplateImage(scanner.Text())
+		if !ok {
+			continue
+		}
+		definition, ok := literalImageDefinition(literal)
+		if !ok {
+			continue
+		}
+
+		found := len(*invalidImages)
+		validateImageDefinition(definition, fmt.Sprintf("%s:%d", relPath, line), policy, invalidImages)
+		for i := found; i < len(*invalidImages); i++ {
+			(*invalidImages)[i].File = relPath
+			(*invalidImages)[i].Line = line
+			(*invalidImages)[i].Image = literal
+		}
+	}
+}
+
+// literalTemplateImage returns the value of an `image:` line when it is a literal string.
+func literalTemplateImage(line string) (string, bool) {
+	match := templateImagePattern.FindStringSubmatch(line)
+	if match == nil {
+		return "", false
+	}
+	value := match[1]
+	if strings.Contains(value, "{{") {
+		return "", false
+	}
+	if idx := strings.Index(value, " #"); idx >= 0 {
+		value = value[:idx]
+	}
+	value = strings.Trim(strings.TrimSpace(value), `"'`)
+	// Empty values start an image map, which only values.yaml is expected to hold
+	if value == "" || strings.ContainsAny(value, " {}[]|>") {
+		return "", false
+	}
+	return value, true
+}
+
+// literalImageDefinition converts an image reference into the registry/repository/tag
+// map used in values.yaml so it can be validated the same way. The registry field is
+// only set when the reference names a registry host.
+func literalImageDefinition(literal string) (map[string]interface{}, bool) {
+	ref, err := image.ParseReference(literal)
+	if err != nil {
+		return nil, false
+	}
+
+	name, _, _ := strings.Cut(literal, "@")
+	if ref.Tag != "" {
+		name = strings.TrimSuffix(name, ":"+ref.Tag)
+	}
+
+	// A reference without tag or digest implies the "latest" tag
+	tag := ref.Tag
+	if tag == "" && ref.Digest == "" {
+		tag = image.DefaultTag
+	}
+	definition := map[string]interface{}{"tag": tag}
+	if ref.Digest != "" {
+		definition[image.DigestFieldDigest] = ref.Digest
+	}
+	if host, repository, found := strings.Cut(name, "/"); found && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		definition["registry"] = host
+		name = repository
+	}
+	definition["repository"] = name
+	return definition, true
+}
@@ -0,0 +1,172 @@
+package branchverifycheck
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLiteralTemplateImage(t *testing.T) {
+	cases := []struct {
+		line   string
+		want   string
+		wantOK bool
+	}{
+		{"        image: rancher/shell:v0.3.0", "rancher/shell:v0.3.0", true},
+		{`      - image: "quay.io/foo/bar:v1"`, "quay.io/foo/bar:v1", true},
+		{"  image: 'busybox' # init container", "busybox", true},
+		{`  "image": "rancher/kubectl:v1.30"`, "rancher/kubectl:v1.30", true},
+		{`  image: "{{ template "system_default_registry" . }}{{ .Values.image.repository }}"`, "", false},
+		{"  image:", "", false},
+		{"  image: {}", "", false},
+		{"  imagePullPolicy: Always", "", false},
+		{"  # image: rancher/shell:v0.3.0", "", false},
+		{"  description: the image: to use", "", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.line, func(t *testing.T) {
+			got, ok := literalTemplateImage(tc.line)
+			assert.Equal(t, tc.wantOK, ok)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestLiteralImageDefinition(t *testing.T) {
+	cases := []struct {
+		literal string
+		want    map[string]interface{}
+	}{
+		{"rancher/shell:v0.3.0", map[string]interface{}{"repository": "rancher/shell", "tag": "v0.3.0"}},
+		{"busybox", map[string]interface{}{"repository": "busybox", "tag": "latest"}},
+		{"docker.io/rancher/shell:v1", map[string]interface{}{"registry": "docker.io", "repository": "rancher/shell", "tag": "v1"}},
+		{"localhost:5000/team/app:1.0", map[string]interface{}{"registry": "localhost:5000", "repository": "team/app", "tag": "1.0"}},
+		{"rancher/shell@sha256:abc", map[string]interface{}{"repository": "rancher/shell", "tag": "", "digest": "sha256:abc"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.literal, func(t *testing.T) {
+			got, ok := literalImageDefinition(tc.literal)
+			require.True(t, ok)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	_, ok := literalImageDefinition("Not An Image")
+	assert.False(t, ok)
+}
+
+func TestFindTemplateFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{
+		"templates/deployment.yaml",
+		"templates/_helpers.tpl",
+		"templates/NOTES.txt",
+		"charts/grafana/templates/deployment.yaml",
+		"values.yaml",
+		"crds/crd.yaml",
+	} {
+		writeFile(t, filepath.Join(dir, f), "")
+	}
+
+	got, err := findTemplateFiles(dir)
+	require.NoError(t, err)
+	var rel []string
+	for _, f := range got {
+		r, err := filepath.Rel(dir, f)
+		require.NoError(t, err)
+		rel = append(rel, filepath.ToSlash(r))
+	}
+	assert.ElementsMatch(t, []string{
+		"templates/deployment.yaml",
+		"templates/_helpers.tpl",
+		"charts/grafana/templates/deployment.yaml",
+	}, rel)
+}
+
+func TestCheckPackageImagesTemplatesAndCRDChart(t *testing.T) {
+	const currentVersion = "1.0.0"
+	pkgName := "rancher-logging"
+
+	setup := func(t *testing.T) (string, PackageInfo) {
+		t.Helper()
+		repoPath := t.TempDir()
+		pkg := makePackageInfo(pkgName, "4.1")
+		setupPackage(t, repoPath, pkg, currentVersion)
+		setupBuiltChart(t, repoPath, pkgName, currentVersion)
+		return repoPath, pkg
+	}
+	chartDir := func(repoPath string) string {
+		return filepath.Join(repoPath, "charts", pkgName, currentVersion)
+	}
+	crdChartDir := func(repoPath string) string {
+		return filepath.Join(repoPath, "charts", pkgName+"-crd", currentVersion)
+	}
+
+	t.Run("literal template image reported with file and line", func(t *testing.T) {
+		repoPath, pkg := setup(t)
+		writeFile(t, filepath.Join(chartDir(repoPath), "templates", "job.yaml"), `spec:
+  containers:
+    - name: ok
+      image: {{ template "system_default_registry" . }}{{ .Values.image.repository }}
+    - name: hardcoded
+      image: "quay.io/foo/bar:v1"
+`)
+
+		result := CheckPackageImages(repoPath, pkg)
+		assert.False(t, result.Passed, result.Message)
+		details, ok := result.Details.(*ImageCheckDetails)
+		require.True(t, ok, "Details should be *ImageCheckDetails")
+		assert.Equal(t, 1, details.TemplatesChecked)
+		require.Len(t, details.InvalidImages, 1)
+		img := details.InvalidImages[0]
+		assert.Equal(t, filepath.Join("templates", "job.yaml")+":6", img.Path)
+		assert.Equal(t, filepath.Join("templates", "job.yaml"), img.File)
+		assert.Equal(t, 6, img.Line)
+		assert.Equal(t, "quay.io/foo/bar:v1", img.Image)
+		assert.Equal(t, []string{
+			`registry=quay.io (expected "")`,
+			"repository=foo/bar (expected rancher/...)",
+		}, img.Issues)
+	})
+
+	t.Run("rancher literal template image passes", func(t *testing.T) {
+		repoPath, pkg := setup(t)
+		writeFile(t, filepath.Join(chartDir(repoPath), "templates", "job.yaml"), "image: rancher/kubectl:v1.30.0\n")
+
+		result := CheckPackageImages(repoPath, pkg)
+		assert.True(t, result.Passed, result.Message)
+		assert.Contains(t, result.Message, "1 template(s)")
+	})
+
+	t.Run("companion CRD chart is checked", func(t *testing.T) {
+		repoPath, pkg := setup(t)
+		writeFile(t, filepath.Join(chartDir(repoPath), "values.yaml"), "image:\n  repository: rancher/foo\n  tag: v1\n")
+		writeFile(t, filepath.Join(crdChartDir(repoPath), "Chart.yaml"), "name: rancher-logging-crd\n")
+		writeFile(t, filepath.Join(crdChartDir(repoPath), "values.yaml"), "image:\n  repository: bitnami/kubectl\n  tag: v1\n")
+		writeFile(t, filepath.Join(crdChartDir(repoPath), "templates", "job.yaml"), "image: docker.io/bitnami/kubectl:v1\n")
+
+		result := CheckPackageImages(repoPath, pkg)
+		assert.False(t, result.Passed, result.Message)
+		details, ok := result.Details.(*ImageCheckDetails)
+		require.True(t, ok, "Details should be *ImageCheckDetails")
+		assert.Equal(t, 2, details.FilesChecked)
+		var paths []string
+		for _, img := range details.InvalidImages {
+			paths = append(paths, img.Path)
+		}
+		assert.ElementsMatch(t, []string{
+			filepath.Join("rancher-logging-crd", currentVersion, "values.yaml") + ".image",
+			filepath.Join("rancher-logging-crd", currentVersion, "templates", "job.yaml") + ":1",
+		}, paths)
+	})
+
+	t.Run("CRD chart without Chart.yaml is ignored", func(t *testing.T) {
+		repoPath, pkg := setup(t)
+		writeFile(t, filepath.Join(crdChartDir(repoPath), "values.yaml"), "image:\n  repository: bitnami/kubectl\n  tag: v1\n")
+
+		result := CheckPackageImages(repoPath, pkg)
+		assert.True(t, result.Passed, result.Message)
+	})
+}
@@ -13,15 +13,19 @@ import (
 type CheckDetails interface {
 	// Format returns a human-readable string representation of the details
 	Format() string
+	// Kind identifies the details type in JSON output, e.g. DetailsKindBuildDiff
+	Kind() string
 }
 
 // CheckResult represents the result of a single verification check
 type CheckResult struct {
+	// ID is the ID of the check that produced the result (see DefaultRegistry)
+	ID       string       `json:"id,omitempty"`
 	Name     string       `json:"name"`
 	Passed   bool         `json:"passed"`
 	Message  string       `json:"message"`
 	Critical bool         `json:"critical"`          // If true, failure should exit with error
-	Details  CheckDetails `json:"details,omitempty"` // Optional structured data with check-specific details
+	Details  CheckDetails `json:"details,omitempty"` // Optional structured data with check-specific details, written as a DetailsEnvelope
 }
 
 // PackageResult groups all check results for a single package
@@ -47,9 +51,13 @@ func (p *PackageResult) HasCriticalFailure() bool {
 
 // VerificationResult represents the overall verification result
 type VerificationResult struct {
-	Success        bool            `json:"success"`
+	Success bool `json:"success"`
+	// Branch is the verified branch, kept so saved results can be re-rendered
+	Branch         string          `json:"branch,omitempty"`
 	GlobalChecks   []CheckResult   `json:"globalChecks"`
 	PackageResults []PackageResult `json:"packageResults"`
+	// CommitResults holds the per-commit results of VerifyCommits
+	CommitResults []CommitResult `json:"commitResults,omitempty"`
 }
 
 // AddGlobalCheck appends a check result to the global checks
@@ -85,22 +93,18 @@ func (r *VerificationResult) HasCriticalFailure() bool {
 			return true
 		}
 	}
+	for _, commitResult := range r.CommitResults {
+		if commitResult.HasCriticalFailure() {
+			return true
+		}
+	}
 	return false
 }
 
 // CountResults returns counts of passed, failed, and warning checks
 func (r *VerificationResult) CountResults() (passed, failed, warnings int) {
-	for _, check := range r.GlobalChecks {
-		if check.Passed {
-			passed++
-		} else if check.Critical {
-			failed++
-		} else {
-			warnings++
-		}
-	}
-	for _, pkgResult := range r.PackageResults {
-		for _, check := range pkgResult.Checks {
+	count := func(checks []CheckResult) {
+		for _, check := range checks {
 			if check.Passed {
 				passed++
 			} else if check.Critical {
@@ -110,15 +114,16 @@ func (r *VerificationResult) CountResults() (passed, failed, warnings int) {
 			}
 		}
 	}
+	count(r.GlobalChecks)
+	for _, pkgResult := range r.PackageResults {
+		count(pkgResult.Checks)
+	}
+	for _, commitResult := range r.CommitResults {
+		count(commitResult.Checks)
+	}
 	return
 }
 
-// PackageYAML represents the structure of a package.yaml file
-type PackageYAML struct {
-	Version string `yaml:"version"`
-	Commit  string `yaml:"commit"`
-}
-
 // GitRefs holds the git references needed for verification
 type GitRefs struct {
 	// HeadRef is the current HEAD reference
@@ -127,12 +132,22 @@ type GitRefs struct {
 	HeadCommit *object.Commit
 	// UpstreamRef is the upstream main branch reference
 	UpstreamRef *plumbing.Reference
+	// UpstreamName names the upstream in messages, e.g. "upstream/main" or a base ref
+	UpstreamName string
 	// UpstreamCommit is the commit at upstream main
 	UpstreamCommit *object.Commit
 	// MergeBaseCommit is the common ancestor between HEAD and upstream
 	MergeBaseCommit *object.Commit
 }
 
+// upstreamName returns UpstreamName, or "upstream/main" when it is not set.
+func (r *GitRefs) upstreamName() string {
+	if r.UpstreamName == "" {
+		return "upstream/main"
+	}
+	return r.UpstreamName
+}
+
 // BranchInfo holds information about the current branch
 type BranchInfo struct {
 	Name          string
@@ -172,6 +187,11 @@ type BuildDiffDetails struct {
 	Diff          string   `json:"diff"`
 }
 
+// Kind returns DetailsKindBuildDiff
+func (d *BuildDiffDetails) Kind() string {
+	return DetailsKindBuildDiff
+}
+
 // Format returns a formatted string representation of the build diff details
 func (d *BuildDiffDetails) Format() string {
 	var sb strings.Builder
@@ -181,29 +201,84 @@ func (d *BuildDiffDetails) Format() string {
 	return sb.String()
 }
 
-// InvalidImage represents an invalid image found in values.yaml
+// InvalidImage represents an invalid image found in values.yaml or a chart template
 type InvalidImage struct {
 	Path   string   `json:"path"`
 	Issues []string `json:"issues"`
+	// File, Line and Image locate an image hardcoded in a template
+	File  string `json:"file,omitempty"`
+	Line  int    `json:"line,omitempty"`
+	Image string `json:"image,omitempty"`
+	// Exempted lists the issues waived by an image policy exemption
+	Exempted []ExemptedIssue `json:"exempted,omitempty"`
+	// Location is where the image is defined, for reporters that annotate files
+	Location *SourceLocation `json:"location,omitempty"`
+}
+
+// SourceLocation points at a line of a file in the repository
+type SourceLocation struct {
+	// File is relative to the repository root, with forward slashes
+	File string `json:"file"`
+	// Line is 1-based, or 0 when unknown
+	Line int `json:"line,omitempty"`
+}
+
+// ExemptedIssue is an image policy violation waived by an exemption
+type ExemptedIssue struct {
+	Issue         string `json:"issue"`
+	Justification string `json:"justification"`
 }
 
 // ImageCheckDetails contains details about invalid images found during image validation
 type ImageCheckDetails struct {
 	InvalidImages []InvalidImage `json:"invalidImages"`
-	FilesChecked  int            `json:"filesChecked"`
+	// ExemptedImages lists images whose issues were all waived by the image policy
+	ExemptedImages []InvalidImage `json:"exemptedImages,omitempty"`
+	FilesChecked   int            `json:"filesChecked"`
+	// TemplatesChecked counts the template files scanned for hardcoded images
+	TemplatesChecked int `json:"templatesChecked,omitempty"`
+}
+
+// Kind returns DetailsKindImageCheck
+func (d *ImageCheckDetails) Kind() string {
+	return DetailsKindImageCheck
 }
 
 // Format returns a formatted string representation of the image check details
 func (d *ImageCheckDetails) Format() string {
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("Found %d invalid image(s) in %d values.yaml file(s):\n\n", len(d.InvalidImages), d.FilesChecked))
-	for _, img := range d.InvalidImages {
-		sb.WriteString(fmt.Sprintf("  • %s\n", img.Path))
+	if len(d.InvalidImages) > 0 {
+		sb.WriteString(fmt.Sprintf("Found %d invalid image(s) in %d values.yaml file(s)", len(d.InvalidImages), d.FilesChecked))
+		if d.TemplatesChecked > 0 {
+			sb.WriteString(fmt.Sprintf(" and %d template(s)", d.TemplatesChecked))
+		}
+		sb.WriteString(":\n\n")
+		writeInvalidImages(&sb, d.InvalidImages)
+	}
+	if len(d.ExemptedImages) > 0 {
+		if len(d.InvalidImages) > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(fmt.Sprintf("Exempted by image policy (%d image(s)):\n\n", len(d.ExemptedImages)))
+		writeInvalidImages(&sb, d.ExemptedImages)
+	}
+	return sb.String()
+}
+
+func writeInvalidImages(sb *strings.Builder, images []InvalidImage) {
+	for _, img := range images {
+		if img.Image != "" {
+			sb.WriteString(fmt.Sprintf("  • %s (%s)\n", img.Path, img.Image))
+		} else {
+			sb.WriteString(fmt.Sprintf("  • %s\n", img.Path))
+		}
 		for _, issue := range img.Issues {
 			sb.WriteString(fmt.Sprintf("    - %s\n", issue))
 		}
+		for _, exempted := range img.Exempted {
+			sb.WriteString(fmt.Sprintf("    - %s (exempt: %s)\n", exempted.Issue, exempted.Justification))
+		}
 	}
-	return sb.String()
 }
 
 // SubchartTagMismatch represents a mismatch between a subchart's Chart.yaml appVersion and its values.yaml image tag
@@ -212,6 +287,8 @@ type SubchartTagMismatch struct {
 	ValuesKey     string `json:"valuesKey"`
 	ActualValue   string `json:"actualValue"`
 	ExpectedValue string `json:"expectedValue"`
+	// Location is the subchart values.yaml line holding the tag
+	Location *SourceLocation `json:"location,omitempty"`
 }
 
 // SubchartTagCheckDetails contains details about subchart image tag mismatches
@@ -219,6 +296,11 @@ type SubchartTagCheckDetails struct {
 	Mismatches []SubchartTagMismatch `json:"mismatches"`
 }
 
+// Kind returns DetailsKindSubchartTag
+func (d *SubchartTagCheckDetails) Kind() string {
+	return DetailsKindSubchartTag
+}
+
 // Format returns a formatted string representation of the subchart tag check details
 func (d *SubchartTagCheckDetails) Format() string {
 	var sb strings.Builder
@@ -0,0 +1,338 @@
+package changelog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/rancher/ob-charts-tool/internal/chartsrepo"
+	"github.com/rancher/ob-charts-tool/internal/cmd/branchverifycheck"
+	"github.com/rancher/ob-charts-tool/internal/rebase"
+)
+
+// Options configures which versions of a package a changelog covers
+type Options struct {
+	// Package is the package name, e.g. "rancher-monitoring"
+	Package string
+	// FromVersion is the previous built chart version; defaults to the newest built
+	// version older than ToVersion
+	FromVersion string
+	// ToVersion is the new chart version; defaults to the version in the package.yaml
+	ToVersion string
+	// RebaseYAML is an optional rebase.yaml (from monitoring:getRebaseInfo) describing
+	// the upstream chart and subchart versions of ToVersion
+	RebaseYAML string
+}
+
+// Changelog summarises the changes to a package between two chart versions
+type Changelog struct {
+	Package     string
+	FromVersion string
+	ToVersion   string
+	// Upstream is the change of the upstream chart, when a rebase.yaml was given
+	Upstream *UpstreamChange
+	// Subcharts lists the subcharts whose version changed, when a rebase.yaml was given
+	Subcharts []SubchartChange
+	// Commits are the commits touching packages/<name>/, oldest first
+	Commits []Commit
+}
+
+// UpstreamChange is the change of the upstream chart a package is based on
+type UpstreamChange struct {
+	Name        string
+	FromVersion string
+	ToVersion   string
+	AppVersion  string
+}
+
+// SubchartChange is the change of a subchart's chart version and appVersion.
+// The From fields are empty for a new subchart.
+type SubchartChange struct {
+	Name           string
+	FromVersion    string
+	ToVersion      string
+	FromAppVersion string
+	ToAppVersion   string
+}
+
+// Commit is a commit included in a changelog
+type Commit struct {
+	Hash    string
+	Subject string
+}
+
+// ShortHash returns the abbreviated commit hash.
+func (c Commit) ShortHash() string {
+	if len(c.Hash) > 7 {
+		return c.Hash[:7]
+	}
+	return c.Hash
+}
+
+// Generate builds the changelog of a package between two built chart versions of the
+// repository at repoPath. The commits are those between the commit that added the
+// FromVersion chart to charts/<name>/ and the commit that added ToVersion, or HEAD
+// when ToVersion is not built yet.
+func Generate(repoPath string, opts Options) (*Changelog, error) {
+	packages := branchverifycheck.FindAllPackages(repoPath)
+	pkg, ok := packages[opts.Package]
+	if !ok {
+		names := make([]string, 0, len(packages))
+		for name := range packages {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("package %q not found (available: %s)", opts.Package, strings.Join(names, ", "))
+	}
+
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repo: %w", err)
+	}
+	headRef, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	head, err := repo.CommitObject(headRef.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD commit: %w", err)
+	}
+	headTree, err := head.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD tree: %w", err)
+	}
+
+	changelog := &Changelog{
+		Package:     pkg.Name,
+		FromVersion: opts.FromVersion,
+		ToVersion:   opts.ToVersion,
+	}
+	if changelog.ToVersion == "" {
+		if changelog.ToVersion, err = packageVersion(pkg); err != nil {
+			return nil, err
+		}
+	}
+
+	chartsDir := path.Join("charts", pkg.Name)
+	builtVersions := builtChartVersions(headTree, chartsDir)
+	if changelog.FromVersion == "" {
+		if changelog.FromVersion, err = previousVersion(builtVersions, changelog.ToVersion); err != nil {
+			return nil, err
+		}
+	}
+	if !slices.Contains(builtVersions, changelog.FromVersion) {
+		return nil, fmt.Errorf("chart %s %s is not built in %s", pkg.Name, changelog.FromVersion, chartsDir)
+	}
+
+	from, err := addedIn(head, path.Join(chartsDir, changelog.FromVersion))
+	if err != nil {
+		return nil, err
+	}
+	to := head
+	if slices.Contains(builtVersions, changelog.ToVersion) {
+		if to, err = addedIn(head, path.Join(chartsDir, changelog.ToVersion)); err != nil {
+			return nil, err
+		}
+	}
+	if changelog.Commits, err = packageCommits(repo, from, to, path.Join("packages", pkg.Name)); err != nil {
+		return nil, err
+	}
+
+	if opts.RebaseYAML != "" {
+		rebaseInfo, err := rebase.LoadRebaseYaml(opts.RebaseYAML)
+		if err != nil {
+			return nil, err
+		}
+		changelog.Upstream = &UpstreamChange{
+			Name:        rebaseInfo.FoundChart.Name,
+			FromVersion: rebase.UpstreamVersion(changelog.FromVersion),
+			ToVersion:   rebaseInfo.FoundChart.ChartVersion,
+			AppVersion:  rebaseInfo.FoundChart.AppVersion,
+		}
+		changelog.Subcharts = subchartChanges(headTree, path.Join(chartsDir, changelog.FromVersion), rebaseInfo.DependencyChartVersions)
+	}
+
+	return changelog, nil
+}
+
+// packageVersion reads the version of a package from its package.yaml.
+func packageVersion(pkg branchverifycheck.PackageInfo) (string, error) {
+	data, err := os.ReadFile(pkg.PackageYAMLPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read package.yaml: %w", err)
+	}
+	var pkgYAML chartsrepo.PackageYAML
+	if err := yaml.Unmarshal(data, &pkgYAML); err != nil {
+		return "", fmt.Errorf("failed to parse package.yaml: %w", err)
+	}
+	if pkgYAML.Version == "" {
+		return "", fmt.Errorf("%s has no version", pkg.PackageYAMLPath)
+	}
+	return pkgYAML.Version, nil
+}
+
+// builtChartVersions lists the version directories of a chart in charts/<name>/.
+func builtChartVersions(tree *object.Tree, chartsDir string) []string {
+	chartsTree, err := tree.Tree(chartsDir)
+	if err != nil {
+		return nil
+	}
+	var versions []string
+	for _, entry := range chartsTree.Entries {
+		if entry.Mode == filemode.Dir {
+			versions = append(versions, entry.Name)
+		}
+	}
+	return versions
+}
+
+// previousVersion returns the newest of the built versions that is older than version.
+func previousVersion(builtVersions []string, version string) (string, error) {
+	target, err := semver.NewVersion(version)
+	if err != nil {
+		return "", fmt.Errorf("invalid chart version %q: %w", version, err)
+	}
+	var previous *semver.Version
+	for _, built := range builtVersions {
+		v, err := semver.NewVersion(built)
+		if err != nil || !v.LessThan(target) {
+			continue
+		}
+		if previous == nil || v.GreaterThan(previous) {
+			previous = v
+		}
+	}
+	if previous == nil {
+		return "", fmt.Errorf("no built chart version older than %s", version)
+	}
+	return previous.Original(), nil
+}
+
+// addedIn returns the commit that added dir, following first parents from head.
+func addedIn(head *object.Commit, dir string) (*object.Commit, error) {
+	commit := head
+	for {
+		if commit.NumParents() == 0 {
+			return commit, nil
+		}
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get parent commit: %w", err)
+		}
+		hash, err := treeEntryHash(parent, dir)
+		if err != nil {
+			return nil, err
+		}
+		if hash.IsZero() {
+			return commit, nil
+		}
+		commit = parent
+	}
+}
+
+// packageCommits returns the commits after from up to to that change dir, oldest first.
+func packageCommits(repo *git.Repository, from, to *object.Commit, dir string) ([]Commit, error) {
+	rangeCommits, err := branchverifycheck.RangeCommits(repo, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	commits := []Commit{}
+	for _, c := range rangeCommits {
+		hash, err := treeEntryHash(c, dir)
+		if err != nil {
+			return nil, err
+		}
+		var parentHash plumbing.Hash
+		if c.NumParents() > 0 {
+			parent, err := c.Parent(0)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get parent commit: %w", err)
+			}
+			if parentHash, err = treeEntryHash(parent, dir); err != nil {
+				return nil, err
+			}
+		}
+		if hash == parentHash {
+			continue
+		}
+		subject, _, _ := strings.Cut(c.Message, "\n")
+		commits = append(commits, Commit{Hash: c.Hash.String(), Subject: subject})
+	}
+	return commits, nil
+}
+
+// treeEntryHash returns the hash of the tree at dir in a commit, or the zero hash if the
+// commit does not contain dir. Comparing it between commits tells whether dir changed
+// without diffing the trees.
+func treeEntryHash(commit *object.Commit, dir string) (plumbing.Hash, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to get tree of %s: %w", commit.Hash.String()[:7], err)
+	}
+	entry, err := tree.FindEntry(dir)
+	if err != nil {
+		if errors.Is(err, object.ErrDirectoryNotFound) || errors.Is(err, object.ErrEntryNotFound) {
+			return plumbing.ZeroHash, nil
+		}
+		return plumbing.ZeroHash, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	return entry.Hash, nil
+}
+
+// subchartChanges compares the subcharts vendored in a built chart with the dependency
+// versions of a rebase, returning those that changed or are new.
+func subchartChanges(tree *object.Tree, chartDir string, dependencies []rebase.DependencyChartVersion) []SubchartChange {
+	var changes []SubchartChange
+	for _, dep := range dependencies {
+		change := SubchartChange{
+			Name:         dep.Name,
+			ToVersion:    dep.ChartVersion,
+			ToAppVersion: dep.AppVersion,
+		}
+		if meta, ok := readChartMetaData(tree, path.Join(chartDir, "charts", dep.Name, "Chart.yaml")); ok {
+			change.FromVersion = meta.Version
+			change.FromAppVersion = meta.AppVersion
+		}
+		if change.FromVersion == change.ToVersion && change.FromAppVersion == change.ToAppVersion {
+			continue
+		}
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+// readChartMetaData reads the version and appVersion of a Chart.yaml in a tree.
+func readChartMetaData(tree *object.Tree, chartYAMLPath string) (rebase.ChartMetaData, bool) {
+	var meta rebase.ChartMetaData
+	file, err := tree.File(chartYAMLPath)
+	if err != nil {
+		return meta, false
+	}
+	reader, err := file.Reader()
+	if err != nil {
+		return meta, false
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return meta, false
+	}
+	if err := yaml.Unmarshal(data, &meta); err != nil {
+		return meta, false
+	}
+	return meta, true
+}
@@ -0,0 +1,157 @@
+package changelog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pkgYAMLPath = "packages/rancher-monitoring/77.9/rancher-monitoring/package.yaml"
+
+// commitFiles writes files (repo-relative path → content) into dir, stages them and commits.
+func commitFiles(t *testing.T, dir string, files map[string]string, msg string) *object.Commit {
+	t.Helper()
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	for relPath, content := range files {
+		fullPath := filepath.Join(dir, relPath)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0755))
+		require.NoError(t, os.WriteFile(fullPath, []byte(content), 0644))
+		_, err := wt.Add(relPath)
+		require.NoError(t, err)
+	}
+	hash, err := wt.Commit(msg, &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@test.com", When: time.Now()},
+	})
+	require.NoError(t, err)
+	c, err := repo.CommitObject(hash)
+	require.NoError(t, err)
+	return c
+}
+
+// changelogRepo builds a repo where rancher-monitoring 77.9.1-rancher.1 is built, followed by
+// two package changes, an unrelated commit and the bump to the unbuilt 77.9.1-rancher.2.
+func changelogRepo(t *testing.T) (dir string, commits []*object.Commit) {
+	t.Helper()
+	dir = t.TempDir()
+	_, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+
+	const built = "charts/rancher-monitoring/77.9.1-rancher.1/"
+	commits = append(commits,
+		commitFiles(t, dir, map[string]string{
+			pkgYAMLPath:                                    "version: 77.9.1-rancher.1\n",
+			built + "Chart.yaml":                           "name: rancher-monitoring\nversion: 77.9.1-rancher.1\n",
+			built + "charts/grafana/Chart.yaml":            "name: grafana\nversion: 9.0.0\nappVersion: 12.0.0\n",
+			built + "charts/kube-state-metrics/Chart.yaml": "name: kube-state-metrics\nversion: 6.1.0\nappVersion: 2.16.0\n",
+		}, "Release rancher-monitoring 77.9.1-rancher.1"),
+		commitFiles(t, dir, map[string]string{
+			"packages/rancher-monitoring/77.9/generated-changes/patch/values.yaml.patch": "patch\n",
+		}, "Fix the etcd dashboard\n\nLonger description."),
+		commitFiles(t, dir, map[string]string{"README.md": "# docs\n"}, "Update docs"),
+		commitFiles(t, dir, map[string]string{pkgYAMLPath: "version: 77.9.1-rancher.2\n"}, "Bump rancher-monitoring to 77.9.1-rancher.2"),
+	)
+	return dir, commits
+}
+
+func TestGenerate(t *testing.T) {
+	dir, commits := changelogRepo(t)
+
+	got, err := Generate(dir, Options{Package: "rancher-monitoring"})
+	require.NoError(t, err)
+	assert.Equal(t, "77.9.1-rancher.1", got.FromVersion, "defaults to the previous built version")
+	assert.Equal(t, "77.9.1-rancher.2", got.ToVersion, "defaults to the package.yaml version")
+	assert.Equal(t, []Commit{
+		{Hash: commits[1].Hash.String(), Subject: "Fix the etcd dashboard"},
+		{Hash: commits[3].Hash.String(), Subject: "Bump rancher-monitoring to 77.9.1-rancher.2"},
+	}, got.Commits, "only commits touching the package, oldest first")
+	assert.Nil(t, got.Upstream)
+
+	_, err = Generate(dir, Options{Package: "rancher-logging"})
+	assert.ErrorContains(t, err, "available: rancher-monitoring")
+
+	_, err = Generate(dir, Options{Package: "rancher-monitoring", FromVersion: "77.8.0-rancher.1"})
+	assert.ErrorContains(t, err, "is not built")
+}
+
+func TestGenerate_RebaseYAML(t *testing.T) {
+	dir, _ := changelogRepo(t)
+	rebaseYAML := filepath.Join(t.TempDir(), "rebase.yaml")
+	require.NoError(t, os.WriteFile(rebaseYAML, []byte(`target_version: 78.0.0
+found_chart:
+  name: kube-prometheus-stack
+  chart_version: 78.0.0
+  app_version: v0.86.0
+dependency_chart_versions:
+  - name: kube-state-metrics
+    chart_version: 6.1.0
+    app_version: 2.16.0
+  - name: grafana
+    chart_version: 9.1.0
+    app_version: 12.1.0
+  - name: prometheus-windows-exporter
+    chart_version: 0.12.0
+    app_version: 0.31.0
+`), 0644))
+
+	got, err := Generate(dir, Options{Package: "rancher-monitoring", RebaseYAML: rebaseYAML})
+	require.NoError(t, err)
+	assert.Equal(t, &UpstreamChange{
+		Name: "kube-prometheus-stack", FromVersion: "77.9.1", ToVersion: "78.0.0", AppVersion: "v0.86.0",
+	}, got.Upstream)
+	assert.Equal(t, []SubchartChange{
+		{Name: "grafana", FromVersion: "9.0.0", ToVersion: "9.1.0", FromAppVersion: "12.0.0", ToAppVersion: "12.1.0"},
+		{Name: "prometheus-windows-exporter", ToVersion: "0.12.0", ToAppVersion: "0.31.0"},
+	}, got.Subcharts, "unchanged subcharts are left out")
+}
+
+func TestPreviousVersion(t *testing.T) {
+	built := []string{"77.9.1-rancher.1", "77.9.1-rancher.3", "77.9.1-rancher.10", "78.0.0-rancher.1", "not-a-version"}
+
+	got, err := previousVersion(built, "78.0.0-rancher.1")
+	require.NoError(t, err)
+	assert.Equal(t, "77.9.1-rancher.10", got)
+
+	_, err = previousVersion(built, "77.9.1-rancher.1")
+	assert.Error(t, err)
+}
+
+func TestMarkdown(t *testing.T) {
+	c := &Changelog{
+		Package:     "rancher-monitoring",
+		FromVersion: "77.9.1-rancher.1",
+		ToVersion:   "78.0.0-rancher.1",
+		Upstream:    &UpstreamChange{Name: "kube-prometheus-stack", FromVersion: "77.9.1", ToVersion: "78.0.0"},
+		Subcharts: []SubchartChange{
+			{Name: "grafana", FromVersion: "9.0.0", ToVersion: "9.1.0", FromAppVersion: "12.0.0", ToAppVersion: "12.0.0"},
+		},
+		Commits: []Commit{{Hash: "0123456789abcdef", Subject: "Rebase onto 78.0.0"}},
+	}
+
+	assert.Equal(t, `## rancher-monitoring 78.0.0-rancher.1
+
+Changes since 77.9.1-rancher.1.
+
+### Upstream
+
+- kube-prometheus-stack: 77.9.1 → 78.0.0
+
+### Subcharts
+
+| Subchart | Chart version | appVersion |
+|---|---|---|
+| grafana | 9.0.0 → 9.1.0 | 12.0.0 |
+
+### Changes
+
+- Rebase onto 78.0.0 (0123456)
+`, c.Markdown())
+}
@@ -0,0 +1,54 @@
+package changelog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Markdown renders the changelog as a Markdown section, ready to paste into a chart's
+// release notes or CHANGELOG.
+func (c *Changelog) Markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## %s %s\n\n", c.Package, c.ToVersion)
+	fmt.Fprintf(&sb, "Changes since %s.\n", c.FromVersion)
+
+	if c.Upstream != nil {
+		sb.WriteString("\n### Upstream\n\n")
+		fmt.Fprintf(&sb, "- %s: %s\n", c.Upstream.Name, versionChange(c.Upstream.FromVersion, c.Upstream.ToVersion))
+		if c.Upstream.AppVersion != "" {
+			fmt.Fprintf(&sb, "- appVersion: %s\n", c.Upstream.AppVersion)
+		}
+	}
+
+	if len(c.Subcharts) > 0 {
+		sb.WriteString("\n### Subcharts\n\n")
+		sb.WriteString("| Subchart | Chart version | appVersion |\n")
+		sb.WriteString("|---|---|---|\n")
+		for _, s := range c.Subcharts {
+			fmt.Fprintf(&sb, "| %s | %s | %s |\n", s.Name,
+				versionChange(s.FromVersion, s.ToVersion), versionChange(s.FromAppVersion, s.ToAppVersion))
+		}
+	}
+
+	sb.WriteString("\n### Changes\n\n")
+	if len(c.Commits) == 0 {
+		fmt.Fprintf(&sb, "- No changes to packages/%s\n", c.Package)
+	}
+	for _, commit := range c.Commits {
+		fmt.Fprintf(&sb, "- %s (%s)\n", commit.Subject, commit.ShortHash())
+	}
+	return sb.String()
+}
+
+// versionChange formats a version change as "old → new", "new (new)" when there was no
+// previous version, or just the version when it did not change.
+func versionChange(from, to string) string {
+	switch {
+	case from == "":
+		return to + " (new)"
+	case from == to:
+		return to
+	default:
+		return from + " → " + to
+	}
+}
@@ -2,13 +2,16 @@ package chartimages
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
-	"net/url"
+	"io/fs"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
 
+	"github.com/rancher/ob-charts-tool/helmtools/image"
+	"github.com/rancher/ob-charts-tool/helmtools/registry"
 	"github.com/rancher/ob-charts-tool/helmtools/util"
 	"github.com/rancher/ob-charts-tool/internal"
 
@@ -20,17 +23,54 @@ type ImageLists struct {
 	NeedsManualCheck util.Set[string]
 	RancherImages    util.Set[string]
 	NonRancherImages util.Set[string]
+	// Owners maps each image to the Kubernetes resources that reference it.
+	// It is empty when images were found by pattern matching.
+	Owners map[string][]image.ResourceRef
 }
 
 func newImageLists() ImageLists {
 	return ImageLists{
-		util.NewSet[string](),
-		util.NewSet[string](),
-		util.NewSet[string](),
+		NeedsManualCheck: util.NewSet[string](),
+		RancherImages:    util.NewSet[string](),
+		NonRancherImages: util.NewSet[string](),
+		Owners:           make(map[string][]image.ResourceRef),
 	}
 }
 
+// add sorts an image into the manual-check, rancher or non-rancher list.
+func (l ImageLists) add(img string) {
+	switch {
+	case strings.Contains(img, "{{"):
+		l.NeedsManualCheck.Add(img)
+	case strings.Contains(img, "rancher/"):
+		l.RancherImages.Add(img)
+	default:
+		l.NonRancherImages.Add(img)
+	}
+}
+
+// PrepareChartImagesList finds the images used by a rendered chart. The chart is decoded
+// as Kubernetes manifests so images in workload PodSpecs, prometheus-operator resources and
+// image-bearing container args and env values are found along with their owning resources. Input that is not
+// valid YAML (e.g. raw `helm install --debug` output) falls back to pattern matching.
 func PrepareChartImagesList(chart string) ImageLists {
+	found, err := image.ExtractImagesFromManifests([]byte(chart))
+	if err != nil {
+		log.Warnf("Unable to decode the rendered chart as Kubernetes manifests, falling back to pattern matching: %v", err)
+		return prepareChartImagesListFromPatterns(chart)
+	}
+
+	imageListRes := newImageLists()
+	for img, owners := range image.OwnersByImage(found) {
+		img = image.Familiarize(img)
+		imageListRes.Owners[img] = append(imageListRes.Owners[img], owners...)
+		imageListRes.add(img)
+	}
+	return imageListRes
+}
+
+// prepareChartImagesListFromPatterns finds images by matching `image:` and `docker.io` strings.
+func prepareChartImagesListFromPatterns(chart string) ImageLists {
 	imageListRes := newImageLists()
 
 	log.Debug("Looking for images...with `image:` strings")
@@ -38,8 +78,8 @@ func PrepareChartImagesList(chart string) ImageLists {
 	re := regexp.MustCompile(`image: (.*)`)
 	imageList := re.FindAllString(chart, -1)
 	imagesSet := util.NewSet[string]()
-	for _, image := range imageList {
-		imagesSet.Add(image)
+	for _, img := range imageList {
+		imagesSet.Add(img)
 	}
 
 	log.Debug("Looking for images...with `docker.io` strings")
@@ -49,8 +89,8 @@ func PrepareChartImagesList(chart string) ImageLists {
 	imageList = util.FilterSlice(imageList, func(s string) bool {
 		return !strings.Contains(strings.ToLower(s), "registry:")
 	})
-	for _, image := range imageList {
-		imagesSet.Add(image)
+	for _, img := range imageList {
+		imagesSet.Add(img)
 	}
 
 	imagesSet = imagesSet.Map(func(s string) string {
@@ -62,31 +102,12 @@ func PrepareChartImagesList(chart string) ImageLists {
 		}
 		return s
 	}).Map(func(s string) string {
-		s = strings.ReplaceAll(s, "\"", "")
-		if strings.Index(s, "docker.io/") == 0 {
-			s = strings.ReplaceAll(s, "docker.io/", "")
-		}
-		return s
+		return image.Familiarize(strings.ReplaceAll(s, "\"", ""))
 	})
 
 	// TODO: maybe consider adding image tag check - if non use latest?
 	for item := range imagesSet.ValuesChan() {
-		if strings.Contains(item, "{{") {
-			imageListRes.NeedsManualCheck.Add(item)
-			imagesSet.Remove(item)
-		}
-	}
-
-	for item := range imagesSet.ValuesChan() {
-		if strings.Contains(item, "rancher/") {
-			imageListRes.RancherImages.Add(item)
-			imagesSet.Remove(item)
-		}
-	}
-
-	for item := range imagesSet.ValuesChan() {
-		imageListRes.NonRancherImages.Add(item)
-		imagesSet.Remove(item)
+		imageListRes.add(item)
 	}
 
 	return imageListRes
@@ -97,8 +118,8 @@ func ProcessRenderedChartImages(chartImages *ImageLists) error {
 		fmt.Println("\n🩻 We will check these images:")
 		l := list.NewWriter()
 		l.SetStyle(list.StyleBulletCircle)
-		for image := range chartImages.RancherImages {
-			l.AppendItem(image)
+		for img := range chartImages.RancherImages {
+			l.AppendItem(imageWithOwners(img, chartImages.Owners))
 		}
 		fmt.Println(l.Render())
 	}
@@ -107,8 +128,8 @@ func ProcessRenderedChartImages(chartImages *ImageLists) error {
 		fmt.Println("\n👨‍🔧 These need manual checks:")
 		l := list.NewWriter()
 		l.SetStyle(list.StyleBulletCircle)
-		for image := range chartImages.NeedsManualCheck {
-			l.AppendItem(image)
+		for img := range chartImages.NeedsManualCheck {
+			l.AppendItem(imageWithOwners(img, chartImages.Owners))
 		}
 		fmt.Println(l.Render())
 	}
@@ -117,8 +138,8 @@ func ProcessRenderedChartImages(chartImages *ImageLists) error {
 		fmt.Println("\n💥 These appear to have the wrong source registry:")
 		l := list.NewWriter()
 		l.SetStyle(list.StyleBulletCircle)
-		for image := range chartImages.NonRancherImages {
-			l.AppendItem(image)
+		for img := range chartImages.NonRancherImages {
+			l.AppendItem(imageWithOwners(img, chartImages.Owners))
 		}
 		fmt.Println(l.Render())
 	}
@@ -126,76 +147,120 @@ func ProcessRenderedChartImages(chartImages *ImageLists) error {
 	return nil
 }
 
-func CheckRancherImages(rancherImages util.Set[string]) map[string]bool {
-	res := make(map[string]bool)
-	// Make list of all images and their results,
-	// Prepare list to table,
-	// Render table
-	for item := range rancherImages {
-		exists := checkImageTagExists(item)
-		res[item] = exists
+// imageWithOwners formats an image followed by the resources that reference it.
+func imageWithOwners(img string, owners map[string][]image.ResourceRef) string {
+	refs := ownerStrings(owners[img])
+	if len(refs) == 0 {
+		return img
 	}
-
-	return res
+	return fmt.Sprintf("%s (%s)", img, strings.Join(refs, ", "))
 }
 
-func checkImageTagExists(image string) bool {
-	imageParts := strings.Split(image, ":")
-	repo := imageParts[0]
-	tag := imageParts[1]
+func ownerStrings(refs []image.ResourceRef) []string {
+	if len(refs) == 0 {
+		return nil
+	}
+	out := make([]string, len(refs))
+	for i, ref := range refs {
+		out[i] = ref.String()
+	}
+	return out
+}
 
-	imageRequestToken := getDockerHubToken(repo)
-	return makeImageTagRequest(imageRequestToken, fmt.Sprintf(manifestURLTemplate, repo, tag))
+// NewRegistryClient creates a registry client using credentials from the docker config.json
+// at dockerConfigPath. A missing config file is not an error; images are then checked anonymously.
+func NewRegistryClient(dockerConfigPath string) (*registry.Client, error) {
+	var credentials *registry.DockerConfig
+	if dockerConfigPath != "" {
+		cfg, err := registry.LoadDockerConfig(dockerConfigPath)
+		switch {
+		case err == nil:
+			credentials = cfg
+		case errors.Is(err, fs.ErrNotExist):
+			log.Debugf("No docker config found at %s; using anonymous registry access", dockerConfigPath)
+		default:
+			return nil, err
+		}
+	}
+	return registry.NewClient(internal.DefaultHTTPClient, credentials)
 }
 
-const (
-	dockerTokenURL      = "https://auth.docker.io/token"
-	dockerService       = "registry.docker.io"
-	scopeTemplate       = "repository:%s:pull"
-	manifestURLTemplate = "https://registry-1.docker.io/v2/%s/manifests/%s"
-)
+// ImageCheck is the registry and platform coverage result for a single image.
+type ImageCheck struct {
+	registry.CheckResult
+	// Coverage is nil when platform checks are disabled or the image was not found.
+	Coverage *registry.PlatformCoverage
+}
 
-func getDockerHubToken(repo string) string {
-	requestScope := fmt.Sprintf(scopeTemplate, repo)
-	// Construct query parameters
-	params := url.Values{}
-	params.Add("service", dockerService)
-	params.Add("scope", requestScope)
+// Passed reports whether the image exists and, when checked, provides every required platform.
+func (c ImageCheck) Passed() bool {
+	return c.Exists() && (c.Coverage == nil || c.Coverage.Complete())
+}
 
-	// Construct full URL with encoded query parameters
-	fullURL := fmt.Sprintf("%s?%s", dockerTokenURL, params.Encode())
-	body, err := util.FetchURL(context.Background(), internal.DefaultHTTPClient, fullURL)
-	if err != nil {
-		log.Fatal(err)
-	}
+// CheckRancherImages checks every image against its registry using at most concurrency
+// parallel lookups and returns the results sorted by image name. When checkPlatforms is set,
+// images that exist are also checked for the platforms required by the OSList of their
+// values.yaml reference in references (see registry.RequiredPlatforms). references may be
+// nil, e.g. for rendered charts read from stdin.
+func CheckRancherImages(client *registry.Client, rancherImages util.Set[string], references map[string]*image.ImageReference, concurrency int, checkPlatforms bool) []ImageCheck {
+	images := rancherImages.Values()
+	sort.Strings(images)
 
-	responseData := make(map[string]interface{})
-	err = json.Unmarshal(body, &responseData)
-	if err != nil {
-		log.Fatal(err)
+	ctx := context.Background()
+	results := client.CheckImages(ctx, images, concurrency)
+	checks := make([]ImageCheck, len(results))
+	byImage := referencesByImage(references)
+	var refs []image.ImageReference
+	var refIdx []int
+	for idx, res := range results {
+		checks[idx] = ImageCheck{CheckResult: res}
+		if res.Err != nil && res.Status != registry.StatusMissing {
+			log.Warnf("Unable to check image `%s` (%s): %v", res.Image, res.Status, res.Err)
+		}
+		if checkPlatforms && res.Exists() {
+			refs = append(refs, platformReference(res.Image, byImage))
+			refIdx = append(refIdx, idx)
+		}
 	}
 
-	token, ok := responseData["token"]
-	if !ok {
-		panic(fmt.Errorf("unable to find `token` in the resopnse; requested for `%s`", repo))
+	for i, coverage := range client.CheckReferenceCoverages(ctx, refs, concurrency) {
+		if coverage.Err != nil {
+			log.Warnf("Unable to check platforms for image `%s`: %v", coverage.Image, coverage.Err)
+		}
+		checks[refIdx[i]].Coverage = &coverage
 	}
 
-	return token.(string)
+	return checks
 }
 
-func makeImageTagRequest(token string, url string) bool {
-	req, err := http.NewRequest("HEAD", url, nil)
-	if err != nil {
-		log.Error("Error creating request:", err)
-		return false
+// referencesByImage keys values.yaml image references by their familiar name, as used for
+// rendered chart images. The OS lists of references to the same image are merged.
+func referencesByImage(references map[string]*image.ImageReference) map[string]image.ImageReference {
+	byImage := make(map[string]image.ImageReference, len(references))
+	for _, ref := range references {
+		name := image.Familiarize(ref.FullImage())
+		existing, ok := byImage[name]
+		if !ok {
+			existing = image.ImageReference{Image: ref.Image, Sources: ref.Sources, OS: ref.OS}
+		}
+		for _, os := range ref.OSList {
+			if !slices.Contains(existing.OSList, os) {
+				existing.OSList = append(existing.OSList, os)
+			}
+		}
+		byImage[name] = existing
 	}
+	return byImage
+}
 
-	req.Header.Add("Accept", "application/vnd.docker.distribution.manifest.v2+json")
-	req.Header.Add("Authorization", "Bearer "+token)
-
-	// Create an HTTP client and send the request
-	client := &http.Client{}
-	resp, _ := client.Do(req)
-
-	return resp.StatusCode == http.StatusOK
+// platformReference returns the values.yaml reference of a rendered image. Images that no
+// values.yaml defines fall back to the OS their name suggests, as image.ExtractImagesWithSources
+// does for images without an os field.
+func platformReference(img string, byImage map[string]image.ImageReference) image.ImageReference {
+	if ref, ok := byImage[img]; ok {
+		return ref
+	}
+	imageOS := image.DetectOS(img)
+	// The whole reference goes in Repository so FullImage returns it unchanged
+	return image.ImageReference{Image: image.Image{Repository: img}, OS: imageOS, OSList: []string{imageOS}}
 }
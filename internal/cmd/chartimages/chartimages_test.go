@@ -0,0 +1,87 @@
+package chartimages
+
+import (
+	"testing"
+
+	"github.com/rancher/ob-charts-tool/helmtools/image"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrepareChartImagesList(t *testing.T) {
+	rendered := `---
+# Source: rancher-monitoring/templates/prometheus-operator/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: operator
+  namespace: cattle-monitoring-system
+spec:
+  template:
+    spec:
+      containers:
+        - name: operator
+          # image: rancher/mirrored-commented-out:v1
+          image: "docker.io/rancher/mirrored-prometheus-operator:v0.79.2"
+          args:
+            - --prometheus-config-reloader=docker.io/rancher/mirrored-config-reloader:v0.79.2
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: exporter
+spec:
+  template:
+    spec:
+      containers:
+        - name: exporter
+          image: quay.io/prometheus/node-exporter:v1.8.2
+`
+
+	lists := PrepareChartImagesList(rendered)
+	assert.ElementsMatch(t, []string{
+		"rancher/mirrored-prometheus-operator:v0.79.2",
+		"rancher/mirrored-config-reloader:v0.79.2",
+	}, lists.RancherImages.Values())
+	assert.Equal(t, []string{"quay.io/prometheus/node-exporter:v1.8.2"}, lists.NonRancherImages.Values())
+	assert.True(t, lists.NeedsManualCheck.IsEmpty())
+	assert.Equal(t, "rancher/mirrored-config-reloader:v0.79.2 (Deployment/cattle-monitoring-system/operator)",
+		imageWithOwners("rancher/mirrored-config-reloader:v0.79.2", lists.Owners))
+}
+
+func TestPrepareChartImagesList_PatternFallback(t *testing.T) {
+	// Not valid YAML, e.g. `helm install --dry-run --debug` output with a leading banner.
+	rendered := "NAME: rancher-monitoring\n\t- bad: [\n        image: rancher/shell:v0.3.0\n        image: {{ .Values.image }}\n"
+
+	lists := PrepareChartImagesList(rendered)
+	assert.Equal(t, []string{"rancher/shell:v0.3.0"}, lists.RancherImages.Values())
+	assert.Equal(t, []string{"image: {{ .Values.image }}"}, lists.NeedsManualCheck.Values())
+	assert.Empty(t, lists.Owners)
+}
+
+func TestPlatformReference(t *testing.T) {
+	references := map[string]*image.ImageReference{
+		"docker.io/rancher/mirrored-prometheus-windows-exporter:0.31.0": {
+			Image:  image.Image{Registry: "docker.io", Repository: "rancher/mirrored-prometheus-windows-exporter", Tag: "0.31.0"},
+			OSList: []string{"windows"},
+		},
+		"rancher/mirrored-prometheus-windows-exporter:0.31.0": {
+			Image:  image.Image{Repository: "rancher/mirrored-prometheus-windows-exporter", Tag: "0.31.0"},
+			OSList: []string{"linux"},
+		},
+		"rancher/shell:v0.5.0": {
+			Image:  image.Image{Repository: "rancher/shell", Tag: "v0.5.0"},
+			OSList: []string{"windows", "linux"},
+		},
+	}
+	byImage := referencesByImage(references)
+
+	got := platformReference("rancher/mirrored-prometheus-windows-exporter:0.31.0", byImage)
+	assert.ElementsMatch(t, []string{"windows", "linux"}, got.OSList, "OS lists of the same image are merged")
+
+	got = platformReference("rancher/shell:v0.5.0", byImage)
+	assert.Equal(t, []string{"windows", "linux"}, got.OSList, "the values.yaml os field is used over the image name")
+
+	got = platformReference("rancher/mirrored-windows-thing:v1", byImage)
+	assert.Equal(t, "rancher/mirrored-windows-thing:v1", got.FullImage())
+	assert.Equal(t, []string{"windows"}, got.OSList, "images without a values.yaml reference fall back to their name")
+}
@@ -0,0 +1,237 @@
+package chartimages
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rancher/ob-charts-tool/helmtools/registry"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// OutputFormat selects how a Report is written.
+type OutputFormat string
+
+const (
+	// OutputTable is the default human-readable table output.
+	OutputTable    OutputFormat = "table"
+	OutputJSON     OutputFormat = "json"
+	OutputYAML     OutputFormat = "yaml"
+	OutputJUnit    OutputFormat = "junit"
+	OutputMarkdown OutputFormat = "markdown"
+)
+
+// OutputFormats lists every supported output format.
+var OutputFormats = []OutputFormat{OutputTable, OutputJSON, OutputYAML, OutputJUnit, OutputMarkdown}
+
+// ParseOutputFormat validates an --output flag value.
+func ParseOutputFormat(value string) (OutputFormat, error) {
+	for _, format := range OutputFormats {
+		if strings.EqualFold(value, string(format)) {
+			return format, nil
+		}
+	}
+	names := make([]string, len(OutputFormats))
+	for i, format := range OutputFormats {
+		names[i] = string(format)
+	}
+	return "", fmt.Errorf("unknown output format %q (expected one of: %s)", value, strings.Join(names, ", "))
+}
+
+// IsMachineReadable reports whether the format must not be mixed with progress output.
+func (f OutputFormat) IsMachineReadable() bool {
+	return f != OutputTable
+}
+
+// WriteReport writes the report in a machine-readable format.
+// OutputTable is rendered by the command itself and is rejected here.
+func WriteReport(w io.Writer, report *Report, format OutputFormat) error {
+	switch format {
+	case OutputJSON:
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case OutputYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("failed to marshal YAML: %w", err)
+		}
+		return encoder.Close()
+	case OutputJUnit:
+		return writeJUnit(w, report)
+	case OutputMarkdown:
+		return writeMarkdown(w, report)
+	default:
+		return fmt.Errorf("output format %q cannot be written as a report", format)
+	}
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+func writeJUnit(w io.Writer, report *Report) error {
+	rancher := junitTestSuite{Name: "rancher-images"}
+	for _, img := range report.RancherImages {
+		tc := junitTestCase{Name: img.Image, ClassName: "verifyChartImages.rancherImages"}
+		var out []string
+		if len(img.Platforms) > 0 {
+			out = append(out, "platforms: "+strings.Join(img.Platforms, ", "))
+		}
+		if owners := report.Owners[img.Image]; len(owners) > 0 {
+			out = append(out, "used by: "+strings.Join(owners, ", "))
+		}
+		tc.SystemOut = strings.Join(out, "\n")
+		if !img.Passed {
+			tc.Failure = &junitMessage{Message: imageFailureMessage(img)}
+			rancher.Failures++
+		}
+		rancher.Cases = append(rancher.Cases, tc)
+	}
+	rancher.Tests = len(rancher.Cases)
+
+	wrongRegistry := junitTestSuite{Name: "wrong-registry"}
+	for _, img := range report.WrongRegistry {
+		tc := junitTestCase{
+			Name:      img,
+			ClassName: "verifyChartImages.wrongRegistry",
+			Failure:   &junitMessage{Message: "image does not use a rancher/ repository"},
+		}
+		if owners := report.Owners[img]; len(owners) > 0 {
+			tc.SystemOut = "used by: " + strings.Join(owners, ", ")
+		}
+		wrongRegistry.Cases = append(wrongRegistry.Cases, tc)
+	}
+	wrongRegistry.Tests = len(wrongRegistry.Cases)
+	wrongRegistry.Failures = len(wrongRegistry.Cases)
+
+	manual := junitTestSuite{Name: "needs-manual-check"}
+	for _, img := range report.NeedsManualCheck {
+		manual.Cases = append(manual.Cases, junitTestCase{
+			Name:      img,
+			ClassName: "verifyChartImages.needsManualCheck",
+			Skipped:   &junitMessage{Message: "image is templated and must be checked manually"},
+		})
+	}
+	manual.Tests = len(manual.Cases)
+	manual.Skipped = len(manual.Cases)
+
+	data, err := xml.MarshalIndent(junitTestSuites{Suites: []junitTestSuite{rancher, wrongRegistry, manual}}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JUnit XML: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, data)
+	return err
+}
+
+func writeMarkdown(w io.Writer, report *Report) error {
+	var b strings.Builder
+	passed, failed := report.CountResults()
+
+	b.WriteString("## Chart Image Verification\n\n")
+	if report.Success {
+		b.WriteString("**Result:** ✅ passed\n\n")
+	} else {
+		b.WriteString("**Result:** ❌ failed\n\n")
+	}
+	fmt.Fprintf(&b, "%d passed, %d failed, %d wrong registry, %d need manual check\n",
+		passed, failed, len(report.WrongRegistry), len(report.NeedsManualCheck))
+
+	if len(report.RancherImages) > 0 {
+		b.WriteString("\n### Rancher Images\n\n")
+		b.WriteString("| Image | Status | Platforms | Used By |\n")
+		b.WriteString("| --- | --- | --- | --- |\n")
+		for _, img := range report.RancherImages {
+			status := string(img.Status)
+			if !img.Passed {
+				status = "❌ " + imageFailureMessage(img)
+			} else {
+				status = "✅ " + status
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", img.Image, escapeMarkdownCell(status),
+				strings.Join(img.Platforms, ", "), strings.Join(report.Owners[img.Image], ", "))
+		}
+	}
+
+	writeMarkdownList(&b, "Wrong Source Registry", report.WrongRegistry, report.Owners)
+	writeMarkdownList(&b, "Needs Manual Check", report.NeedsManualCheck, report.Owners)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeMarkdownList(b *strings.Builder, title string, images []string, owners map[string][]string) {
+	if len(images) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n### %s\n\n", title)
+	for _, img := range images {
+		if refs := owners[img]; len(refs) > 0 {
+			fmt.Fprintf(b, "- `%s` (%s)\n", img, strings.Join(refs, ", "))
+		} else {
+			fmt.Fprintf(b, "- `%s`\n", img)
+		}
+	}
+}
+
+// imageFailureMessage explains why an image did not pass.
+func imageFailureMessage(img ImageReport) string {
+	switch {
+	case len(img.MissingPlatforms) > 0:
+		return "missing platforms: " + strings.Join(img.MissingPlatforms, ", ")
+	case img.Status == registry.StatusFound:
+		return "platforms unknown: " + img.Error
+	case img.Error != "" && img.Status != registry.StatusMissing:
+		return fmt.Sprintf("%s: %s", img.Status, img.Error)
+	default:
+		return string(img.Status)
+	}
+}
+
+func escapeMarkdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// StatusIcon renders an image check status for results tables.
+func StatusIcon(status registry.ImageStatus) string {
+	switch status {
+	case registry.StatusFound:
+		return "✅"
+	case registry.StatusMissing:
+		return "❌"
+	case registry.StatusUnauthorized:
+		return "🔒 unauthorized"
+	case registry.StatusRateLimited:
+		return "⏳ rate limited"
+	default:
+		return "⚠️ network error"
+	}
+}
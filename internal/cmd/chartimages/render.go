@@ -0,0 +1,58 @@
+package chartimages
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/rancher/ob-charts-tool/helmtools/image"
+	"github.com/rancher/ob-charts-tool/helmtools/render"
+)
+
+// ResolveChartDir returns the directory of a built chart. target may be a path to a
+// chart directory, or a version under <chartsDir>/<chartName>/.
+func ResolveChartDir(chartsDir, chartName, target string) (string, error) {
+	if _, err := os.Stat(filepath.Join(target, "Chart.yaml")); err == nil {
+		return target, nil
+	}
+
+	chartDir := filepath.Join(chartsDir, chartName, target)
+	if _, err := os.Stat(filepath.Join(chartDir, "Chart.yaml")); err != nil {
+		return "", fmt.Errorf("cannot find a %s chart with the provided version (%s) in %s", chartName, target, chartsDir)
+	}
+	return chartDir, nil
+}
+
+// RenderChart renders the chart at chartDir in-process and returns the manifests
+// as a single YAML stream, like `helm template` would print.
+func RenderChart(chartDir string, opts render.Options) (string, error) {
+	result, err := render.RenderDir(chartDir, opts)
+	if err != nil {
+		return "", err
+	}
+	return result.String(), nil
+}
+
+// ChartDirImages extracts the images from the values.yaml of the chart at
+// chartDir and of every vendored subchart. Each image's sources name the charts
+// defining it as "<chart>:<version>".
+func ChartDirImages(chartDir string) (map[string]*image.ImageReference, error) {
+	chrt, err := render.LoadChart(chartDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []map[string]*image.ImageReference
+	for _, valuesFile := range render.ValuesFiles(chrt) {
+		if len(valuesFile.Data) == 0 {
+			continue
+		}
+		source := valuesFile.Chart + ":" + valuesFile.Version
+		refs, err := image.ExtractImagesWithSources(valuesFile.Data, source, valuesFile.AppVersion)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract %s images: %w", source, err)
+		}
+		found = append(found, refs)
+	}
+	return image.MergeImageSources(found...), nil
+}
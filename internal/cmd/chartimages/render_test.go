@@ -0,0 +1,25 @@
+package chartimages
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveChartDir(t *testing.T) {
+	chartsDir := t.TempDir()
+	for _, dir := range []string{"rancher-monitoring/108.0.0", "rancher-logging/108.0.0"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(chartsDir, dir), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(chartsDir, dir, "Chart.yaml"), []byte("name: x\n"), 0o644))
+	}
+
+	chartDir, err := ResolveChartDir(chartsDir, "rancher-monitoring", "108.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(chartsDir, "rancher-monitoring", "108.0.0"), chartDir)
+
+	_, err = ResolveChartDir(chartsDir, "rancher-monitoring", "107.0.0")
+	assert.Error(t, err)
+}
@@ -0,0 +1,101 @@
+package chartimages
+
+import (
+	"sort"
+
+	"github.com/rancher/ob-charts-tool/helmtools/registry"
+)
+
+// Report is the typed result of verifying a rendered chart's images.
+type Report struct {
+	Success          bool          `json:"success" yaml:"success"`
+	RancherImages    []ImageReport `json:"rancherImages" yaml:"rancherImages"`
+	NeedsManualCheck []string      `json:"needsManualCheck" yaml:"needsManualCheck"`
+	WrongRegistry    []string      `json:"wrongRegistry" yaml:"wrongRegistry"`
+	// Owners maps each image to the resources referencing it, formatted as "Kind/namespace/name".
+	Owners map[string][]string `json:"owners,omitempty" yaml:"owners,omitempty"`
+}
+
+// ImageReport is the verification outcome for a single Rancher image.
+type ImageReport struct {
+	Image            string               `json:"image" yaml:"image"`
+	Status           registry.ImageStatus `json:"status" yaml:"status"`
+	Passed           bool                 `json:"passed" yaml:"passed"`
+	Digest           string               `json:"digest,omitempty" yaml:"digest,omitempty"`
+	Platforms        []string             `json:"platforms,omitempty" yaml:"platforms,omitempty"`
+	MissingPlatforms []string             `json:"missingPlatforms,omitempty" yaml:"missingPlatforms,omitempty"`
+	Error            string               `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// NewReport builds a Report from the extracted image lists and the registry checks of the Rancher images.
+// The report succeeds only when every Rancher image passed and no image uses the wrong source registry.
+func NewReport(lists ImageLists, checks []ImageCheck) *Report {
+	report := &Report{
+		RancherImages:    make([]ImageReport, 0, len(checks)),
+		NeedsManualCheck: sortedValues(lists.NeedsManualCheck.Values()),
+		WrongRegistry:    sortedValues(lists.NonRancherImages.Values()),
+		Success:          lists.NonRancherImages.IsEmpty(),
+	}
+	if len(lists.Owners) > 0 {
+		report.Owners = make(map[string][]string, len(lists.Owners))
+		for img, refs := range lists.Owners {
+			report.Owners[img] = ownerStrings(refs)
+		}
+	}
+
+	for _, check := range checks {
+		img := ImageReport{
+			Image:  check.Image,
+			Status: check.Status,
+			Passed: check.Passed(),
+			Digest: check.Digest,
+		}
+		if check.Err != nil {
+			img.Error = check.Err.Error()
+		}
+		if check.Coverage != nil {
+			if check.Coverage.Err != nil {
+				img.Error = check.Coverage.Err.Error()
+			}
+			img.Platforms = platformStrings(check.Coverage.Platforms)
+			img.MissingPlatforms = platformStrings(check.Coverage.Missing)
+		}
+		if !img.Passed {
+			report.Success = false
+		}
+		report.RancherImages = append(report.RancherImages, img)
+	}
+
+	return report
+}
+
+// CountResults returns the number of Rancher images that passed and failed.
+func (r *Report) CountResults() (passed, failed int) {
+	for _, img := range r.RancherImages {
+		if img.Passed {
+			passed++
+		} else {
+			failed++
+		}
+	}
+	return passed, failed
+}
+
+func platformStrings(platforms []registry.Platform) []string {
+	if len(platforms) == 0 {
+		return nil
+	}
+	out := make([]string, len(platforms))
+	for i, p := range platforms {
+		out[i] = p.String()
+	}
+	return out
+}
+
+func sortedValues(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	sort.Strings(values)
+	return values
+}
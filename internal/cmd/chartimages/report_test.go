@@ -0,0 +1,135 @@
+package chartimages
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"testing"
+
+	"github.com/rancher/ob-charts-tool/helmtools/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v3"
+)
+
+func testImageLists(nonRancher ...string) ImageLists {
+	lists := newImageLists()
+	lists.RancherImages.Add("rancher/mirrored-app:v1")
+	lists.NeedsManualCheck.Add("{{ .Values.image }}")
+	for _, img := range nonRancher {
+		lists.NonRancherImages.Add(img)
+	}
+	return lists
+}
+
+func TestNewReport(t *testing.T) {
+	found := ImageCheck{
+		CheckResult: registry.CheckResult{Image: "rancher/mirrored-app:v1", Status: registry.StatusFound, Digest: "sha256:aaa"},
+		Coverage: &registry.PlatformCoverage{
+			Image:     "rancher/mirrored-app:v1",
+			Platforms: []registry.Platform{{OS: "linux", Architecture: "amd64"}, {OS: "linux", Architecture: "arm64"}},
+		},
+	}
+	noArm := ImageCheck{
+		CheckResult: registry.CheckResult{Image: "rancher/mirrored-amd64:v1", Status: registry.StatusFound},
+		Coverage: &registry.PlatformCoverage{
+			Platforms: []registry.Platform{{OS: "linux", Architecture: "amd64"}},
+			Missing:   []registry.Platform{{OS: "linux", Architecture: "arm64"}},
+		},
+	}
+	missing := ImageCheck{
+		CheckResult: registry.CheckResult{Image: "rancher/mirrored-gone:v1", Status: registry.StatusMissing, Err: errors.New("manifest not found")},
+	}
+
+	tests := []struct {
+		name        string
+		lists       ImageLists
+		checks      []ImageCheck
+		wantSuccess bool
+	}{
+		{name: "all found", lists: testImageLists(), checks: []ImageCheck{found}, wantSuccess: true},
+		{name: "missing image", lists: testImageLists(), checks: []ImageCheck{found, missing}},
+		{name: "missing platform", lists: testImageLists(), checks: []ImageCheck{noArm}},
+		{name: "wrong registry", lists: testImageLists("quay.io/prometheus/prometheus:v3"), checks: []ImageCheck{found}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewReport(tt.lists, tt.checks)
+			assert.Equal(t, tt.wantSuccess, report.Success)
+			assert.Len(t, report.RancherImages, len(tt.checks))
+			assert.Equal(t, []string{"{{ .Values.image }}"}, report.NeedsManualCheck)
+		})
+	}
+
+	report := NewReport(testImageLists(), []ImageCheck{found, noArm, missing})
+	assert.Equal(t, []string{"linux/amd64", "linux/arm64"}, report.RancherImages[0].Platforms)
+	assert.Equal(t, []string{"linux/arm64"}, report.RancherImages[1].MissingPlatforms)
+	assert.Equal(t, "manifest not found", report.RancherImages[2].Error)
+	passed, failed := report.CountResults()
+	assert.Equal(t, 1, passed)
+	assert.Equal(t, 2, failed)
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	format, err := ParseOutputFormat("JSON")
+	require.NoError(t, err)
+	assert.Equal(t, OutputJSON, format)
+	assert.True(t, format.IsMachineReadable())
+
+	format, err = ParseOutputFormat("table")
+	require.NoError(t, err)
+	assert.False(t, format.IsMachineReadable())
+
+	_, err = ParseOutputFormat("csv")
+	assert.Error(t, err)
+}
+
+func TestWriteReport(t *testing.T) {
+	report := NewReport(testImageLists("quay.io/prometheus/prometheus:v3"), []ImageCheck{
+		{CheckResult: registry.CheckResult{Image: "rancher/mirrored-app:v1", Status: registry.StatusFound}},
+		{CheckResult: registry.CheckResult{Image: "rancher/mirrored-gone:v1", Status: registry.StatusMissing}},
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteReport(&buf, report, OutputJSON))
+		var decoded Report
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+		assert.Equal(t, *report, decoded)
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteReport(&buf, report, OutputYAML))
+		var decoded Report
+		require.NoError(t, yaml.Unmarshal(buf.Bytes(), &decoded))
+		assert.Equal(t, *report, decoded)
+	})
+
+	t.Run("junit", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteReport(&buf, report, OutputJUnit))
+		var decoded junitTestSuites
+		require.NoError(t, xml.Unmarshal(buf.Bytes(), &decoded))
+		require.Len(t, decoded.Suites, 3)
+		assert.Equal(t, 2, decoded.Suites[0].Tests)
+		assert.Equal(t, 1, decoded.Suites[0].Failures)
+		assert.Equal(t, 1, decoded.Suites[1].Failures)
+		assert.Equal(t, 1, decoded.Suites[2].Skipped)
+	})
+
+	t.Run("markdown", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteReport(&buf, report, OutputMarkdown))
+		out := buf.String()
+		assert.Contains(t, out, "**Result:** ❌ failed")
+		assert.Contains(t, out, "| `rancher/mirrored-gone:v1` | ❌ missing |")
+		assert.Contains(t, out, "### Wrong Source Registry\n\n- `quay.io/prometheus/prometheus:v3`")
+	})
+
+	t.Run("table is not a report format", func(t *testing.T) {
+		assert.Error(t, WriteReport(&bytes.Buffer{}, report, OutputTable))
+	})
+}
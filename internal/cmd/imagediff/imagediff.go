@@ -0,0 +1,140 @@
+package imagediff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/rancher/ob-charts-tool/helmtools/image"
+	"github.com/rancher/ob-charts-tool/internal/cmd/chartimages"
+	"github.com/rancher/ob-charts-tool/internal/rebase"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Change is a single image difference between two chart versions.
+type Change struct {
+	Kind   image.ChangeKind `json:"kind" yaml:"kind"`
+	Image  string           `json:"image" yaml:"image"`
+	OldTag string           `json:"oldTag,omitempty" yaml:"oldTag,omitempty"`
+	NewTag string           `json:"newTag,omitempty" yaml:"newTag,omitempty"`
+	// Bump classifies the tag change of retagged images (major, minor, patch, downgrade, ...).
+	Bump    string   `json:"bump,omitempty" yaml:"bump,omitempty"`
+	OS      string   `json:"os" yaml:"os"`
+	Sources []string `json:"sources" yaml:"sources"`
+}
+
+// Diff is the typed result of comparing the images of two chart versions.
+type Diff struct {
+	Old     string   `json:"old" yaml:"old"`
+	New     string   `json:"new" yaml:"new"`
+	Changes []Change `json:"changes" yaml:"changes"`
+}
+
+// LoadImages reads the images of a chart version. target may be a rebase.yaml
+// file, a chart directory, or a version under <chartsDir>/<chartName>/.
+func LoadImages(chartsDir, chartName, target string) (map[string]*image.ImageReference, error) {
+	if info, err := os.Stat(target); err == nil && !info.IsDir() {
+		rebaseInfo, err := rebase.LoadRebaseYaml(target)
+		if err != nil {
+			return nil, err
+		}
+		return rebaseInfo.ImageReferences(), nil
+	}
+
+	chartDir, err := chartimages.ResolveChartDir(chartsDir, chartName, target)
+	if err != nil {
+		return nil, err
+	}
+	return chartimages.ChartDirImages(chartDir)
+}
+
+// NewDiff compares two image sets with image.DiffImages.
+func NewDiff(oldLabel, newLabel string, oldImages, newImages map[string]*image.ImageReference) *Diff {
+	diff := &Diff{Old: oldLabel, New: newLabel, Changes: []Change{}}
+	for _, change := range image.DiffImages(oldImages, newImages) {
+		c := Change{Kind: change.Kind, Image: change.Name, Bump: string(change.Bump)}
+		for _, ref := range []*image.ImageReference{change.Old, change.New} {
+			if ref == nil {
+				continue
+			}
+			c.OS = ref.OS
+			for _, source := range ref.Sources {
+				if !slices.Contains(c.Sources, source) {
+					c.Sources = append(c.Sources, source)
+				}
+			}
+		}
+		if change.Old != nil {
+			c.OldTag = change.Old.Image.Tag
+		}
+		if change.New != nil {
+			c.NewTag = change.New.Image.Tag
+		}
+		slices.Sort(c.Sources)
+		diff.Changes = append(diff.Changes, c)
+	}
+	return diff
+}
+
+// Counts returns the number of changes per kind.
+func (d *Diff) Counts() map[image.ChangeKind]int {
+	counts := make(map[image.ChangeKind]int)
+	for _, change := range d.Changes {
+		counts[change.Kind]++
+	}
+	return counts
+}
+
+// WriteDiff writes the diff as JSON, YAML or Markdown.
+// OutputTable is rendered by the command itself; JUnit is not supported.
+func WriteDiff(w io.Writer, diff *Diff, format chartimages.OutputFormat) error {
+	switch format {
+	case chartimages.OutputJSON:
+		data, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case chartimages.OutputYAML:
+		data, err := yaml.Marshal(diff)
+		if err != nil {
+			return fmt.Errorf("failed to marshal YAML: %w", err)
+		}
+		_, err = w.Write(data)
+		return err
+	case chartimages.OutputMarkdown:
+		return writeMarkdown(w, diff)
+	default:
+		return fmt.Errorf("output format %q is not supported for image diffs", format)
+	}
+}
+
+func writeMarkdown(w io.Writer, diff *Diff) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Image changes: `%s` → `%s`\n\n", diff.Old, diff.New)
+	if len(diff.Changes) == 0 {
+		b.WriteString("No image changes.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	b.WriteString("| Change | Image | Old Tag | New Tag | Bump | OS | Sources |\n")
+	b.WriteString("|---|---|---|---|---|---|---|\n")
+	for _, c := range diff.Changes {
+		fmt.Fprintf(&b, "| %s | `%s` | %s | %s | %s | %s | %s |\n",
+			c.Kind, c.Image, orDash(c.OldTag), orDash(c.NewTag), orDash(c.Bump), c.OS, strings.Join(c.Sources, ", "))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,99 @@
+package imagediff
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancher/ob-charts-tool/helmtools/image"
+	"github.com/rancher/ob-charts-tool/internal/cmd/chartimages"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o644))
+	}
+}
+
+func TestLoadImages_ChartDir(t *testing.T) {
+	chartsDir := t.TempDir()
+	writeFiles(t, chartsDir, map[string]string{
+		"rancher-monitoring/108.0.0/Chart.yaml": "apiVersion: v2\nname: rancher-monitoring\nversion: 108.0.0\nappVersion: v0.79.2\n",
+		"rancher-monitoring/108.0.0/values.yaml": `prometheusOperator:
+  image:
+    repository: rancher/mirrored-prometheus-operator
+    tag: ""
+`,
+		"rancher-monitoring/108.0.0/charts/windowsExporter/Chart.yaml": "apiVersion: v2\nname: windowsExporter\nversion: 0.8.0\n",
+		"rancher-monitoring/108.0.0/charts/windowsExporter/values.yaml": `image:
+  repository: rancher/windows_exporter-package
+  tag: v0.0.4
+`,
+	})
+
+	images, err := LoadImages(chartsDir, "rancher-monitoring", "108.0.0")
+	require.NoError(t, err)
+	require.Len(t, images, 2)
+
+	operator := images["rancher/mirrored-prometheus-operator:v0.79.2"]
+	require.NotNil(t, operator)
+	assert.Equal(t, []string{"rancher-monitoring:108.0.0"}, operator.Sources)
+
+	exporter := images["rancher/windows_exporter-package:v0.0.4"]
+	require.NotNil(t, exporter)
+	assert.Equal(t, "windows", exporter.OS)
+	assert.Equal(t, []string{"windowsExporter:0.8.0"}, exporter.Sources)
+
+	_, err = LoadImages(chartsDir, "rancher-monitoring", "999.0.0")
+	assert.Error(t, err)
+}
+
+func TestNewDiffAndWriteDiff(t *testing.T) {
+	ref := func(repository, tag, source string) *image.ImageReference {
+		return &image.ImageReference{
+			Image:   image.Image{Repository: repository, Tag: tag},
+			Sources: []string{source},
+			OS:      "linux",
+			OSList:  []string{"linux"},
+		}
+	}
+	oldImages := map[string]*image.ImageReference{
+		"a": ref("quay.io/prometheus/prometheus", "v3.1.0", "kube-prometheus-stack:68.0.0"),
+		"b": ref("bats/bats", "v1.4.1", "grafana:8.0.0"),
+	}
+	newImages := map[string]*image.ImageReference{
+		"a": ref("quay.io/prometheus/prometheus", "v4.0.0", "kube-prometheus-stack:69.0.0"),
+	}
+
+	diff := NewDiff("old", "new", oldImages, newImages)
+	assert.Equal(t, []Change{
+		{Kind: image.ChangeRemoved, Image: "docker.io/bats/bats", OldTag: "v1.4.1", OS: "linux", Sources: []string{"grafana:8.0.0"}},
+		{
+			Kind:    image.ChangeRetagged,
+			Image:   "quay.io/prometheus/prometheus",
+			OldTag:  "v3.1.0",
+			NewTag:  "v4.0.0",
+			Bump:    "major",
+			OS:      "linux",
+			Sources: []string{"kube-prometheus-stack:68.0.0", "kube-prometheus-stack:69.0.0"},
+		},
+	}, diff.Changes)
+	assert.Equal(t, map[image.ChangeKind]int{image.ChangeRemoved: 1, image.ChangeRetagged: 1}, diff.Counts())
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteDiff(&buf, diff, chartimages.OutputMarkdown))
+	assert.Contains(t, buf.String(), "| retagged | `quay.io/prometheus/prometheus` | v3.1.0 | v4.0.0 | major | linux |")
+	assert.Contains(t, buf.String(), "| removed | `docker.io/bats/bats` | v1.4.1 | - | - |")
+
+	buf.Reset()
+	require.NoError(t, WriteDiff(&buf, diff, chartimages.OutputJSON))
+	assert.Contains(t, buf.String(), `"bump": "major"`)
+
+	assert.Error(t, WriteDiff(&buf, diff, chartimages.OutputJUnit))
+}
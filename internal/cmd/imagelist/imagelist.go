@@ -0,0 +1,109 @@
+package imagelist
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rancher/ob-charts-tool/helmtools/image"
+	"github.com/rancher/ob-charts-tool/internal/cmd/chartimages"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	// ImagesFileName lists one image per line, like rancher-images.txt.
+	ImagesFileName = "images.txt"
+	// SourcesFileName maps each image to the charts that reference it.
+	SourcesFileName = "sources.yaml"
+)
+
+// SupportedOS lists the values accepted by Filter.
+var SupportedOS = []string{"linux", "windows"}
+
+// Collect extracts the images from every values.yaml of the charts at chartDirs,
+// including their subcharts. Images are keyed by their familiar reference (see
+// image.Familiarize) so "docker.io/rancher/x:v1" and "rancher/x:v1" are merged.
+func Collect(chartDirs ...string) (map[string]*image.ImageReference, error) {
+	var found []map[string]*image.ImageReference
+	for _, chartDir := range chartDirs {
+		refs, err := chartimages.ChartDirImages(chartDir)
+		if err != nil {
+			return nil, err
+		}
+		for key, ref := range refs {
+			// One map per image lets MergeImageSources combine keys that familiarize to the same name.
+			found = append(found, map[string]*image.ImageReference{image.Familiarize(key): ref})
+		}
+	}
+	return image.MergeImageSources(found...), nil
+}
+
+// Filter returns the images that support osName ("linux" or "windows").
+// An empty osName keeps every image.
+func Filter(refs map[string]*image.ImageReference, osName string) (map[string]*image.ImageReference, error) {
+	if osName == "" {
+		return refs, nil
+	}
+	osName = strings.ToLower(osName)
+	if osName != "linux" && osName != "windows" {
+		return nil, fmt.Errorf("unknown OS %q (expected one of: %s)", osName, strings.Join(SupportedOS, ", "))
+	}
+
+	filtered := make(map[string]*image.ImageReference)
+	for key, ref := range refs {
+		if ref.SupportsOS(osName) {
+			filtered[key] = ref
+		}
+	}
+	return filtered, nil
+}
+
+// Images returns the sorted image list.
+func Images(refs map[string]*image.ImageReference) []string {
+	images := make([]string, 0, len(refs))
+	for key := range refs {
+		images = append(images, key)
+	}
+	sort.Strings(images)
+	return images
+}
+
+// Sources maps each image to the sorted "<chart>:<version>" sources referencing it.
+func Sources(refs map[string]*image.ImageReference) map[string][]string {
+	sources := make(map[string][]string, len(refs))
+	for key, ref := range refs {
+		chartSources := append([]string(nil), ref.Sources...)
+		sort.Strings(chartSources)
+		sources[key] = chartSources
+	}
+	return sources
+}
+
+// Write saves ImagesFileName and SourcesFileName to outputDir and returns their paths.
+func Write(outputDir string, refs map[string]*image.ImageReference) (string, string, error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	imagesPath := filepath.Join(outputDir, ImagesFileName)
+	var b strings.Builder
+	for _, img := range Images(refs) {
+		b.WriteString(img + "\n")
+	}
+	if err := os.WriteFile(imagesPath, []byte(b.String()), 0644); err != nil {
+		return "", "", fmt.Errorf("failed to write %s: %w", imagesPath, err)
+	}
+
+	sourcesPath := filepath.Join(outputDir, SourcesFileName)
+	data, err := yaml.Marshal(Sources(refs))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal image sources: %w", err)
+	}
+	if err := os.WriteFile(sourcesPath, data, 0644); err != nil {
+		return "", "", fmt.Errorf("failed to write %s: %w", sourcesPath, err)
+	}
+	return imagesPath, sourcesPath, nil
+}
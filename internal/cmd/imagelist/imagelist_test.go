@@ -0,0 +1,99 @@
+package imagelist
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancher/ob-charts-tool/helmtools/chart"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o644))
+	}
+}
+
+func testCharts(t *testing.T) string {
+	t.Helper()
+	chartsDir := t.TempDir()
+	writeFiles(t, chartsDir, map[string]string{
+		"rancher-monitoring/108.0.0/Chart.yaml": "apiVersion: v2\nname: rancher-monitoring\nversion: 108.0.0\nappVersion: v0.79.2\n",
+		"rancher-monitoring/108.0.0/values.yaml": `prometheusOperator:
+  image:
+    repository: rancher/mirrored-prometheus-operator
+shell:
+  image:
+    repository: docker.io/rancher/shell
+    tag: v0.3.0
+`,
+		"rancher-monitoring/108.0.0/charts/grafana/Chart.yaml": "apiVersion: v2\nname: grafana\nversion: 8.8.2\n",
+		"rancher-monitoring/108.0.0/charts/grafana/values.yaml": `image:
+  repository: rancher/mirrored-grafana-grafana
+  tag: 11.4.0
+kubectl:
+  image:
+    repository: rancher/shell
+    tag: v0.3.0
+`,
+		"rancher-monitoring/108.0.0/charts/windowsExporter/Chart.yaml": "apiVersion: v2\nname: windowsExporter\nversion: 0.8.0\n",
+		"rancher-monitoring/108.0.0/charts/windowsExporter/values.yaml": `image:
+  repository: rancher/windows_exporter-package
+  tag: v0.0.4
+  os: windows
+`,
+		"rancher-monitoring-crd/108.0.0/Chart.yaml": "apiVersion: v2\nname: rancher-monitoring-crd\nversion: 108.0.0\n",
+		"rancher-monitoring-crd/108.0.0/values.yaml": `image:
+  repository: rancher/shell
+  tag: v0.3.0
+`,
+	})
+	return chartsDir
+}
+
+func TestCollectFilterAndWrite(t *testing.T) {
+	chartsDir := testCharts(t)
+	chartDir := filepath.Join(chartsDir, "rancher-monitoring", "108.0.0")
+	crdDir, ok := chart.CompanionCRDChartDir(chartDir)
+	require.True(t, ok)
+
+	refs, err := Collect(chartDir, crdDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"rancher/mirrored-grafana-grafana:11.4.0",
+		"rancher/mirrored-prometheus-operator:v0.79.2",
+		"rancher/shell:v0.3.0",
+		"rancher/windows_exporter-package:v0.0.4",
+	}, Images(refs))
+	assert.Equal(t, []string{
+		"grafana:8.8.2",
+		"rancher-monitoring-crd:108.0.0",
+		"rancher-monitoring:108.0.0",
+	}, Sources(refs)["rancher/shell:v0.3.0"])
+
+	windows, err := Filter(refs, "windows")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"rancher/windows_exporter-package:v0.0.4"}, Images(windows))
+
+	linux, err := Filter(refs, "Linux")
+	require.NoError(t, err)
+	assert.Len(t, linux, 3)
+
+	_, err = Filter(refs, "darwin")
+	assert.Error(t, err)
+
+	outputDir := filepath.Join(t.TempDir(), "out")
+	imagesPath, sourcesPath, err := Write(outputDir, windows)
+	require.NoError(t, err)
+	data, err := os.ReadFile(imagesPath)
+	require.NoError(t, err)
+	assert.Equal(t, "rancher/windows_exporter-package:v0.0.4\n", string(data))
+	data, err = os.ReadFile(sourcesPath)
+	require.NoError(t, err)
+	assert.Equal(t, "rancher/windows_exporter-package:v0.0.4:\n    - windowsExporter:0.8.0\n", string(data))
+}
@@ -0,0 +1,193 @@
+package imagemirror
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/rancher/ob-charts-tool/helmtools/image"
+	"github.com/rancher/ob-charts-tool/helmtools/registry"
+	"github.com/rancher/ob-charts-tool/internal/config"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Rules holds the upstream → Rancher naming table used to build image-mirror entries.
+type Rules struct {
+	// Prefix is prepended to the flattened upstream repository path.
+	Prefix string `yaml:"prefix"`
+	// Overrides replace the default naming for specific upstream repositories.
+	Overrides []config.MirrorRule `yaml:"overrides"`
+}
+
+// DefaultRules returns the naming table from internal/config.
+func DefaultRules() Rules {
+	return Rules{
+		Prefix:    config.MirroredImagePrefix,
+		Overrides: append([]config.MirrorRule(nil), config.MirrorRules...),
+	}
+}
+
+// LoadRules reads a YAML naming table from path and merges it over DefaultRules:
+// a non-empty prefix replaces the default, and overrides for the same source
+// replace the default rule.
+func LoadRules(path string) (Rules, error) {
+	rules := DefaultRules()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return rules, fmt.Errorf("failed to read mirror rules: %w", err)
+	}
+
+	var custom Rules
+	if err := yaml.Unmarshal(data, &custom); err != nil {
+		return rules, fmt.Errorf("failed to parse mirror rules %s: %w", path, err)
+	}
+	if custom.Prefix != "" {
+		rules.Prefix = custom.Prefix
+	}
+	for _, override := range custom.Overrides {
+		source, err := normalizeSource(override.Source)
+		if err != nil {
+			return rules, fmt.Errorf("invalid mirror rule in %s: %w", path, err)
+		}
+		override.Source = source
+		rules.Overrides = slices.DeleteFunc(rules.Overrides, func(rule config.MirrorRule) bool {
+			normalized, err := normalizeSource(rule.Source)
+			return err == nil && normalized == source
+		})
+		rules.Overrides = append(rules.Overrides, override)
+	}
+	return rules, nil
+}
+
+// Destination returns the Rancher repository an upstream image is mirrored as.
+// When the image is not mirrored, ok is false and reason explains why.
+func (r Rules) Destination(ref image.Reference) (destination string, reason string, ok bool) {
+	for _, override := range r.Overrides {
+		source, err := normalizeSource(override.Source)
+		if err != nil || source != ref.Name() {
+			continue
+		}
+		if override.Destination == "" {
+			return "", override.Reason, false
+		}
+		return override.Destination, "", true
+	}
+	if ref.Registry == image.DefaultRegistry && strings.HasPrefix(ref.Repository, "rancher/") {
+		return "", "already a Rancher image", false
+	}
+	return r.Prefix + strings.ReplaceAll(ref.Repository, "/", "-"), "", true
+}
+
+func normalizeSource(source string) (string, error) {
+	ref, err := image.ParseReference(source)
+	if err != nil {
+		return "", err
+	}
+	return ref.Name(), nil
+}
+
+// Entry is a single line of the rancher/image-mirror images-list file.
+type Entry struct {
+	Source      string `json:"source" yaml:"source"`
+	Destination string `json:"destination" yaml:"destination"`
+	Tag         string `json:"tag" yaml:"tag"`
+}
+
+// String formats the entry as an images-list line: "SOURCE DESTINATION TAG".
+func (e Entry) String() string {
+	return e.Source + " " + e.Destination + " " + e.Tag
+}
+
+// MirroredImage returns the fully qualified image the entry publishes.
+func (e Entry) MirroredImage() string {
+	return image.DefaultRegistry + "/" + e.Destination + ":" + e.Tag
+}
+
+// SkippedImage is an upstream image that gets no mirror entry.
+type SkippedImage struct {
+	Image  string `json:"image" yaml:"image"`
+	Reason string `json:"reason" yaml:"reason"`
+}
+
+// BuildEntries maps upstream images to image-mirror entries using rules.
+// Entries are deduplicated and sorted by source and tag.
+func BuildEntries(images []string, rules Rules) ([]Entry, []SkippedImage) {
+	seen := make(map[Entry]bool)
+	var entries []Entry
+	var skipped []SkippedImage
+	for _, img := range images {
+		ref, err := image.ParseReference(img)
+		if err != nil {
+			skipped = append(skipped, SkippedImage{Image: img, Reason: err.Error()})
+			continue
+		}
+		if ref.Tag == "" {
+			skipped = append(skipped, SkippedImage{Image: img, Reason: "no tag to mirror"})
+			continue
+		}
+		destination, reason, ok := rules.Destination(ref)
+		if !ok {
+			skipped = append(skipped, SkippedImage{Image: img, Reason: reason})
+			continue
+		}
+		entry := Entry{Source: ref.Name(), Destination: destination, Tag: ref.Tag}
+		if !seen[entry] {
+			seen[entry] = true
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Source != entries[j].Source {
+			return entries[i].Source < entries[j].Source
+		}
+		return entries[i].Tag < entries[j].Tag
+	})
+	sort.Slice(skipped, func(i, j int) bool { return skipped[i].Image < skipped[j].Image })
+	return entries, skipped
+}
+
+// ValuesImages returns the images defined in a values.yaml, using defaultTag
+// (usually the chart appVersion) for images without a tag.
+func ValuesImages(valuesData []byte, defaultTag string) ([]string, error) {
+	found, err := image.ExtractImages(valuesData, defaultTag)
+	if err != nil {
+		return nil, err
+	}
+	images := make([]string, 0, found.Size())
+	for img := range found {
+		images = append(images, img.String())
+	}
+	sort.Strings(images)
+	return images, nil
+}
+
+// EntryCheck is the registry result for an entry's mirrored image.
+type EntryCheck struct {
+	Entry
+	Result registry.CheckResult
+}
+
+// Missing reports whether the registry confirmed the mirrored image does not exist.
+func (c EntryCheck) Missing() bool {
+	return c.Result.Status == registry.StatusMissing
+}
+
+// CheckEntries looks up each entry's mirrored image using at most concurrency parallel requests.
+func CheckEntries(client *registry.Client, entries []Entry, concurrency int) []EntryCheck {
+	images := make([]string, len(entries))
+	for i, entry := range entries {
+		images[i] = entry.MirroredImage()
+	}
+	results := client.CheckImages(context.Background(), images, concurrency)
+
+	checks := make([]EntryCheck, len(entries))
+	for i, entry := range entries {
+		checks[i] = EntryCheck{Entry: entry, Result: results[i]}
+	}
+	return checks
+}
@@ -0,0 +1,115 @@
+package imagemirror
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancher/ob-charts-tool/helmtools/image"
+	"github.com/rancher/ob-charts-tool/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRules_Destination(t *testing.T) {
+	rules := Rules{
+		Prefix: "rancher/mirrored-",
+		Overrides: []config.MirrorRule{
+			{Source: "ghcr.io/prometheus-community/windows-exporter", Reason: "built by Rancher"},
+			{Source: "registry.k8s.io/kube-state-metrics/kube-state-metrics", Destination: "rancher/mirrored-kube-state-metrics"},
+		},
+	}
+
+	tests := []struct {
+		image      string
+		wantDest   string
+		wantReason string
+		wantOK     bool
+	}{
+		{image: "quay.io/prometheus/node-exporter:v1.8.2", wantDest: "rancher/mirrored-prometheus-node-exporter", wantOK: true},
+		{image: "grafana/grafana:11.4.0", wantDest: "rancher/mirrored-grafana-grafana", wantOK: true},
+		{image: "busybox:1.36", wantDest: "rancher/mirrored-library-busybox", wantOK: true},
+		{image: "registry.k8s.io/kube-state-metrics/kube-state-metrics:v2.14.0", wantDest: "rancher/mirrored-kube-state-metrics", wantOK: true},
+		{image: "ghcr.io/prometheus-community/windows-exporter:0.29.2", wantReason: "built by Rancher"},
+		{image: "rancher/shell:v0.3.0", wantReason: "already a Rancher image"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			dest, reason, ok := rules.Destination(image.MustParseReference(tt.image))
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantDest, dest)
+			assert.Equal(t, tt.wantReason, reason)
+		})
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mirror-rules.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`prefix: example/mirror-
+overrides:
+  - source: ghcr.io/prometheus-community/windows-exporter
+    destination: rancher/windows-exporter
+  - source: quay.io/thanos/thanos
+    destination: rancher/thanos
+`), 0o644))
+
+	rules, err := LoadRules(path)
+	require.NoError(t, err)
+	assert.Equal(t, "example/mirror-", rules.Prefix)
+
+	dest, _, ok := rules.Destination(image.MustParseReference("ghcr.io/prometheus-community/windows-exporter:0.29.2"))
+	assert.True(t, ok)
+	assert.Equal(t, "rancher/windows-exporter", dest)
+
+	dest, _, _ = rules.Destination(image.MustParseReference("quay.io/thanos/thanos:v0.37.2"))
+	assert.Equal(t, "rancher/thanos", dest)
+
+	dest, _, _ = rules.Destination(image.MustParseReference("quay.io/prometheus/prometheus:v3.1.0"))
+	assert.Equal(t, "example/mirror-prometheus-prometheus", dest)
+
+	// The built-in defaults are left untouched.
+	assert.Equal(t, config.MirrorRules, DefaultRules().Overrides)
+
+	_, err = LoadRules(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestBuildEntries(t *testing.T) {
+	entries, skipped := BuildEntries([]string{
+		"quay.io/prometheus/node-exporter:v1.8.2",
+		"quay.io/prometheus/prometheus:v3.1.0",
+		"quay.io/prometheus/node-exporter:v1.8.2",
+		"quay.io/prometheus/node-exporter:v1.8.1",
+		"rancher/shell:v0.3.0",
+		"nginx@sha256:abc",
+		"{{ .Values.image }}",
+	}, DefaultRules())
+
+	assert.Equal(t, []Entry{
+		{Source: "quay.io/prometheus/node-exporter", Destination: "rancher/mirrored-prometheus-node-exporter", Tag: "v1.8.1"},
+		{Source: "quay.io/prometheus/node-exporter", Destination: "rancher/mirrored-prometheus-node-exporter", Tag: "v1.8.2"},
+		{Source: "quay.io/prometheus/prometheus", Destination: "rancher/mirrored-prometheus-prometheus", Tag: "v3.1.0"},
+	}, entries)
+	assert.Equal(t, "quay.io/prometheus/node-exporter rancher/mirrored-prometheus-node-exporter v1.8.1", entries[0].String())
+	assert.Equal(t, "docker.io/rancher/mirrored-prometheus-node-exporter:v1.8.1", entries[0].MirroredImage())
+
+	require.Len(t, skipped, 3)
+	assert.Equal(t, SkippedImage{Image: "nginx@sha256:abc", Reason: "no tag to mirror"}, skipped[0])
+	assert.Equal(t, SkippedImage{Image: "rancher/shell:v0.3.0", Reason: "already a Rancher image"}, skipped[1])
+	assert.Equal(t, "{{ .Values.image }}", skipped[2].Image)
+}
+
+func TestValuesImages(t *testing.T) {
+	images, err := ValuesImages([]byte(`
+image:
+  registry: quay.io
+  repository: prometheus/node-exporter
+  tag: ""
+sidecar:
+  image:
+    repository: kiwigrid/k8s-sidecar
+    tag: 1.28.0
+`), "v1.8.2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kiwigrid/k8s-sidecar:1.28.0", "quay.io/prometheus/node-exporter:v1.8.2"}, images)
+}
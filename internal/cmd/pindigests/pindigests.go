@@ -0,0 +1,100 @@
+package pindigests
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/rancher/ob-charts-tool/helmtools/image"
+	"github.com/rancher/ob-charts-tool/helmtools/registry"
+	"github.com/rancher/ob-charts-tool/helmtools/render"
+	"github.com/rancher/ob-charts-tool/helmtools/util"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// RenderedImages returns the images referenced by rendered chart manifests as
+// fully qualified references without digest (see image.DigestKey), sorted and
+// deduplicated. Images that cannot be parsed or that are pinned by digest only
+// (so have no tag to resolve) are returned separately in skipped.
+func RenderedImages(rendered string) (names []string, skipped []string, err error) {
+	found, err := image.ExtractImagesFromManifests([]byte(rendered))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	images := util.NewSet[string]()
+	skippedSet := util.NewSet[string]()
+	for _, img := range found {
+		ref, err := image.ParseReference(img.Image)
+		if err != nil || (ref.Tag == "" && ref.Digest != "") {
+			skippedSet.Add(img.Image)
+			continue
+		}
+		images.Add(ref.WithDigest("").String())
+	}
+
+	names = images.Values()
+	skipped = skippedSet.Values()
+	sort.Strings(names)
+	sort.Strings(skipped)
+	return names, skipped, nil
+}
+
+// DigestMap collects the successfully resolved digests keyed by image.
+func DigestMap(results []registry.DigestResult) map[string]string {
+	digests := make(map[string]string, len(results))
+	for _, result := range results {
+		if result.Err == nil && result.Digest != "" {
+			digests[result.Image] = result.Digest
+		}
+	}
+	return digests
+}
+
+// FailedResults returns the results whose digest could not be resolved.
+func FailedResults(results []registry.DigestResult) []registry.DigestResult {
+	return util.FilterSlice(results, func(result registry.DigestResult) bool {
+		return result.Err != nil || result.Digest == ""
+	})
+}
+
+// ChartValuesOverlay builds a values overlay for the chart at chartDir that pins
+// the digest field of every image in its values.yaml, and in the values.yaml of
+// each vendored subchart, found in digests. It returns the overlay and the number
+// of pinned images.
+func ChartValuesOverlay(chartDir string, digests map[string]string) (map[string]interface{}, int, error) {
+	chrt, err := render.LoadChart(chartDir)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	overlay := make(map[string]interface{})
+	pinned := 0
+	for _, valuesFile := range render.ValuesFiles(chrt) {
+		if len(valuesFile.Data) == 0 {
+			continue
+		}
+		locations, err := image.ExtractImageLocations(valuesFile.Data, valuesFile.AppVersion)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to find %s images: %w", valuesFile.Chart, err)
+		}
+		pinned += image.PinDigests(overlay, valuesFile.Prefix, locations, digests)
+	}
+	return overlay, pinned, nil
+}
+
+// WriteValuesOverlay saves overlay as a values file at path, noting when the digests were resolved.
+func WriteValuesOverlay(path string, overlay map[string]interface{}, resolvedAt time.Time) error {
+	data, err := yaml.Marshal(overlay)
+	if err != nil {
+		return fmt.Errorf("failed to marshal values overlay: %w", err)
+	}
+
+	header := fmt.Sprintf("# Image digests resolved at %s.\n# Pass with -f to pin chart images to the content they had at that time.\n", resolvedAt.UTC().Format(time.RFC3339))
+	if err := os.WriteFile(path, append([]byte(header), data...), 0644); err != nil {
+		return fmt.Errorf("failed to write values overlay: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package pindigests
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rancher/ob-charts-tool/helmtools/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderedImages(t *testing.T) {
+	rendered := `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: operator
+spec:
+  template:
+    spec:
+      containers:
+        - name: operator
+          image: docker.io/rancher/mirrored-prometheus-operator:v0.79.2
+        - name: sidecar
+          image: rancher/mirrored-prometheus-operator:v0.79.2@sha256:abc
+        - name: pinned
+          image: rancher/shell@sha256:def
+        - name: templated
+          image: "{{ .Values.image }}"
+`
+	names, skipped, err := RenderedImages(rendered)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docker.io/rancher/mirrored-prometheus-operator:v0.79.2"}, names)
+	assert.Equal(t, []string{"rancher/shell@sha256:def", "{{ .Values.image }}"}, skipped)
+}
+
+func TestDigestMapAndFailedResults(t *testing.T) {
+	results := []registry.DigestResult{
+		{Image: "docker.io/rancher/a:v1", Digest: "sha256:aaa"},
+		{Image: "docker.io/rancher/b:v1", Err: errors.New("not found")},
+	}
+	assert.Equal(t, map[string]string{"docker.io/rancher/a:v1": "sha256:aaa"}, DigestMap(results))
+	assert.Equal(t, results[1:], FailedResults(results))
+}
+
+func TestChartValuesOverlay(t *testing.T) {
+	chartDir := t.TempDir()
+	files := map[string]string{
+		"Chart.yaml": `apiVersion: v2
+name: rancher-monitoring
+version: 108.0.0
+appVersion: v0.79.2
+dependencies:
+  - name: grafana
+    version: 8.0.0
+`,
+		"values.yaml": `prometheusOperator:
+  image:
+    repository: rancher/mirrored-prometheus-operator
+    tag: ""
+    sha: ""
+`,
+		"charts/grafana/Chart.yaml": "apiVersion: v2\nname: grafana\nversion: 8.0.0\nappVersion: 11.4.0\n",
+		"charts/grafana/values.yaml": `image:
+  registry: docker.io
+  repository: rancher/mirrored-grafana-grafana
+  digest: ""
+`,
+	}
+	for name, content := range files {
+		p := filepath.Join(chartDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o644))
+	}
+
+	overlay, pinned, err := ChartValuesOverlay(chartDir, map[string]string{
+		"docker.io/rancher/mirrored-prometheus-operator:v0.79.2": "sha256:aaa",
+		"docker.io/rancher/mirrored-grafana-grafana:11.4.0":      "sha256:bbb",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, pinned)
+	assert.Equal(t, map[string]interface{}{
+		"prometheusOperator": map[string]interface{}{"image": map[string]interface{}{"sha": "aaa"}},
+		"grafana":            map[string]interface{}{"image": map[string]interface{}{"digest": "sha256:bbb"}},
+	}, overlay)
+
+	path := filepath.Join(t.TempDir(), "digests.yaml")
+	require.NoError(t, WriteValuesOverlay(path, overlay, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "# Image digests resolved at 2026-01-02T03:04:05Z."))
+	assert.Contains(t, string(data), "sha: aaa")
+}
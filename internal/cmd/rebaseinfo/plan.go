@@ -0,0 +1,212 @@
+package rebaseinfo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/jedib0t/go-pretty/text"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/rancher/ob-charts-tool/helmtools/image"
+	"github.com/rancher/ob-charts-tool/internal/chartsrepo"
+	"github.com/rancher/ob-charts-tool/internal/rebase"
+	"github.com/rancher/ob-charts-tool/internal/upstream"
+	internalvalues "github.com/rancher/ob-charts-tool/internal/values"
+)
+
+// PlanRebase compares the upstream chart that a package of the charts repository at
+// repoPath currently ships with the rebase target. The current upstream chart is collected
+// at the package.yaml commit, with its dependencies pinned to the versions vendored in the
+// built chart, so this does the same web requests again.
+func PlanRebase(repoPath, packageName string, chart upstream.Chart, target rebase.ChartRebaseInfo) (*rebase.RebasePlan, error) {
+	pkgYAMLPath, pkgYAML, err := chartsrepo.CurrentPackage(repoPath, packageName)
+	if err != nil {
+		return nil, err
+	}
+	if pkgYAML.Commit == "" {
+		return nil, fmt.Errorf("%s has no upstream commit", pkgYAMLPath)
+	}
+
+	current := target
+	if pkgYAML.Commit != target.FoundChart.CommitHash {
+		shipped, err := shippedDependencyVersions(repoPath, packageName, pkgYAML.Version)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Println(text.Color.Sprintf(text.FgBlue, "Collecting the currently used upstream chart at `%s`...", pkgYAML.Commit))
+		rebaseRequest, err := rebase.NewRebaseRequest(chart, rebase.UpstreamVersion(pkgYAML.Version), "", pkgYAML.Commit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch the upstream chart at %s: %w", pkgYAML.Commit, err)
+		}
+		rebaseRequest.DependencyVersions = shipped
+		current = collectInfo(rebaseRequest)
+	}
+
+	relPath, err := filepath.Rel(repoPath, pkgYAMLPath)
+	if err != nil {
+		relPath = pkgYAMLPath
+	}
+	return newRebasePlan(filepath.ToSlash(relPath), pkgYAML, current, target)
+}
+
+// shippedDependencyVersions returns the versions of the subcharts of the built
+// charts/<packageName>/<version> chart, by normalized name. Subcharts vendored in charts/,
+// including nested ones, are read from their Chart.yaml; the Chart.lock covers the rest.
+func shippedDependencyVersions(repoPath, packageName, version string) (map[string]string, error) {
+	chartDir := filepath.Join(repoPath, "charts", packageName, version)
+	if _, err := os.Stat(filepath.Join(chartDir, "Chart.yaml")); err != nil {
+		return nil, fmt.Errorf("chart %s %s is not built in %s: %w", packageName, version, filepath.Join(repoPath, "charts"), err)
+	}
+
+	versions := make(map[string]string)
+	var lock struct {
+		Dependencies []rebase.ChartDep `yaml:"dependencies"`
+	}
+	data, err := os.ReadFile(filepath.Join(chartDir, "Chart.lock"))
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, &lock); err != nil {
+			return nil, fmt.Errorf("failed to parse the Chart.lock of %s %s: %w", packageName, version, err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("failed to read the Chart.lock of %s %s: %w", packageName, version, err)
+	}
+	for _, dep := range lock.Dependencies {
+		versions[internalvalues.NormalizeName(dep.Name)] = dep.Version
+	}
+
+	err = filepath.WalkDir(filepath.Join(chartDir, "charts"), func(path string, d os.DirEntry, err error) error {
+		if errors.Is(err, os.ErrNotExist) {
+			return filepath.SkipDir
+		}
+		if err != nil || d.IsDir() || d.Name() != "Chart.yaml" || filepath.Base(filepath.Dir(filepath.Dir(path))) != "charts" {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var meta struct {
+			Name    string `yaml:"name"`
+			Version string `yaml:"version"`
+		}
+		if err := yaml.Unmarshal(data, &meta); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if meta.Name == "" {
+			meta.Name = filepath.Base(filepath.Dir(path))
+		}
+		versions[internalvalues.NormalizeName(meta.Name)] = meta.Version
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// newRebasePlan builds the plan of rebasing the package at pkgYAMLPath from current onto target.
+func newRebasePlan(pkgYAMLPath string, pkgYAML chartsrepo.PackageYAML, current, target rebase.ChartRebaseInfo) (*rebase.RebasePlan, error) {
+	plan := &rebase.RebasePlan{
+		PackageYAML:    pkgYAMLPath,
+		CurrentVersion: pkgYAML.Version,
+		NewVersion:     pkgYAML.Version,
+		CurrentCommit:  pkgYAML.Commit,
+		NewCommit:      target.FoundChart.CommitHash,
+		ChartVersion:   rebase.VersionChange{From: current.FoundChart.ChartVersion, To: target.FoundChart.ChartVersion},
+		AppVersion:     rebase.VersionChange{From: current.FoundChart.AppVersion, To: target.FoundChart.AppVersion},
+		Dependencies:   []rebase.DependencyChange{},
+	}
+	if !plan.UpToDate() {
+		newVersion, err := rebase.NextPackageVersion(pkgYAML.Version, target.FoundChart.ChartVersion)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the new version of %s: %w", pkgYAMLPath, err)
+		}
+		plan.NewVersion = newVersion
+	}
+
+	dependencies := make(map[string]*rebase.DependencyChange)
+	var names []string
+	dependency := func(name string) *rebase.DependencyChange {
+		if dependencies[name] == nil {
+			dependencies[name] = &rebase.DependencyChange{Name: name}
+			names = append(names, name)
+		}
+		return dependencies[name]
+	}
+	for _, dep := range current.DependencyChartVersions {
+		change := dependency(dep.Name)
+		change.ChartVersion.From = dep.ChartVersion
+		change.AppVersion.From = dep.AppVersion
+	}
+	for _, dep := range target.DependencyChartVersions {
+		change := dependency(dep.Name)
+		change.ChartVersion.To = dep.ChartVersion
+		change.AppVersion.To = dep.AppVersion
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		plan.Dependencies = append(plan.Dependencies, *dependencies[name])
+	}
+
+	for _, change := range image.DiffImages(current.ImageReferences(), target.ImageReferences()) {
+		imageChange := rebase.ImageChange{Kind: string(change.Kind), Image: change.Name}
+		if change.Old != nil {
+			imageChange.OldTag = change.Old.Image.Tag
+		}
+		if change.New != nil {
+			imageChange.NewTag = change.New.Image.Tag
+		}
+		plan.Images = append(plan.Images, imageChange)
+	}
+	return plan, nil
+}
+
+// PrintPlan prints the rebase plan to the console.
+func PrintPlan(plan *rebase.RebasePlan) {
+	fmt.Println("")
+	if plan.UpToDate() {
+		fmt.Println(text.Color.Sprintf(text.FgGreen, "%s already uses the target upstream commit.", plan.PackageYAML))
+		return
+	}
+	fmt.Println(text.Color.Sprintf(text.FgYellow, "Rebase plan for %s:", plan.PackageYAML))
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"", "Current", "Target"})
+	t.AppendRow(table.Row{"package.yaml version", plan.CurrentVersion, plan.NewVersion})
+	t.AppendRow(table.Row{"package.yaml commit", plan.CurrentCommit, plan.NewCommit})
+	t.AppendRow(table.Row{"Chart version", plan.ChartVersion.From, plan.ChartVersion.To})
+	t.AppendRow(table.Row{"appVersion", plan.AppVersion.From, plan.AppVersion.To})
+	for _, dep := range plan.Dependencies {
+		t.AppendRow(table.Row{
+			dep.Name,
+			fmt.Sprintf("%s (app %s)", orNone(dep.ChartVersion.From), orNone(dep.AppVersion.From)),
+			fmt.Sprintf("%s (app %s)", orNone(dep.ChartVersion.To), orNone(dep.AppVersion.To)),
+		})
+	}
+	t.Render()
+
+	if len(plan.Images) == 0 {
+		fmt.Println("No image changes.")
+		return
+	}
+	images := table.NewWriter()
+	images.SetOutputMirror(os.Stdout)
+	images.AppendHeader(table.Row{"Change", "Image", "Old Tag", "New Tag"})
+	for _, change := range plan.Images {
+		images.AppendRow(table.Row{change.Kind, change.Image, change.OldTag, change.NewTag})
+	}
+	images.Render()
+}
+
+func orNone(version string) string {
+	if version == "" {
+		return "none"
+	}
+	return version
+}
@@ -0,0 +1,106 @@
+package rebaseinfo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rancher/ob-charts-tool/helmtools/util"
+	"github.com/rancher/ob-charts-tool/internal/chartsrepo"
+	"github.com/rancher/ob-charts-tool/internal/rebase"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestShippedDependencyVersions(t *testing.T) {
+	repo := t.TempDir()
+	chartDir := filepath.Join(repo, "charts/rancher-monitoring/77.9.1-rancher.3")
+	writeFile(t, filepath.Join(chartDir, "Chart.yaml"), "name: rancher-monitoring\nversion: 77.9.1-rancher.3\n")
+	writeFile(t, filepath.Join(chartDir, "Chart.lock"), `dependencies:
+- name: grafana
+  repository: https://grafana-community.github.io/helm-charts
+  version: 9.0.0
+- name: prometheus-windows-exporter
+  repository: https://prometheus-community.github.io/helm-charts
+  version: 0.11.0
+`)
+	writeFile(t, filepath.Join(chartDir, "charts/grafana/Chart.yaml"), "name: grafana\nversion: 9.0.1\n")
+	writeFile(t, filepath.Join(chartDir, "charts/grafana/charts/common/Chart.yaml"), "name: common\nversion: 2.3.0\n")
+	writeFile(t, filepath.Join(chartDir, "charts/rancher-node-exporter/Chart.yaml"), "name: rancher-node-exporter\nversion: 4.47.0\n")
+	writeFile(t, filepath.Join(chartDir, "charts/grafana/templates/Chart.yaml"), "name: not-a-subchart\nversion: 0.0.1\n")
+
+	got, err := shippedDependencyVersions(repo, "rancher-monitoring", "77.9.1-rancher.3")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"grafana":                     "9.0.1",
+		"common":                      "2.3.0",
+		"node-exporter":               "4.47.0",
+		"prometheus-windows-exporter": "0.11.0",
+	}, got, "vendored subcharts take precedence over the Chart.lock")
+
+	_, err = shippedDependencyVersions(repo, "rancher-monitoring", "78.0.0-rancher.1")
+	assert.ErrorContains(t, err, "is not built")
+}
+
+func rebaseInfo(commit, chartVersion, appVersion string, deps []rebase.DependencyChartVersion, images map[string][]rebase.ChartImage) rebase.ChartRebaseInfo {
+	info := rebase.ChartRebaseInfo{
+		FoundChart:              rebase.FoundChart{Name: "kube-prometheus-stack", CommitHash: commit, ChartVersion: chartVersion, AppVersion: appVersion},
+		DependencyChartVersions: deps,
+		ChartsImagesLists:       make(map[string]util.Set[rebase.ChartImage]),
+	}
+	for chart, chartImages := range images {
+		set := util.NewSet[rebase.ChartImage]()
+		for _, img := range chartImages {
+			set.Add(img)
+		}
+		info.ChartsImagesLists[chart] = set
+	}
+	return info
+}
+
+func TestNewRebasePlan(t *testing.T) {
+	current := rebaseInfo("aaa", "77.9.1", "v0.85.0",
+		[]rebase.DependencyChartVersion{
+			{Name: "grafana", ChartVersion: "9.0.0", AppVersion: "12.0.0"},
+			{Name: "kube-state-metrics", ChartVersion: "6.1.0", AppVersion: "2.16.0"},
+		},
+		map[string][]rebase.ChartImage{
+			"kube-prometheus-stack": {{Registry: "quay.io", Repository: "prometheus/prometheus", Tag: "v3.5.0"}},
+		})
+	target := rebaseInfo("bbb", "78.0.0", "v0.86.0",
+		[]rebase.DependencyChartVersion{
+			{Name: "grafana", ChartVersion: "9.1.0", AppVersion: "12.1.0"},
+			{Name: "prometheus-windows-exporter", ChartVersion: "0.12.0", AppVersion: "0.31.0"},
+		},
+		map[string][]rebase.ChartImage{
+			"kube-prometheus-stack": {{Registry: "quay.io", Repository: "prometheus/prometheus", Tag: "v3.6.0"}},
+		})
+
+	plan, err := newRebasePlan("packages/rancher-monitoring/77.9/rancher-monitoring/package.yaml",
+		chartsrepo.PackageYAML{Version: "77.9.1-rancher.3", Commit: "aaa"}, current, target)
+	require.NoError(t, err)
+	assert.False(t, plan.UpToDate())
+	assert.Equal(t, "78.0.0-rancher.1", plan.NewVersion)
+	assert.Equal(t, rebase.VersionChange{From: "77.9.1", To: "78.0.0"}, plan.ChartVersion)
+	assert.Equal(t, rebase.VersionChange{From: "v0.85.0", To: "v0.86.0"}, plan.AppVersion)
+	assert.Equal(t, []rebase.DependencyChange{
+		{Name: "grafana", ChartVersion: rebase.VersionChange{From: "9.0.0", To: "9.1.0"}, AppVersion: rebase.VersionChange{From: "12.0.0", To: "12.1.0"}},
+		{Name: "kube-state-metrics", ChartVersion: rebase.VersionChange{From: "6.1.0"}, AppVersion: rebase.VersionChange{From: "2.16.0"}},
+		{Name: "prometheus-windows-exporter", ChartVersion: rebase.VersionChange{To: "0.12.0"}, AppVersion: rebase.VersionChange{To: "0.31.0"}},
+	}, plan.Dependencies)
+	require.Len(t, plan.Images, 1)
+	assert.Equal(t, rebase.ImageChange{Kind: "retagged", Image: "quay.io/prometheus/prometheus", OldTag: "v3.5.0", NewTag: "v3.6.0"}, plan.Images[0])
+
+	plan, err = newRebasePlan("package.yaml", chartsrepo.PackageYAML{Version: "78.0.0-rancher.1", Commit: "bbb"}, target, target)
+	require.NoError(t, err)
+	assert.True(t, plan.UpToDate())
+	assert.Equal(t, "78.0.0-rancher.1", plan.NewVersion, "an up to date package keeps its version")
+	assert.Empty(t, plan.Images)
+}
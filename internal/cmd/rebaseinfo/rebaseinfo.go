@@ -13,12 +13,12 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-func VerifyTagExists(tag string) (string, string) {
-	// Construct the full tag name for kube-prometheus-stack
-	fullTag := "kube-prometheus-stack-" + tag
-	exists, tagRef, hash, err := git.VerifyTagExists(context.Background(), string(upstream.RepositoryPrometheus), fullTag)
+// VerifyTagExists will either exit or return the tag reference and hash of an upstream chart version.
+func VerifyTagExists(chart upstream.Chart, version string) (string, string) {
+	fullTag := chart.Tag(version)
+	exists, tagRef, hash, err := git.VerifyTagExists(context.Background(), string(chart.Repository), fullTag)
 	if err != nil || !exists {
-		errorText := fmt.Sprintf("Cannot find upstream chart version `%s`", tag)
+		errorText := fmt.Sprintf("Cannot find upstream %s chart version `%s`", chart.Name, version)
 		fmt.Println(
 			text.AlignCenter.Apply(
 				text.Color.Sprint(text.FgRed, errorText),
@@ -32,10 +32,21 @@ func VerifyTagExists(tag string) (string, string) {
 	return tagRef, hash
 }
 
-func CollectInfo(version string, ref string, hash string) rebase.ChartRebaseInfo {
-	rebaseRequest := rebase.PrepareRebaseRequestInfo(version, ref, hash)
+// CollectInfo collects the rebase information of an upstream chart version, including its
+// dependencies and the images of each chart. Dependencies only resolve to pre-release
+// versions when includePrereleases is set.
+func CollectInfo(chart upstream.Chart, version string, ref string, hash string, includePrereleases bool) rebase.ChartRebaseInfo {
+	rebaseRequest := rebase.PrepareRebaseRequestInfo(chart, version, ref, hash)
+	rebaseRequest.IncludePrereleases = includePrereleases
+	return collectInfo(rebaseRequest)
+}
+
+// collectInfo resolves the dependencies of a prepared rebase request and finds the images
+// of each chart.
+func collectInfo(rebaseRequest rebase.StartRequest) rebase.ChartRebaseInfo {
 	rebaseInfoState := rebaseRequest.CollectRebaseChartsInfo()
 	_ = rebaseInfoState.FindChartsContainers()
+	printUnresolvedDependencies(rebaseInfoState.UnresolvedDependencies)
 	// TODO: Add something that will actually "resolve the images"
 	// This way it can output a clear list of docker images and their tags
 	// This means filling in the blanks where tags are empty (likely with appVersion)
@@ -44,3 +55,11 @@ func CollectInfo(version string, ref string, hash string) rebase.ChartRebaseInfo
 
 	return rebaseInfoState
 }
+
+// printUnresolvedDependencies warns about the dependencies no release satisfied, which are
+// missing from the collected information.
+func printUnresolvedDependencies(unresolved []rebase.UnresolvedDependency) {
+	for _, dep := range unresolved {
+		fmt.Println(text.Color.Sprintf(text.FgRed, "No release of %s (required by %s) satisfies `%s`: %s", dep.Name, dep.Parent, dep.Constraint, dep.Error))
+	}
+}
@@ -0,0 +1,29 @@
+package config
+
+// MirroredImagePrefix is prepended to the flattened upstream repository path to
+// name an image mirrored by rancher/image-mirror, e.g.
+// "quay.io/prometheus/node-exporter" → "rancher/mirrored-prometheus-node-exporter".
+const MirroredImagePrefix = "rancher/mirrored-"
+
+// MirrorRule overrides the default rancher/image-mirror naming for one upstream repository.
+type MirrorRule struct {
+	// Source is the fully qualified upstream repository, e.g. "ghcr.io/prometheus-community/windows-exporter".
+	Source string `yaml:"source"`
+	// Destination is the Rancher repository the image is published as. Leave it
+	// empty for images that are not mirrored and explain why in Reason.
+	Destination string `yaml:"destination,omitempty"`
+	Reason      string `yaml:"reason,omitempty"`
+}
+
+// MirrorRules lists the upstream images whose Rancher name does not follow the
+// MirroredImagePrefix convention, or that are published outside image-mirror.
+var MirrorRules = []MirrorRule{
+	{
+		Source: "ghcr.io/prometheus-community/windows-exporter",
+		Reason: "built by rancher/windows_exporter-package",
+	},
+	{
+		Source: "docker.io/prometheus-community/windows-exporter",
+		Reason: "built by rancher/windows_exporter-package",
+	},
+}
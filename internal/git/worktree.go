@@ -0,0 +1,75 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	log "github.com/rancher/ob-charts-tool/internal/logging"
+)
+
+// Worktree is a throwaway linked worktree of a repository, checked out at a detached commit.
+// go-git cannot create linked worktrees, so this uses the git CLI.
+type Worktree struct {
+	// Path is the worktree directory
+	Path     string
+	repoPath string
+}
+
+// worktreeMu serializes worktree administration; concurrent `git worktree` commands
+// race on the repository's worktree metadata.
+var worktreeMu sync.Mutex
+
+// AddWorktree checks out commit into a new linked worktree of the repository at repoPath,
+// in a temporary directory. Uncommitted changes in repoPath are not visible in it, and
+// changes made in it do not affect repoPath. Call Remove when done.
+func AddWorktree(repoPath, commit string) (*Worktree, error) {
+	dir, err := os.MkdirTemp("", "ob-charts-tool-worktree-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create worktree directory: %w", err)
+	}
+
+	worktreeMu.Lock()
+	defer worktreeMu.Unlock()
+	if _, err := runGit(repoPath, "worktree", "add", "--detach", dir, commit); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to add worktree for %s: %w", commit, err)
+	}
+	log.Log.Debugf("Added worktree at %s for %s", dir, commit)
+
+	return &Worktree{Path: dir, repoPath: repoPath}, nil
+}
+
+// Remove deletes the worktree directory and unregisters it from the repository.
+func (w *Worktree) Remove() error {
+	worktreeMu.Lock()
+	defer worktreeMu.Unlock()
+	_, removeErr := runGit(w.repoPath, "worktree", "remove", "--force", w.Path)
+	if removeErr == nil {
+		log.Log.Debugf("Removed worktree at %s", w.Path)
+		return nil
+	}
+
+	// Fall back to deleting the directory and pruning the stale registration
+	if err := os.RemoveAll(w.Path); err != nil {
+		return errors.Join(removeErr, fmt.Errorf("failed to delete worktree %s: %w", w.Path, err))
+	}
+	if _, err := runGit(w.repoPath, "worktree", "prune"); err != nil {
+		return errors.Join(removeErr, err)
+	}
+	return nil
+}
+
+// runGit runs a git command in dir and returns its trimmed output.
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,57 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddWorktree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git CLI not available")
+	}
+
+	repoPath := t.TempDir()
+	repo, err := git.PlainInit(repoPath, false)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "file.txt"), []byte("committed\n"), 0o644))
+	_, err = wt.Add("file.txt")
+	require.NoError(t, err)
+	_, err = wt.Commit("initial", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@test.com", When: time.Now()},
+	})
+	require.NoError(t, err)
+
+	// Uncommitted changes in the checkout must not leak into the worktree
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "file.txt"), []byte("dirty\n"), 0o644))
+
+	worktree, err := AddWorktree(repoPath, "HEAD")
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(worktree.Path, "file.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "committed\n", string(data))
+
+	require.NoError(t, os.WriteFile(filepath.Join(worktree.Path, "file.txt"), []byte("changed in worktree\n"), 0o644))
+	data, err = os.ReadFile(filepath.Join(repoPath, "file.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "dirty\n", string(data), "changes in the worktree must not affect the checkout")
+
+	require.NoError(t, worktree.Remove())
+	assert.NoDirExists(t, worktree.Path)
+	list, err := runGit(repoPath, "worktree", "list", "--porcelain")
+	require.NoError(t, err)
+	assert.NotContains(t, list, worktree.Path)
+
+	_, err = AddWorktree(repoPath, "does-not-exist")
+	assert.Error(t, err)
+}
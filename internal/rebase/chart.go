@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/rancher/ob-charts-tool/helmtools/git"
 	"github.com/rancher/ob-charts-tool/helmtools/util"
 	"github.com/rancher/ob-charts-tool/helmtools/values"
@@ -19,17 +20,23 @@ import (
 	"go.yaml.in/yaml/v3"
 )
 
-func findNewestReleaseTagInfo(chartDep ChartDep) *DependencyChartVersion {
-	exists, tag := findNewestReleaseTag(chartDep)
-	if !exists {
-		return nil
+// findNewestReleaseTagInfo resolves a dependency of upstreamChart to the newest release tag
+// that satisfies its version constraint and returns it along with the dependencies declared
+// by that release's Chart.yaml.
+func findNewestReleaseTagInfo(upstreamChart upstream.Chart, chartDep ChartDep, includePrereleases bool) (*DependencyChartVersion, []ChartDep, error) {
+	depChart, err := dependencyChart(upstreamChart, chartDep)
+	if err != nil {
+		return nil, nil, err
+	}
+	tag, err := findNewestReleaseTag(depChart, chartDep, includePrereleases)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	chartChartURL := upstream.BuildChartYAMLURL(chartDep.Name, tag.CommitHash)
-	chartVersion, appVersion, err := findChartVersionInfo(chartChartURL)
+	chartChartURL := depChart.ChartYAMLURL(tag.CommitHash)
+	chart, err := findChartInfo(chartChartURL)
 	if err != nil {
-		log.Errorf("Failed to find chart version info for %s: %v", chartDep.Name, err)
-		return nil
+		return nil, nil, fmt.Errorf("failed to find chart version info for %s: %w", chartDep.Name, err)
 	}
 
 	return &DependencyChartVersion{
@@ -37,48 +44,64 @@ func findNewestReleaseTagInfo(chartDep ChartDep) *DependencyChartVersion {
 		Ref:          tag.Name,
 		CommitHash:   tag.CommitHash,
 		ChartURL:     chartChartURL,
-		ChartVersion: chartVersion,
-		AppVersion:   appVersion,
+		ChartVersion: chart.Version,
+		AppVersion:   chart.AppVersion,
+	}, chart.Dependencies, nil
+}
+
+// dependencyChart returns the descriptor of a dependency, possibly nested, of upstreamChart.
+func dependencyChart(upstreamChart upstream.Chart, chartDep ChartDep) (upstream.Chart, error) {
+	depChart, ok := upstreamChart.Dependency(chartDep.Name, chartDep.Repository)
+	if !ok {
+		return depChart, fmt.Errorf("no git repository is known for %s dependency %s from %q",
+			upstreamChart.Name, chartDep.Name, chartDep.Repository)
 	}
+	return depChart, nil
 }
 
-func findNewestReleaseTag(chartDep ChartDep) (bool, *git.Tag) {
+// findNewestReleaseTag returns the highest <name>-<version> release tag of depChart whose
+// version satisfies the Chart.yaml version constraint of chartDep, the same way Helm
+// resolves it. Pre-releases are skipped unless includePrereleases is set or the constraint
+// names one.
+func findNewestReleaseTag(depChart upstream.Chart, chartDep ChartDep, includePrereleases bool) (*git.Tag, error) {
 	version := chartDep.Version
-	if strings.Contains(version, ".*") {
-		version = strings.ReplaceAll(version, ".*", "")
+	if version == "" {
+		version = "*"
+	}
+	constraint, err := semver.NewConstraint(version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version constraint %q for %s: %w", chartDep.Version, chartDep.Name, err)
 	}
 
-	repo := upstream.IdentifyRepository(chartDep.Name)
-	tag := fmt.Sprintf("%s-%s", chartDep.Name, version)
-
-	found, tags, err := git.FindMatchingTags(context.Background(), string(repo), tag)
+	found, tags, err := git.FindMatchingTags(context.Background(), string(depChart.Repository), depChart.TagPrefix)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("failed to list %s tags: %w", chartDep.Name, err)
 	}
 	if !found {
-		panic("Could not find any tags for this chart")
+		return nil, fmt.Errorf("no %s release tags found in %s", chartDep.Name, depChart.Repository)
 	}
 
-	highestTag := git.FindHighestVersionTag(tags, chartDep.Name)
+	highestTag := git.FindHighestVersionTagInRange(tags, depChart.Name, constraint, includePrereleases)
 	if highestTag == nil {
-		panic("No valid version tags found")
+		return nil, fmt.Errorf("no %s release satisfies %s", chartDep.Name, version)
 	}
 
-	return found, highestTag
+	return highestTag, nil
 }
 
-func findChartVersionInfo(chartFileURL string) (string, string, error) {
+// findChartInfo fetches a Chart.yaml and returns its versions and dependencies.
+func findChartInfo(chartFileURL string) (Chart, error) {
+	var chart Chart
 	body, err := util.FetchURL(context.Background(), internal.DefaultHTTPClient, chartFileURL)
 	if err != nil {
-		return "", "", err
+		return chart, err
 	}
 
-	var chartMeta ChartMetaData
-	if err := yaml.Unmarshal(body, &chartMeta); err != nil {
-		return "", "", err
+	if err := yaml.Unmarshal(body, &chart); err != nil {
+		return chart, err
 	}
 
-	return chartMeta.Version, chartMeta.AppVersion, nil
+	return chart, nil
 }
 
 func (s *ChartRebaseInfo) FindChartsContainers() error {
@@ -94,7 +117,7 @@ func (s *ChartRebaseInfo) FindChartsContainers() error {
 
 func (s *ChartRebaseInfo) lookupChartImages(chartName string, commitHash string) {
 	// TODO: Add output for debug and normal flows
-	valuesFileURL := upstream.BuildValuesYAMLURL(chartName, commitHash)
+	valuesFileURL := s.valuesFileURL(chartName)
 	log.Debugf("Fetching '%s' values file from: %s", chartName, valuesFileURL)
 
 	chartImageSet := make(util.Set[ChartImage])
@@ -106,7 +129,7 @@ func (s *ChartRebaseInfo) lookupChartImages(chartName string, commitHash string)
 		chartImagesList:  &chartImageSet,
 	}
 
-	if chartName == "kube-prometheus-stack" {
+	if chartName == s.FoundChart.Name {
 		imageResolver.chartVersion = s.FoundChart.ChartVersion
 		imageResolver.appVersion = s.FoundChart.AppVersion
 	} else {
@@ -141,6 +164,31 @@ func (s *ChartRebaseInfo) lookupChartImages(chartName string, commitHash string)
 	s.ChartsImagesLists[chartName] = chartImageSet
 }
 
+// valuesFileURL returns the values.yaml URL of the upstream chart or of one of its
+// dependencies, next to the Chart.yaml it was resolved from.
+func (s *ChartRebaseInfo) valuesFileURL(chartName string) string {
+	if chartName == s.FoundChart.Name {
+		if s.FoundChart.ValuesFileURL != "" {
+			return s.FoundChart.ValuesFileURL
+		}
+		return siblingValuesURL(s.FoundChart.ChartFileURL)
+	}
+	for _, dep := range s.DependencyChartVersions {
+		if dep.Name == chartName {
+			return siblingValuesURL(dep.ChartURL)
+		}
+	}
+	return ""
+}
+
+// siblingValuesURL returns the URL of the values.yaml next to a Chart.yaml URL.
+func siblingValuesURL(chartURL string) string {
+	if !strings.HasSuffix(chartURL, "/Chart.yaml") {
+		return ""
+	}
+	return strings.TrimSuffix(chartURL, "Chart.yaml") + "values.yaml"
+}
+
 type chartImagesResolver struct {
 	currentChartName string
 	currentHash      string
@@ -0,0 +1,67 @@
+package rebase
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rancher/ob-charts-tool/helmtools/util"
+	"github.com/rancher/ob-charts-tool/internal/upstream"
+)
+
+func TestValuesFileURL(t *testing.T) {
+	info := ChartRebaseInfo{
+		FoundChart: FoundChart{
+			Name:         "kube-prometheus-stack",
+			ChartFileURL: "https://github.com/prometheus-community/helm-charts/raw/abc/charts/kube-prometheus-stack/Chart.yaml",
+		},
+		DependencyChartVersions: []DependencyChartVersion{
+			{Name: "grafana", ChartURL: "https://github.com/grafana-community/helm-charts/raw/def/charts/grafana/Chart.yaml"},
+		},
+	}
+
+	assert.Equal(t, "https://github.com/prometheus-community/helm-charts/raw/abc/charts/kube-prometheus-stack/values.yaml",
+		info.valuesFileURL("kube-prometheus-stack"), "rebase.yaml files without values_file_url")
+	assert.Equal(t, "https://github.com/grafana-community/helm-charts/raw/def/charts/grafana/values.yaml",
+		info.valuesFileURL("grafana"))
+	assert.Empty(t, info.valuesFileURL("unknown"))
+
+	info.FoundChart.ValuesFileURL = "https://example.com/values.yaml"
+	assert.Equal(t, "https://example.com/values.yaml", info.valuesFileURL("kube-prometheus-stack"))
+}
+
+func TestChartRebaseInfo_ImageReferences(t *testing.T) {
+	kpsImages := util.NewSet[ChartImage]()
+	kpsImages.Add(ChartImage{Registry: "quay.io", Repository: "prometheus/prometheus", Tag: "v3.1.0"})
+	grafanaImages := util.NewSet[ChartImage]()
+	grafanaImages.Add(ChartImage{Repository: "grafana/grafana", Tag: "11.4.0"})
+
+	images := ChartRebaseInfo{
+		FoundChart:              FoundChart{Name: "kube-prometheus-stack", ChartVersion: "69.0.0"},
+		DependencyChartVersions: []DependencyChartVersion{{Name: "grafana", ChartVersion: "8.8.2"}},
+		ChartsImagesLists: map[string]util.Set[ChartImage]{
+			"kube-prometheus-stack": kpsImages,
+			"grafana":               grafanaImages,
+		},
+	}.ImageReferences()
+	require.Len(t, images, 2)
+	assert.Equal(t, []string{"kube-prometheus-stack:69.0.0"}, images["quay.io/prometheus/prometheus:v3.1.0"].Sources)
+	assert.Equal(t, []string{"grafana:8.8.2"}, images["grafana/grafana:11.4.0"].Sources)
+	assert.Equal(t, "linux", images["grafana/grafana:11.4.0"].OS)
+}
+
+func TestDependencyChart(t *testing.T) {
+	// a nested dependency missing from the DependencyRepositories of the upstream chart
+	depChart, err := dependencyChart(upstream.KubePrometheusStack, ChartDep{
+		Name:       "kube-rbac-proxy",
+		Version:    "0.2.*",
+		Repository: "https://prometheus-community.github.io/helm-charts",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, upstream.RepositoryPrometheus, depChart.Repository)
+	assert.Equal(t, "kube-rbac-proxy-", depChart.TagPrefix)
+
+	_, err = dependencyChart(upstream.KubePrometheusStack, ChartDep{Name: "common", Repository: "oci://registry-1.docker.io/bitnamicharts"})
+	assert.ErrorContains(t, err, "no git repository is known")
+}
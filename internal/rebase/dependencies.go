@@ -0,0 +1,101 @@
+package rebase
+
+import (
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	log "github.com/sirupsen/logrus"
+)
+
+// dependencyLookup resolves a dependency of the chart named parent to a release and returns
+// the dependencies declared by that release, or an error when it cannot be resolved.
+type dependencyLookup func(dep ChartDep, parent string) (*DependencyChartVersion, []ChartDep, error)
+
+// dependencyResolver walks the dependency tree of a chart. A chart that several charts
+// depend on is resolved once and lists each of them as a parent.
+type dependencyResolver struct {
+	lookup   dependencyLookup
+	resolved map[string]*DependencyChartVersion
+	// failed holds the charts that could not be resolved, so they are not looked up again
+	failed     map[string]bool
+	order      []string
+	unresolved []UnresolvedDependency
+}
+
+func newDependencyResolver(lookup dependencyLookup) *dependencyResolver {
+	return &dependencyResolver{
+		lookup:   lookup,
+		resolved: make(map[string]*DependencyChartVersion),
+		failed:   make(map[string]bool),
+	}
+}
+
+// resolve resolves dep, required by the chart named parent, and then its own dependencies.
+func (r *dependencyResolver) resolve(dep ChartDep, parent string) {
+	if existing, ok := r.resolved[dep.Name]; ok {
+		existing.addParent(parent, dep.Alias)
+		if !satisfiesConstraint(existing.ChartVersion, dep.Version) {
+			log.Warnf("%s requires %s %s, but %s was resolved for %s (%s)",
+				parent, dep.Name, dep.Version, existing.ChartVersion, existing.Parents[0], existing.Constraint)
+		}
+		return
+	}
+	if r.failed[dep.Name] {
+		return
+	}
+	if strings.HasPrefix(dep.Repository, "file://") {
+		log.Debugf("Skipping %s dependency %s: it is vendored from %s", parent, dep.Name, dep.Repository)
+		return
+	}
+
+	log.Debugf("Fetching chart dependencies for: %v", dep)
+	version, children, err := r.lookup(dep, parent)
+	if err != nil {
+		log.Errorf("Failed to resolve %s dependency %s %s: %v", parent, dep.Name, dep.Version, err)
+		r.failed[dep.Name] = true
+		r.unresolved = append(r.unresolved, UnresolvedDependency{
+			Name:       dep.Name,
+			Constraint: dep.Version,
+			Parent:     parent,
+			Error:      err.Error(),
+		})
+		return
+	}
+	version.Constraint = dep.Version
+	version.addParent(parent, dep.Alias)
+	r.resolved[dep.Name] = version
+	r.order = append(r.order, dep.Name)
+
+	for _, child := range children {
+		version.Dependencies = append(version.Dependencies, child.Name)
+		r.resolve(child, dep.Name)
+	}
+}
+
+// satisfiesConstraint reports whether a resolved chart version satisfies the version
+// constraint of another chart depending on it. An empty constraint accepts any version.
+func satisfiesConstraint(version, constraint string) bool {
+	if constraint == "" {
+		return true
+	}
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return false
+	}
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return false
+	}
+	// the version was already resolved, so a pre-release is acceptable here
+	c.IncludePrerelease = true
+	return c.Check(v)
+}
+
+// versions returns the resolved charts, each listed before its own dependencies.
+func (r *dependencyResolver) versions() []DependencyChartVersion {
+	versions := make([]DependencyChartVersion, 0, len(r.order))
+	for _, name := range r.order {
+		versions = append(versions, *r.resolved[name])
+	}
+	return versions
+}
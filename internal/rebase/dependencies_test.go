@@ -0,0 +1,126 @@
+package rebase
+
+import (
+	"fmt"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDependencyResolver(t *testing.T) {
+	// grafana and kube-state-metrics both depend on a shared library chart,
+	// which depends back on grafana.
+	releases := map[string]struct {
+		version  string
+		children []ChartDep
+	}{
+		"grafana":            {"9.1.0", []ChartDep{{Name: "common", Version: "2.x"}}},
+		"kube-state-metrics": {"6.1.0", []ChartDep{{Name: "common", Version: "2.x", Alias: "lib"}, {Name: "local", Repository: "file://./charts/local"}}},
+		"common":             {"2.3.0", []ChartDep{{Name: "grafana", Version: "9.*"}}},
+	}
+	var lookups []string
+	lookup := func(dep ChartDep, _ string) (*DependencyChartVersion, []ChartDep, error) {
+		lookups = append(lookups, dep.Name)
+		release, ok := releases[dep.Name]
+		if !ok {
+			return nil, nil, fmt.Errorf("no %s release satisfies %s", dep.Name, dep.Version)
+		}
+		return &DependencyChartVersion{Name: dep.Name, ChartVersion: release.version}, release.children, nil
+	}
+
+	resolver := newDependencyResolver(lookup)
+	for _, dep := range []ChartDep{
+		{Name: "grafana", Version: "9.*"},
+		{Name: "kube-state-metrics", Version: "6.1.*"},
+		{Name: "missing", Version: "1.*"},
+		{Name: "missing", Version: "1.*"},
+	} {
+		resolver.resolve(dep, "kube-prometheus-stack")
+	}
+
+	assert.Equal(t, []string{"grafana", "common", "kube-state-metrics", "missing"}, lookups, "each chart is looked up once")
+	assert.Equal(t, []DependencyChartVersion{
+		{Name: "grafana", ChartVersion: "9.1.0", Constraint: "9.*", Parents: []string{"kube-prometheus-stack", "common"}, Dependencies: []string{"common"}},
+		{Name: "common", ChartVersion: "2.3.0", Constraint: "2.x", Parents: []string{"grafana", "kube-state-metrics"}, Dependencies: []string{"grafana"},
+			Aliases: map[string]string{"kube-state-metrics": "lib"}},
+		{Name: "kube-state-metrics", ChartVersion: "6.1.0", Constraint: "6.1.*", Parents: []string{"kube-prometheus-stack"}, Dependencies: []string{"common", "local"}},
+	}, resolver.versions())
+	assert.Equal(t, []UnresolvedDependency{
+		{Name: "missing", Constraint: "1.*", Parent: "kube-prometheus-stack", Error: "no missing release satisfies 1.*"},
+	}, resolver.unresolved, "unresolved dependencies are reported once")
+}
+
+func TestDependencyResolver_ConstraintConflicts(t *testing.T) {
+	hook := logtest.NewGlobal()
+	defer hook.Reset()
+
+	resolver := newDependencyResolver(func(dep ChartDep, _ string) (*DependencyChartVersion, []ChartDep, error) {
+		return &DependencyChartVersion{Name: dep.Name, ChartVersion: "1.2.5"}, nil, nil
+	})
+	resolver.resolve(ChartDep{Name: "common", Version: "~1.2"}, "grafana")
+	resolver.resolve(ChartDep{Name: "common", Version: ">=1.2.0 <1.3.0"}, "kube-state-metrics")
+	resolver.resolve(ChartDep{Name: "common", Version: ""}, "prometheus-node-exporter")
+	assert.Empty(t, hook.AllEntries(), "equivalent constraints the resolved version satisfies are not a conflict")
+
+	resolver.resolve(ChartDep{Name: "common", Version: "~1.2"}, "prometheus-windows-exporter")
+	resolver.resolve(ChartDep{Name: "common", Version: "^2.0.0"}, "kube-prometheus-stack")
+	require.Len(t, hook.AllEntries(), 1)
+	assert.Equal(t, log.WarnLevel, hook.LastEntry().Level)
+	assert.Contains(t, hook.LastEntry().Message, "kube-prometheus-stack requires common ^2.0.0, but 1.2.5 was resolved")
+}
+
+func TestSatisfiesConstraint(t *testing.T) {
+	cases := []struct {
+		version    string
+		constraint string
+		want       bool
+	}{
+		{"1.2.5", "~1.2", true},
+		{"1.2.5", ">=1.2.0 <1.3.0", true},
+		{"1.2.5", "=1.2.5", true},
+		{"1.2.5", "", true},
+		{"1.3.0-rc.1", "1.3.x-0", true},
+		{"1.2.5", "2.x", false},
+		{"1.2.5", "not a constraint", false},
+		{"not a version", "*", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.version+" "+tc.constraint, func(t *testing.T) {
+			assert.Equal(t, tc.want, satisfiesConstraint(tc.version, tc.constraint))
+		})
+	}
+}
+
+func TestPinDependency(t *testing.T) {
+	request := StartRequest{FoundChart: FoundChart{Name: "kube-prometheus-stack"}}
+	dep := request.pinDependency(ChartDep{Name: "grafana", Version: "9.*"}, "kube-prometheus-stack")
+	assert.Equal(t, "9.*", dep.Version, "constraints are resolved when nothing is pinned")
+
+	request.DependencyVersions = map[string]string{"grafana": "9.0.1", "windows-exporter": "0.12.0", "common": "2.2.0"}
+	dep = request.pinDependency(ChartDep{Name: "rancher-windows-exporter", Version: "0.*"}, "kube-prometheus-stack")
+	assert.Equal(t, "=0.12.0", dep.Version)
+
+	// the resolver records the upstream constraint, looks up the shipped version of direct
+	// dependencies and lets nested or newly added dependencies resolve their constraints
+	var lookups []string
+	resolver := newDependencyResolver(func(dep ChartDep, parent string) (*DependencyChartVersion, []ChartDep, error) {
+		dep = request.pinDependency(dep, parent)
+		lookups = append(lookups, dep.Name+" "+dep.Version)
+		var children []ChartDep
+		if dep.Name == "grafana" {
+			children = []ChartDep{{Name: "common", Version: "2.x"}}
+		}
+		return &DependencyChartVersion{Name: dep.Name}, children, nil
+	})
+	resolver.resolve(ChartDep{Name: "grafana", Version: "9.*"}, "kube-prometheus-stack")
+	resolver.resolve(ChartDep{Name: "kube-state-metrics", Version: "6.*"}, "kube-prometheus-stack")
+
+	assert.Equal(t, []string{"grafana =9.0.1", "common 2.x", "kube-state-metrics 6.*"}, lookups)
+	assert.Empty(t, resolver.unresolved)
+	for _, version := range resolver.versions() {
+		assert.NotContains(t, version.Constraint, "=", "%s keeps its upstream constraint", version.Name)
+	}
+}
@@ -0,0 +1,164 @@
+package rebase
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/rancher/ob-charts-tool/helmtools/image"
+	"github.com/rancher/ob-charts-tool/helmtools/registry"
+	"github.com/rancher/ob-charts-tool/helmtools/util"
+	"github.com/rancher/ob-charts-tool/internal"
+
+	log "github.com/sirupsen/logrus"
+	"go.yaml.in/yaml/v3"
+)
+
+// LoadRebaseYaml reads a rebase.yaml file written by SaveStateToRebaseYaml.
+func LoadRebaseYaml(path string) (ChartRebaseInfo, error) {
+	var info ChartRebaseInfo
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return info, fmt.Errorf("failed to read rebase info: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &info); err != nil {
+		return info, fmt.Errorf("failed to parse rebase info %s: %w", path, err)
+	}
+	return info, nil
+}
+
+// ImageNames returns every image found in ChartsImagesLists as a fully qualified
+// reference without digest (see image.DigestKey), sorted and deduplicated.
+// Images that cannot be parsed, such as templated repositories, are returned
+// separately in skipped.
+func (s *ChartRebaseInfo) ImageNames() (names []string, skipped []string) {
+	found := util.NewSet[string]()
+	for _, images := range s.ChartsImagesLists {
+		for img := range images {
+			key, err := image.DigestKey(img.String())
+			if err != nil {
+				skipped = append(skipped, img.String())
+				continue
+			}
+			found.Add(key)
+		}
+	}
+	names = found.Values()
+	sort.Strings(names)
+	sort.Strings(skipped)
+	return names, skipped
+}
+
+// RecordImageDigests replaces ImageDigests with the successfully resolved
+// digests from results and stamps ImageDigestsResolvedAt.
+func (s *ChartRebaseInfo) RecordImageDigests(results []registry.DigestResult, resolvedAt time.Time) {
+	s.ImageDigests = make(map[string]string, len(results))
+	for _, result := range results {
+		if result.Err != nil || result.Digest == "" {
+			continue
+		}
+		s.ImageDigests[result.Image] = result.Digest
+	}
+	s.ImageDigestsResolvedAt = resolvedAt.UTC()
+}
+
+// DigestValuesOverlay builds a values overlay that pins the digest field
+// ("sha" or "digest") of every image recorded in ImageDigests. The upstream
+// values.yaml of the main chart and of each dependency is fetched again to
+// locate the image maps; dependency images are nested under the alias or name
+// of the dependency and of each of its parents (see dependencyValuesPaths). It
+// returns the overlay and the number of pinned images.
+func (s *ChartRebaseInfo) DigestValuesOverlay() (map[string]interface{}, int, error) {
+	overlay := make(map[string]interface{})
+	pinned, err := s.pinChartDigests(overlay, []string{""}, s.FoundChart.Name, s.FoundChart.AppVersion)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, dep := range s.DependencyChartVersions {
+		count, err := s.pinChartDigests(overlay, s.dependencyValuesPaths(dep.Name, nil), dep.Name, dep.AppVersion)
+		if err != nil {
+			return nil, 0, err
+		}
+		pinned += count
+	}
+	return overlay, pinned, nil
+}
+
+// pinChartDigests pins the images of a chart's values.yaml under each of prefixes.
+func (s *ChartRebaseInfo) pinChartDigests(overlay map[string]interface{}, prefixes []string, chartName, appVersion string) (int, error) {
+	valuesFileURL := s.valuesFileURL(chartName)
+	log.Debugf("Fetching '%s' values file from: %s", chartName, valuesFileURL)
+	body, err := util.FetchURL(context.Background(), internal.DefaultHTTPClient, valuesFileURL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch %s values: %w", chartName, err)
+	}
+
+	locations, err := image.ExtractImageLocations(body, appVersion)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find %s images: %w", chartName, err)
+	}
+	pinned := 0
+	for _, prefix := range prefixes {
+		pinned += image.PinDigests(overlay, prefix, locations, s.ImageDigests)
+	}
+	return pinned, nil
+}
+
+// dependencyValuesPaths returns the dotted paths the main chart's values use for a
+// dependency, e.g. "grafana" or "kube-state-metrics.common" for a nested subchart: one
+// per chain of parents, each chart keyed by the alias its parent gives it, or its name.
+// visiting holds the charts already on the chain, so dependency cycles are not followed.
+func (s *ChartRebaseInfo) dependencyValuesPaths(name string, visiting map[string]bool) []string {
+	var dep *DependencyChartVersion
+	for i := range s.DependencyChartVersions {
+		if s.DependencyChartVersions[i].Name == name {
+			dep = &s.DependencyChartVersions[i]
+			break
+		}
+	}
+	// rebase.yaml files from before nested dependencies were resolved have no parents
+	if dep == nil || len(dep.Parents) == 0 {
+		return []string{s.dependencyValuesKey(name)}
+	}
+	if visiting[name] {
+		return nil
+	}
+	visiting = maps.Clone(visiting)
+	if visiting == nil {
+		visiting = make(map[string]bool)
+	}
+	visiting[name] = true
+
+	var paths []string
+	for _, parent := range dep.Parents {
+		key := dep.Aliases[parent]
+		if parent == s.FoundChart.Name {
+			if key == "" {
+				key = s.dependencyValuesKey(name)
+			}
+			paths = append(paths, key)
+			continue
+		}
+		if key == "" {
+			key = name
+		}
+		for _, parentPath := range s.dependencyValuesPaths(parent, visiting) {
+			paths = append(paths, parentPath+"."+key)
+		}
+	}
+	return paths
+}
+
+// dependencyValuesKey returns the key the main chart's values use for a dependency.
+func (s *ChartRebaseInfo) dependencyValuesKey(name string) string {
+	for _, dep := range s.ChartDependencies {
+		if dep.Name == name && dep.Alias != "" {
+			return dep.Alias
+		}
+	}
+	return name
+}
@@ -0,0 +1,72 @@
+package rebase
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDependencyValuesPaths(t *testing.T) {
+	info := ChartRebaseInfo{
+		FoundChart:        FoundChart{Name: "kube-prometheus-stack"},
+		ChartDependencies: []ChartDep{{Name: "prometheus-node-exporter", Alias: "nodeExporter"}},
+		DependencyChartVersions: []DependencyChartVersion{
+			{Name: "grafana", Parents: []string{"kube-prometheus-stack", "common"}},
+			{Name: "kube-state-metrics", Parents: []string{"kube-prometheus-stack"}},
+			{Name: "prometheus-node-exporter", Parents: []string{"kube-prometheus-stack"}},
+			{Name: "common", Parents: []string{"grafana", "kube-state-metrics"}, Aliases: map[string]string{"kube-state-metrics": "lib"}},
+			{Name: "legacy"},
+		},
+	}
+
+	assert.Equal(t, []string{"nodeExporter"}, info.dependencyValuesPaths("prometheus-node-exporter", nil))
+	assert.Equal(t, []string{"grafana.common", "kube-state-metrics.lib"}, info.dependencyValuesPaths("common", nil))
+	assert.Equal(t, []string{"grafana", "kube-state-metrics.lib.grafana"}, info.dependencyValuesPaths("grafana", nil),
+		"dependency cycles are not followed")
+	assert.Equal(t, []string{"legacy"}, info.dependencyValuesPaths("legacy", nil), "charts without parents are direct dependencies")
+}
+
+func TestDigestValuesOverlay_NestedSubchart(t *testing.T) {
+	files := map[string]string{
+		"/kps/values.yaml":     "image:\n  repository: prometheus/prometheus\n  tag: v3.1.0\n",
+		"/grafana/values.yaml": "image:\n  repository: grafana/grafana\n  tag: 12.1.0\n",
+		"/proxy/values.yaml":   "image:\n  repository: brancz/kube-rbac-proxy\n  tag: v0.19.0\n",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	info := ChartRebaseInfo{
+		FoundChart: FoundChart{Name: "kube-prometheus-stack", ChartFileURL: server.URL + "/kps/Chart.yaml"},
+		DependencyChartVersions: []DependencyChartVersion{
+			{Name: "grafana", ChartURL: server.URL + "/grafana/Chart.yaml", Parents: []string{"kube-prometheus-stack"}, Dependencies: []string{"kube-rbac-proxy"}},
+			{Name: "kube-rbac-proxy", ChartURL: server.URL + "/proxy/Chart.yaml", Parents: []string{"grafana"}, Aliases: map[string]string{"grafana": "proxy"}},
+		},
+		ImageDigests: map[string]string{
+			"docker.io/prometheus/prometheus:v3.1.0":   "sha256:aaa",
+			"docker.io/grafana/grafana:12.1.0":         "sha256:bbb",
+			"docker.io/brancz/kube-rbac-proxy:v0.19.0": "sha256:ccc",
+		},
+	}
+
+	overlay, pinned, err := info.DigestValuesOverlay()
+	require.NoError(t, err)
+	assert.Equal(t, 3, pinned)
+	assert.Equal(t, map[string]interface{}{
+		"image": map[string]interface{}{"sha": "aaa"},
+		"grafana": map[string]interface{}{
+			"image": map[string]interface{}{"sha": "bbb"},
+			// the nested subchart is pinned under its parent, as its alias
+			"proxy": map[string]interface{}{"image": map[string]interface{}{"sha": "ccc"}},
+		},
+	}, overlay)
+}
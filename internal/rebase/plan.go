@@ -0,0 +1,48 @@
+package rebase
+
+// RebasePlan compares the upstream chart a package currently ships with the rebase target.
+type RebasePlan struct {
+	// PackageYAML is the package.yaml of the current package, relative to the charts repository
+	PackageYAML    string `yaml:"package_yaml"`
+	CurrentVersion string `yaml:"current_version"`
+	// NewVersion is the package.yaml version after the rebase
+	NewVersion    string             `yaml:"new_version"`
+	CurrentCommit string             `yaml:"current_commit"`
+	NewCommit     string             `yaml:"new_commit"`
+	ChartVersion  VersionChange      `yaml:"chart_version"`
+	AppVersion    VersionChange      `yaml:"app_version"`
+	Dependencies  []DependencyChange `yaml:"dependencies"`
+	Images        []ImageChange      `yaml:"images,omitempty"`
+}
+
+// UpToDate reports whether the package already ships the target commit.
+func (p *RebasePlan) UpToDate() bool {
+	return p.CurrentCommit == p.NewCommit
+}
+
+// VersionChange is a version before and after a rebase; From is empty for something new
+// and To for something removed.
+type VersionChange struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+// Changed reports whether the version differs after the rebase.
+func (c VersionChange) Changed() bool {
+	return c.From != c.To
+}
+
+// DependencyChange is a chart dependency before and after a rebase.
+type DependencyChange struct {
+	Name         string        `yaml:"name"`
+	ChartVersion VersionChange `yaml:"chart_version"`
+	AppVersion   VersionChange `yaml:"app_version"`
+}
+
+// ImageChange is an image added, removed or retagged by a rebase.
+type ImageChange struct {
+	Kind   string `yaml:"kind"`
+	Image  string `yaml:"image"`
+	OldTag string `yaml:"old_tag,omitempty"`
+	NewTag string `yaml:"new_tag,omitempty"`
+}
@@ -2,39 +2,50 @@ package rebase
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/rancher/ob-charts-tool/helmtools/util"
 	"github.com/rancher/ob-charts-tool/internal"
+	"github.com/rancher/ob-charts-tool/internal/upstream"
+	internalvalues "github.com/rancher/ob-charts-tool/internal/values"
 	log "github.com/sirupsen/logrus"
 	"go.yaml.in/yaml/v3"
 )
 
-const (
-	upstreamChartURL = "https://raw.githubusercontent.com/prometheus-community/helm-charts/%s/charts/kube-prometheus-stack/Chart.yaml"
-)
+// PrepareRebaseRequestInfo is like NewRebaseRequest but exits when the Chart.yaml cannot
+// be fetched.
+func PrepareRebaseRequestInfo(chart upstream.Chart, version string, tagRef string, gitHash string) StartRequest {
+	rebaseRequest, err := NewRebaseRequest(chart, version, tagRef, gitHash)
+	if err != nil {
+		log.Fatalf("Failed to fetch chart: %v", err)
+	}
+	return rebaseRequest
+}
 
-func PrepareRebaseRequestInfo(version string, tagRef string, gitHash string) StartRequest {
+// NewRebaseRequest fetches the Chart.yaml of an upstream chart at the commit of its
+// release tag and collects its appVersion and dependencies.
+func NewRebaseRequest(chart upstream.Chart, version string, tagRef string, gitHash string) (StartRequest, error) {
 	rebaseRequest := StartRequest{
 		TargetVersion: version,
+		upstreamChart: chart,
 		FoundChart: FoundChart{
-			Name:       "kube-prometheus-stack",
+			Name:       chart.Name,
 			Ref:        tagRef,
 			CommitHash: gitHash,
 		},
 	}
 
 	if err := rebaseRequest.FetchChart(); err != nil {
-		log.Fatalf("Failed to fetch chart: %v", err)
+		return rebaseRequest, err
 	}
 	rebaseRequest.FindAppVersion()
 	rebaseRequest.FindChartDeps()
 
-	return rebaseRequest
+	return rebaseRequest, nil
 }
 
 func (s *StartRequest) FetchChart() error {
-	s.FoundChart.ChartFileURL = fmt.Sprintf(upstreamChartURL, s.FoundChart.CommitHash)
+	s.FoundChart.ChartFileURL = s.upstreamChart.ChartYAMLURL(s.FoundChart.CommitHash)
+	s.FoundChart.ValuesFileURL = s.upstreamChart.ValuesYAMLURL(s.FoundChart.CommitHash)
 	body, err := util.FetchURL(context.Background(), internal.DefaultHTTPClient, s.FoundChart.ChartFileURL)
 	if err != nil {
 		return err
@@ -64,9 +75,9 @@ func (s *StartRequest) FindChartDeps() {
 		return
 	}
 
-	// TODO: do any of our chart dependencies have dependencies?
+	// Dependencies of the dependencies are resolved by CollectRebaseChartsInfo
 	s.ChartDependencies = util.FilterSlice[ChartDep](chart.Dependencies, func(item ChartDep) bool {
-		return item.Name != "crds"
+		return s.upstreamChart.IncludesDependency(item.Name)
 	})
 	s.targetChart = nil
 }
@@ -78,14 +89,29 @@ func (s *StartRequest) CollectRebaseChartsInfo() ChartRebaseInfo {
 		ChartDependencies: s.ChartDependencies,
 		ChartsImagesLists: make(map[string]util.Set[ChartImage]),
 	}
+	rebaseInfo.IncludePrereleases = s.IncludePrereleases
 
+	resolver := newDependencyResolver(func(dep ChartDep, parent string) (*DependencyChartVersion, []ChartDep, error) {
+		return findNewestReleaseTagInfo(s.upstreamChart, s.pinDependency(dep, parent), s.IncludePrereleases)
+	})
 	for _, item := range rebaseInfo.ChartDependencies {
-		log.Debugf("Fetching chart dependencies for: %v", item)
-		newestTagInfo := findNewestReleaseTagInfo(item)
-		if newestTagInfo != nil {
-			rebaseInfo.DependencyChartVersions = append(rebaseInfo.DependencyChartVersions, *newestTagInfo)
-		}
+		resolver.resolve(item, s.FoundChart.Name)
 	}
+	rebaseInfo.DependencyChartVersions = resolver.versions()
+	rebaseInfo.UnresolvedDependencies = resolver.unresolved
 
 	return rebaseInfo
 }
+
+// pinDependency returns the dependency of parent to look up: a direct dependency of the
+// upstream chart is pinned to its version in DependencyVersions, when it has one, and any
+// other dependency keeps its version constraint.
+func (s *StartRequest) pinDependency(dep ChartDep, parent string) ChartDep {
+	if parent != s.FoundChart.Name {
+		return dep
+	}
+	if shipped, ok := s.DependencyVersions[internalvalues.NormalizeName(dep.Name)]; ok {
+		dep.Version = "=" + shipped
+	}
+	return dep
+}
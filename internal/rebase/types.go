@@ -1,11 +1,19 @@
 package rebase
 
-import "github.com/rancher/ob-charts-tool/helmtools/util"
+import (
+	"slices"
+	"time"
+
+	"github.com/rancher/ob-charts-tool/helmtools/image"
+	"github.com/rancher/ob-charts-tool/helmtools/util"
+	"github.com/rancher/ob-charts-tool/internal/upstream"
+)
 
 type ChartDep struct {
 	Name       string `yaml:"name"`
 	Version    string `yaml:"version"`
 	Repository string `yaml:"repository"`
+	Alias      string `yaml:"alias,omitempty"`
 }
 
 type ChartMetaData struct {
@@ -21,17 +29,26 @@ type Chart struct {
 type FoundChart struct {
 	Name         string `yaml:"name"`
 	ChartFileURL string `yaml:"chart_file_url"`
-	Ref          string `yaml:"ref"`
-	CommitHash   string `yaml:"commit_hash"`
-	ChartVersion string `yaml:"chart_version"`
-	AppVersion   string `yaml:"app_version"`
+	// ValuesFileURL is empty in rebase.yaml files from before upstream charts were configurable
+	ValuesFileURL string `yaml:"values_file_url,omitempty"`
+	Ref           string `yaml:"ref"`
+	CommitHash    string `yaml:"commit_hash"`
+	ChartVersion  string `yaml:"chart_version"`
+	AppVersion    string `yaml:"app_version"`
 }
 
 type StartRequest struct {
 	TargetVersion     string
+	upstreamChart     upstream.Chart
 	targetChart       []byte
 	FoundChart        FoundChart
 	ChartDependencies []ChartDep
+	// IncludePrereleases lets dependency constraints resolve to pre-release versions
+	IncludePrereleases bool
+	// DependencyVersions pins the direct dependencies, by normalized name, to the versions a
+	// package shipped instead of resolving their version constraints. The constraints are
+	// still recorded in DependencyChartVersion.Constraint
+	DependencyVersions map[string]string
 }
 
 type ChartRebaseInfo struct {
@@ -41,6 +58,17 @@ type ChartRebaseInfo struct {
 	DependencyChartVersions []DependencyChartVersion        `yaml:"dependency_chart_versions"`
 	ChartsImagesLists       map[string]util.Set[ChartImage] `yaml:"charts_images_lists"`
 	SubchartTagExpectations []SubchartTagExpectation        `yaml:"subchart_tag_expectations,omitempty"`
+	// UnresolvedDependencies are the dependencies whose version constraint no release satisfied
+	UnresolvedDependencies []UnresolvedDependency `yaml:"unresolved_dependencies,omitempty"`
+	// IncludePrereleases records whether dependencies could resolve to pre-release versions
+	IncludePrereleases bool `yaml:"include_prereleases,omitempty"`
+	// ImageDigests maps each fully qualified image (see image.DigestKey) to the
+	// manifest digest its tag resolved to when ImageDigestsResolvedAt was recorded.
+	ImageDigests           map[string]string `yaml:"image_digests,omitempty"`
+	ImageDigestsResolvedAt time.Time         `yaml:"image_digests_resolved_at,omitempty"`
+	// Plan compares the target with the package currently in the charts repository,
+	// when getRebaseInfo was given one.
+	Plan *RebasePlan `yaml:"plan,omitempty"`
 }
 
 // SubchartTagExpectation holds the expected image tag values for a tracked subchart,
@@ -59,6 +87,37 @@ type DependencyChartVersion struct {
 	ChartURL     string `yaml:"chart_url"`
 	ChartVersion string `yaml:"chart_version"`
 	AppVersion   string `yaml:"app_version"`
+	// Constraint is the version constraint the chart was resolved from
+	Constraint string `yaml:"constraint,omitempty"`
+	// Parents are the charts that depend on this chart: the upstream chart for its direct
+	// dependencies, or other dependencies for nested subcharts
+	Parents []string `yaml:"parents,omitempty"`
+	// Dependencies are the names of the charts this chart depends on
+	Dependencies []string `yaml:"dependencies,omitempty"`
+	// Aliases are the aliases parents declare this chart under, by parent name
+	Aliases map[string]string `yaml:"aliases,omitempty"`
+}
+
+// addParent records that the chart named parent depends on this chart as alias, if set.
+func (v *DependencyChartVersion) addParent(parent, alias string) {
+	if !slices.Contains(v.Parents, parent) {
+		v.Parents = append(v.Parents, parent)
+	}
+	if alias != "" {
+		if v.Aliases == nil {
+			v.Aliases = make(map[string]string)
+		}
+		v.Aliases[parent] = alias
+	}
+}
+
+// UnresolvedDependency is a chart dependency whose version constraint could not be resolved
+// to a release.
+type UnresolvedDependency struct {
+	Name       string `yaml:"name"`
+	Constraint string `yaml:"constraint"`
+	Parent     string `yaml:"parent"`
+	Error      string `yaml:"error"`
 }
 
 type ChartImage struct {
@@ -66,3 +125,40 @@ type ChartImage struct {
 	Repository string `yaml:"repository"`
 	Tag        string `yaml:"tag"`
 }
+
+// Reference parses the chart image into a normalized image reference.
+func (ci ChartImage) Reference() (image.Reference, error) {
+	return image.Image(ci).Reference()
+}
+
+// String formats the chart image as written in values.yaml.
+func (ci ChartImage) String() string {
+	return image.Image(ci).String()
+}
+
+// ImageReferences converts the ChartsImagesLists of a rebase.yaml into image
+// references whose sources name the upstream charts as "<chart>:<version>".
+func (s ChartRebaseInfo) ImageReferences() map[string]*image.ImageReference {
+	chartVersions := map[string]string{s.FoundChart.Name: s.FoundChart.ChartVersion}
+	for _, dep := range s.DependencyChartVersions {
+		chartVersions[dep.Name] = dep.ChartVersion
+	}
+
+	var found []map[string]*image.ImageReference
+	for chartName, images := range s.ChartsImagesLists {
+		source := chartName + ":" + chartVersions[chartName]
+		refs := make(map[string]*image.ImageReference, images.Size())
+		for chartImage := range images {
+			img := image.Image(chartImage)
+			imageOS := image.DetectOS(img.String())
+			refs[img.String()] = &image.ImageReference{
+				Image:   img,
+				Sources: []string{source},
+				OS:      imageOS,
+				OSList:  []string{imageOS},
+			}
+		}
+		found = append(found, refs)
+	}
+	return image.MergeImageSources(found...)
+}
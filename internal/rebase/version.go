@@ -0,0 +1,50 @@
+package rebase
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var rancherReleasePattern = regexp.MustCompile(`-rancher\.(\d+)`)
+
+// ExtractRancherRelease returns the N of the "-rancher.N" suffix of a package version.
+func ExtractRancherRelease(version string) (int, error) {
+	matches := rancherReleasePattern.FindStringSubmatch(version)
+	if len(matches) < 2 {
+		return 0, fmt.Errorf("no rancher release found in version: %s", version)
+	}
+	var release int
+	_, err := fmt.Sscanf(matches[1], "%d", &release)
+	return release, err
+}
+
+// UpstreamVersion returns the upstream chart version a package version is based on, e.g.
+// "77.9.1" for both "77.9.1-rancher.3" and "108.0.0+up77.9.1-rancher.3".
+func UpstreamVersion(version string) string {
+	base := strings.Split(version, "-rancher.")[0]
+	if _, upstream, found := strings.Cut(base, "+up"); found {
+		return upstream
+	}
+	return base
+}
+
+// NextPackageVersion returns the package version that follows current when the package is
+// rebased onto upstreamVersion: the next rancher release when the upstream version is
+// unchanged, or the first rancher release of the new upstream version. A "+up" prefix,
+// e.g. the "108.0.0" of "108.0.0+up77.9.1-rancher.3", is kept as is.
+func NextPackageVersion(current, upstreamVersion string) (string, error) {
+	release, err := ExtractRancherRelease(current)
+	if err != nil {
+		return "", err
+	}
+
+	base := strings.Split(current, "-rancher.")[0]
+	if UpstreamVersion(current) == upstreamVersion {
+		return fmt.Sprintf("%s-rancher.%d", base, release+1), nil
+	}
+	if prefix, _, found := strings.Cut(base, "+up"); found {
+		return fmt.Sprintf("%s+up%s-rancher.1", prefix, upstreamVersion), nil
+	}
+	return upstreamVersion + "-rancher.1", nil
+}
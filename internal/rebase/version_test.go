@@ -0,0 +1,68 @@
+package rebase
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextPackageVersion(t *testing.T) {
+	cases := []struct {
+		current  string
+		upstream string
+		want     string
+		wantErr  bool
+	}{
+		{"77.9.1-rancher.3", "77.9.1", "77.9.1-rancher.4", false},
+		{"77.9.1-rancher.3", "78.0.0", "78.0.0-rancher.1", false},
+		{"108.0.0+up77.9.1-rancher.3", "77.9.1", "108.0.0+up77.9.1-rancher.4", false},
+		{"108.0.0+up77.9.1-rancher.3", "78.0.0", "108.0.0+up78.0.0-rancher.1", false},
+		{"1.0.0", "1.0.1", "", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.current+"->"+tc.upstream, func(t *testing.T) {
+			got, err := NextPackageVersion(tc.current, tc.upstream)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestUpstreamVersion(t *testing.T) {
+	assert.Equal(t, "77.9.1", UpstreamVersion("77.9.1-rancher.3"))
+	assert.Equal(t, "77.9.1", UpstreamVersion("108.0.0+up77.9.1-rancher.3"))
+	assert.Equal(t, "77.9.1", UpstreamVersion("77.9.1"))
+}
+
+func TestExtractRancherRelease(t *testing.T) {
+	cases := []struct {
+		input       string
+		wantRelease int
+		wantErr     bool
+	}{
+		{"1.0.0-rancher.1", 1, false},
+		{"1.0.0-rancher.3", 3, false},
+		{"1.0.0-rancher.10", 10, false},
+		{"77.0.0+up12.0.0-rancher.2", 2, false},
+		{"1.0.0", 0, true},
+		{"1.0.0-rc.1", 0, true},
+		{"", 0, true},
+		{"rancher.3", 0, true}, // no dash before "rancher"
+	}
+	for _, tc := range cases {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := ExtractRancherRelease(tc.input)
+			if tc.wantErr {
+				assert.Error(t, err, "ExtractRancherRelease(%q)", tc.input)
+			} else {
+				assert.NoError(t, err, "ExtractRancherRelease(%q)", tc.input)
+				assert.Equal(t, tc.wantRelease, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package upstream
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+var (
+	// KubePrometheusStack is the upstream of rancher-monitoring.
+	KubePrometheusStack = Chart{
+		Name:                 "kube-prometheus-stack",
+		Repository:           RepositoryPrometheus,
+		ChartPath:            "charts/kube-prometheus-stack",
+		TagPrefix:            "kube-prometheus-stack-",
+		ExcludedDependencies: []string{"crds"},
+		DependencyRepositories: map[string]Repository{
+			"grafana":                     RepositoryGrafana,
+			"kube-state-metrics":          RepositoryPrometheus,
+			"prometheus-node-exporter":    RepositoryPrometheus,
+			"prometheus-windows-exporter": RepositoryPrometheus,
+		},
+	}
+	// LoggingOperator is the upstream of rancher-logging. Its releases are tagged with the bare
+	// version, and its only dependency is the excluded CRD chart.
+	LoggingOperator = Chart{
+		Name:                 "logging-operator",
+		Repository:           RepositoryLoggingOperator,
+		ChartPath:            "charts/logging-operator",
+		ExcludedDependencies: []string{"logging-operator-crds"},
+	}
+)
+
+// Tag returns the release tag of a chart version.
+func (c Chart) Tag(version string) string {
+	return c.TagPrefix + version
+}
+
+// ChartYAMLURL builds the raw GitHub URL for the chart's Chart.yaml file at a commit.
+func (c Chart) ChartYAMLURL(commitHash string) string {
+	return c.rawFileURL(commitHash, "Chart.yaml")
+}
+
+// ValuesYAMLURL builds the raw GitHub URL for the chart's values.yaml file at a commit.
+func (c Chart) ValuesYAMLURL(commitHash string) string {
+	return c.rawFileURL(commitHash, "values.yaml")
+}
+
+// IncludesDependency reports whether a chart dependency is collected during a rebase.
+func (c Chart) IncludesDependency(name string) bool {
+	return !slices.Contains(c.ExcludedDependencies, name)
+}
+
+// Dependency returns the descriptor of a dependency of the chart, declared in a Chart.yaml
+// with the chartRepository Helm repository URL. The git repository is taken from
+// DependencyRepositories when set there, and otherwise derived from chartRepository (see
+// RepositoryFromChartRepository); false is returned when neither names one. Dependencies
+// are expected in charts/<name> and tagged <name>-<version>, as chart-releaser does.
+func (c Chart) Dependency(name, chartRepository string) (Chart, bool) {
+	repo, ok := c.DependencyRepositories[name]
+	if !ok {
+		repo, ok = RepositoryFromChartRepository(chartRepository)
+	}
+	if !ok {
+		return Chart{}, false
+	}
+	return Chart{
+		Name:       name,
+		Repository: repo,
+		ChartPath:  "charts/" + name,
+		TagPrefix:  name + "-",
+	}, true
+}
+
+// RepositoryFromChartRepository returns the git repository behind a Helm chart repository
+// published with chart-releaser: https://<owner>.github.io/<repo> is served from the
+// gh-pages branch of https://github.com/<owner>/<repo>.git. GitHub repository URLs are
+// returned as is.
+func RepositoryFromChartRepository(chartRepository string) (Repository, bool) {
+	u, err := url.Parse(strings.TrimSpace(chartRepository))
+	if err != nil || u.Scheme != "https" {
+		return "", false
+	}
+	path := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case strings.HasSuffix(u.Host, ".github.io") && path[0] != "":
+		owner := strings.TrimSuffix(u.Host, ".github.io")
+		return Repository(fmt.Sprintf("https://github.com/%s/%s.git", owner, path[0])), true
+	case u.Host == "github.com" && len(path) >= 2:
+		return Repository(fmt.Sprintf("https://github.com/%s/%s.git", path[0], strings.TrimSuffix(path[1], ".git"))), true
+	}
+	return "", false
+}
+
+func (c Chart) rawFileURL(commitHash string, file string) string {
+	if commitHash == "" {
+		return ""
+	}
+	repoURL := strings.TrimSuffix(string(c.Repository), ".git")
+	return fmt.Sprintf("%s/raw/%s/%s/%s", repoURL, commitHash, strings.Trim(c.ChartPath, "/"), file)
+}
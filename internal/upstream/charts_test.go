@@ -0,0 +1,66 @@
+package upstream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChart(t *testing.T) {
+	assert.Equal(t, "kube-prometheus-stack-77.9.1", KubePrometheusStack.Tag("77.9.1"))
+	assert.Equal(t, "6.0.3", LoggingOperator.Tag("6.0.3"))
+
+	assert.Equal(t,
+		"https://github.com/prometheus-community/helm-charts/raw/abc123/charts/kube-prometheus-stack/Chart.yaml",
+		KubePrometheusStack.ChartYAMLURL("abc123"))
+	assert.Equal(t,
+		"https://github.com/kube-logging/logging-operator/raw/abc123/charts/logging-operator/values.yaml",
+		LoggingOperator.ValuesYAMLURL("abc123"))
+	assert.Empty(t, LoggingOperator.ChartYAMLURL(""))
+
+	assert.False(t, KubePrometheusStack.IncludesDependency("crds"))
+	assert.True(t, KubePrometheusStack.IncludesDependency("grafana"))
+
+	grafana, ok := KubePrometheusStack.Dependency("grafana", "https://grafana.github.io/helm-charts")
+	assert.True(t, ok)
+	assert.Equal(t, "grafana-9.1.0", grafana.Tag("9.1.0"))
+	assert.Equal(t,
+		"https://github.com/grafana-community/helm-charts/raw/abc123/charts/grafana/values.yaml",
+		grafana.ValuesYAMLURL("abc123"), "the descriptor overrides the declared repository")
+
+	// a nested dependency that the descriptor does not know
+	proxy, ok := KubePrometheusStack.Dependency("kube-rbac-proxy", "https://prometheus-community.github.io/helm-charts")
+	assert.True(t, ok)
+	assert.Equal(t, RepositoryPrometheus, proxy.Repository)
+	assert.Equal(t, "charts/kube-rbac-proxy", proxy.ChartPath)
+
+	_, ok = LoggingOperator.Dependency("grafana", "")
+	assert.False(t, ok, "dependencies are looked up in the descriptor of their upstream chart")
+	_, ok = KubePrometheusStack.Dependency("common", "oci://registry-1.docker.io/bitnamicharts")
+	assert.False(t, ok)
+}
+
+func TestRepositoryFromChartRepository(t *testing.T) {
+	cases := []struct {
+		chartRepository string
+		want            Repository
+		wantOK          bool
+	}{
+		{"https://prometheus-community.github.io/helm-charts", RepositoryPrometheus, true},
+		{"https://prometheus-community.github.io/helm-charts/", RepositoryPrometheus, true},
+		{"https://github.com/kube-logging/logging-operator.git", RepositoryLoggingOperator, true},
+		{"https://github.com/kube-logging/logging-operator", RepositoryLoggingOperator, true},
+		{"https://charts.bitnami.com/bitnami", "", false},
+		{"https://grafana.github.io", "", false},
+		{"oci://ghcr.io/prometheus-community/charts", "", false},
+		{"file://../crds", "", false},
+		{"", "", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.chartRepository, func(t *testing.T) {
+			got, ok := RepositoryFromChartRepository(tc.chartRepository)
+			assert.Equal(t, tc.wantOK, ok)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
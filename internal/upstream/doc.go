@@ -1,19 +1,25 @@
 // Package upstream provides utilities for working with upstream Helm chart repositories.
 //
 // Currently supports:
-//   - Prometheus Community charts
-//   - Grafana charts
+//   - kube-prometheus-stack and its dependencies from the Prometheus Community and Grafana charts
+//   - The kube-logging logging-operator chart, which has no collected dependencies
 //
-// # Basic Usage
+// # Rebase Targets
 //
-// Identify which repository a chart belongs to:
+// A Chart describes an upstream chart that a Rancher chart is rebased onto: where it is
+// released from, how its releases are tagged and which dependencies to skip.
 //
-//	repo := upstream.IdentifyRepository("grafana")
+//	tag := upstream.KubePrometheusStack.Tag("77.9.1")
+//	chartURL := upstream.LoggingOperator.ChartYAMLURL(commitHash)
+//	valuesURL := upstream.LoggingOperator.ValuesYAMLURL(commitHash)
 //
-// Build URLs for chart files:
+// The built URLs point to raw GitHub content.
 //
-//	chartURL := upstream.BuildChartYAMLURL("kube-prometheus-stack", commitHash)
-//	valuesURL := upstream.BuildValuesYAMLURL("kube-prometheus-stack", commitHash)
+// # Dependencies
 //
-// The built URLs point to raw GitHub content.
+// The repository a dependency is released from is derived from the Helm repository its
+// Chart.yaml declares, unless the Chart overrides it by name:
+//
+//	grafana, ok := upstream.KubePrometheusStack.Dependency("grafana", dep.Repository)
+//	chartURL := grafana.ChartYAMLURL(commitHash)
 package upstream
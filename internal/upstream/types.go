@@ -4,6 +4,24 @@ package upstream
 type Repository string
 
 const (
-	RepositoryGrafana    Repository = "https://github.com/grafana-community/helm-charts.git"
-	RepositoryPrometheus Repository = "https://github.com/prometheus-community/helm-charts.git"
+	RepositoryGrafana         Repository = "https://github.com/grafana-community/helm-charts.git"
+	RepositoryPrometheus      Repository = "https://github.com/prometheus-community/helm-charts.git"
+	RepositoryLoggingOperator Repository = "https://github.com/kube-logging/logging-operator.git"
 )
+
+// Chart describes an upstream chart that a Rancher chart is rebased onto.
+type Chart struct {
+	// Name is the chart name, e.g. "kube-prometheus-stack"
+	Name string
+	// Repository is the git repository the chart is released from
+	Repository Repository
+	// ChartPath is the chart directory within Repository, e.g. "charts/kube-prometheus-stack"
+	ChartPath string
+	// TagPrefix is prepended to a chart version to get its release tag, e.g. "kube-prometheus-stack-"
+	TagPrefix string
+	// ExcludedDependencies lists the chart dependencies that are not collected during a rebase
+	ExcludedDependencies []string
+	// DependencyRepositories overrides the repository of the chart's dependencies, including
+	// nested ones, that is otherwise derived from their Chart.yaml (see Chart.Dependency)
+	DependencyRepositories map[string]Repository
+}